@@ -0,0 +1,523 @@
+// Package dxf reads and writes 2D geometry in the ASCII Drawing Exchange Format (DXF), the common format for
+// exchanging cutting paths with laser, waterjet and plasma cutters. Entities are read into [ms2] primitives which can
+// be sampled into polylines and assembled into filled [ms2.Shape] outlines. Drawings are written as AutoCAD R12 DXF,
+// the version most widely supported by CAM software.
+//
+// Only the XY plane is considered: Z coordinates are ignored. Entities with an extrusion direction of -Z, as left
+// by mirroring in some CAD programs, are mirrored back into the XY plane. Block references (INSERT) are not expanded.
+package dxf
+
+import (
+	"strings"
+
+	math "github.com/chewxy/math32"
+	"github.com/soypat/geometry/ms2"
+)
+
+const (
+	// curveMinDepth is the minimum amount of times curves are bisected when sampled.
+	curveMinDepth = 2
+	// curveMaxDepth bounds the bisections of a curve, or of each knot span of a spline, to 2**curveMaxDepth segments.
+	curveMaxDepth = 12
+)
+
+// Drawing holds the 2D entities of a DXF drawing grouped by kind. Entities may be appended to the slices directly
+// or with the Add methods. The empty layer name refers to the default layer "0".
+type Drawing struct {
+	Lines     []Line
+	Polylines []Polyline
+	Arcs      []Arc
+	Circles   []Circle
+	Splines   []Spline
+	Ellipses  []Ellipse
+}
+
+// Line is a LINE entity, a straight segment.
+type Line struct {
+	Layer string
+	Line  ms2.Line
+}
+
+// Polyline is a LWPOLYLINE or 2D POLYLINE entity whose edges are straight segments or circular arcs.
+type Polyline struct {
+	Layer    string
+	Vertices []ms2.Vec
+	// Bulges holds the bulge of the edge starting at each vertex, the tangent of a quarter of the edge's arc angle.
+	// A zero bulge is a straight edge, positive bulges arc counter-clockwise and a bulge of 1 is a half circle.
+	// Bulges may be shorter than Vertices, missing bulges are zero.
+	Bulges []float32
+	// Closed is set when an edge joins the last vertex to the first.
+	Closed bool
+}
+
+// Arc is an ARC entity, a circular arc traced counter-clockwise from the Start to the End angle, in radians.
+type Arc struct {
+	Layer      string
+	Center     ms2.Vec
+	Radius     float32
+	Start, End float32
+}
+
+// Circle is a CIRCLE entity.
+type Circle struct {
+	Layer  string
+	Center ms2.Vec
+	Radius float32
+}
+
+// Spline is a SPLINE entity. Splines defined only by fit points have a zero Curve and are sampled as the polyline
+// through their FitPoints.
+type Spline struct {
+	Layer     string
+	Curve     ms2.NURBS
+	FitPoints []ms2.Vec
+	Closed    bool
+}
+
+// Ellipse is an ELLIPSE entity, a full ellipse or elliptical arc. Its points are
+//
+//	Center + cos(t)*Major + sin(t)*Minor
+//
+// for parameter t increasing from Start to End. A full ellipse spans a parameter range of 2π.
+type Ellipse struct {
+	Layer        string
+	Center       ms2.Vec
+	Major, Minor ms2.Vec
+	Start, End   float32
+}
+
+// AddPolygon adds a closed polyline through vertices on layer.
+func (d *Drawing) AddPolygon(layer string, vertices []ms2.Vec) {
+	d.Polylines = append(d.Polylines, Polyline{
+		Layer:    layer,
+		Vertices: append([]ms2.Vec(nil), vertices...),
+		Closed:   true,
+	})
+}
+
+// AddPolyline adds the polyline pl on layer. Polylines whose last point equals the first are added closed.
+func (d *Drawing) AddPolyline(layer string, pl ms2.Polyline) {
+	n := len(pl)
+	if n > 3 && pl[0] == pl[n-1] {
+		d.AddPolygon(layer, pl[:n-1])
+		return
+	}
+	d.Polylines = append(d.Polylines, Polyline{Layer: layer, Vertices: append([]ms2.Vec(nil), pl...)})
+}
+
+// AddArc adds a circular arc traced counter-clockwise from the start to the end angle, in radians, on layer.
+func (d *Drawing) AddArc(layer string, center ms2.Vec, radius, start, end float32) {
+	d.Arcs = append(d.Arcs, Arc{Layer: layer, Center: center, Radius: radius, Start: start, End: end})
+}
+
+// AddShape adds the rings of s as closed polylines on layer.
+func (d *Drawing) AddShape(layer string, s ms2.Shape) {
+	for _, ring := range s.Rings {
+		d.AddPolygon(layer, ring)
+	}
+}
+
+// Layers returns the names of the layers used by the drawing's entities in order of first use.
+// Layer names are case insensitive in DXF.
+func (d Drawing) Layers() []string {
+	var layers []string
+	seen := make(map[string]bool)
+	d.forEachLayer(func(layer string) {
+		layer = layerName(layer)
+		if key := strings.ToUpper(layer); !seen[key] {
+			seen[key] = true
+			layers = append(layers, layer)
+		}
+	})
+	return layers
+}
+
+// OnLayer returns a drawing with the entities of d on the named layer. Entities are shared with d.
+func (d Drawing) OnLayer(name string) Drawing {
+	name = layerName(name)
+	on := func(layer string) bool { return strings.EqualFold(layerName(layer), name) }
+	var sub Drawing
+	for _, e := range d.Lines {
+		if on(e.Layer) {
+			sub.Lines = append(sub.Lines, e)
+		}
+	}
+	for _, e := range d.Polylines {
+		if on(e.Layer) {
+			sub.Polylines = append(sub.Polylines, e)
+		}
+	}
+	for _, e := range d.Arcs {
+		if on(e.Layer) {
+			sub.Arcs = append(sub.Arcs, e)
+		}
+	}
+	for _, e := range d.Circles {
+		if on(e.Layer) {
+			sub.Circles = append(sub.Circles, e)
+		}
+	}
+	for _, e := range d.Splines {
+		if on(e.Layer) {
+			sub.Splines = append(sub.Splines, e)
+		}
+	}
+	for _, e := range d.Ellipses {
+		if on(e.Layer) {
+			sub.Ellipses = append(sub.Ellipses, e)
+		}
+	}
+	return sub
+}
+
+func (d Drawing) forEachLayer(fn func(layer string)) {
+	for _, e := range d.Lines {
+		fn(e.Layer)
+	}
+	for _, e := range d.Polylines {
+		fn(e.Layer)
+	}
+	for _, e := range d.Arcs {
+		fn(e.Layer)
+	}
+	for _, e := range d.Circles {
+		fn(e.Layer)
+	}
+	for _, e := range d.Splines {
+		fn(e.Layer)
+	}
+	for _, e := range d.Ellipses {
+		fn(e.Layer)
+	}
+}
+
+func layerName(layer string) string {
+	if layer == "" {
+		return "0"
+	}
+	return layer
+}
+
+// AppendOutlines samples the entities of the drawing into polylines whose chords lie within tolerance of the
+// curves and appends them to dst. Open polylines whose end points lie within tolerance of each other are joined,
+// so outlines drawn as separate lines and arcs are returned as a single polyline. Closed polylines repeat their
+// first point at the end. tolerance must be positive.
+func (d Drawing) AppendOutlines(dst []ms2.Polyline, tolerance float32) ([]ms2.Polyline, error) {
+	if tolerance <= 0 {
+		panic("tolerance must be positive")
+	}
+	var pieces []ms2.Polyline
+	for _, e := range d.Lines {
+		pieces = append(pieces, ms2.Polyline{e.Line[0], e.Line[1]})
+	}
+	for _, e := range d.Polylines {
+		pl, err := e.AppendPolyline(nil, tolerance)
+		if err != nil {
+			return dst, err
+		}
+		pieces = append(pieces, pl)
+	}
+	for _, e := range d.Arcs {
+		pieces = append(pieces, e.AppendPolyline(nil, tolerance))
+	}
+	for _, e := range d.Circles {
+		pieces = append(pieces, e.AppendPolyline(nil, tolerance))
+	}
+	for _, e := range d.Splines {
+		pieces = append(pieces, e.AppendPolyline(nil, tolerance))
+	}
+	for _, e := range d.Ellipses {
+		pieces = append(pieces, e.AppendPolyline(nil, tolerance))
+	}
+	var open []ms2.Polyline
+	for _, pl := range pieces {
+		if len(pl) < 2 {
+			continue
+		} else if isClosed(pl) {
+			dst = append(dst, pl)
+		} else {
+			open = append(open, pl)
+		}
+	}
+	return appendJoined(dst, open, tolerance), nil
+}
+
+// AppendShape appends the closed outlines of the drawing as rings to dst and normalizes the
+// orientation of the resulting shape, see [Drawing.AppendOutlines] and [ms2.Shape.Normalize].
+// Outlines that remain open after joining are discarded.
+func (d Drawing) AppendShape(dst ms2.Shape, tolerance float32) (ms2.Shape, error) {
+	outlines, err := d.AppendOutlines(nil, tolerance)
+	if err != nil {
+		return dst, err
+	}
+	for _, pl := range outlines {
+		if isClosed(pl) {
+			dst.Rings = append(dst.Rings, pl[:len(pl)-1])
+		}
+	}
+	dst.Normalize()
+	return dst, nil
+}
+
+// appendJoined joins open polylines whose end points lie within tolerance and appends the results to dst.
+func appendJoined(dst, open []ms2.Polyline, tolerance float32) []ms2.Polyline {
+	used := make([]bool, len(open))
+	for i := range open {
+		if used[i] {
+			continue
+		}
+		used[i] = true
+		chain := append(ms2.Polyline(nil), open[i]...)
+		chain = extendChain(chain, open, used, tolerance)
+		chain.Reverse()
+		chain = extendChain(chain, open, used, tolerance)
+		chain.Reverse()
+		if n := len(chain); n > 2 && near(chain[0], chain[n-1], tolerance) {
+			chain[n-1] = chain[0]
+		}
+		dst = append(dst, chain)
+	}
+	return dst
+}
+
+// extendChain appends unused polylines starting or ending near the end of chain until the chain closes or no
+// polyline continues it.
+func extendChain(chain ms2.Polyline, open []ms2.Polyline, used []bool, tolerance float32) ms2.Polyline {
+	for extended := true; extended; {
+		extended = false
+		end := chain[len(chain)-1]
+		if len(chain) > 2 && near(chain[0], end, tolerance) {
+			break
+		}
+		for j, pl := range open {
+			if used[j] {
+				continue
+			}
+			last := len(pl) - 1
+			if near(pl[0], end, tolerance) {
+				chain = append(chain, pl[1:]...)
+			} else if near(pl[last], end, tolerance) {
+				for k := last - 1; k >= 0; k-- {
+					chain = append(chain, pl[k])
+				}
+			} else {
+				continue
+			}
+			used[j] = true
+			extended = true
+			break
+		}
+	}
+	return chain
+}
+
+func near(a, b ms2.Vec, tolerance float32) bool {
+	return ms2.Norm(ms2.Sub(a, b)) <= tolerance
+}
+
+func isClosed(pl ms2.Polyline) bool {
+	return len(pl) > 3 && pl[0] == pl[len(pl)-1]
+}
+
+// PolygonBuilder returns a [ms2.PolygonBuilder] with the vertices of the polyline where bulged edges are
+// [ms2.PolygonControlPoint.Arc]s discretized so their chords lie within tolerance of the arc.
+// Arcs spanning more than a quarter circle are split at their midpoints, since arcs near a half circle
+// are snapped to an exact half circle by [ms2.PolygonBuilder]. Repeated vertices are dropped.
+// If the polyline is open the builder's closing edge is straight. tolerance must be positive.
+func (p Polyline) PolygonBuilder(tolerance float32) ms2.PolygonBuilder {
+	if tolerance <= 0 {
+		panic("tolerance must be positive")
+	}
+	var pb ms2.PolygonBuilder
+	verts, bulges := p.dedup()
+	if len(verts) == 0 {
+		return pb
+	}
+	var points []ms2.Vec
+	var incoming []float32 // Bulge of the edge reaching each point.
+	for i, v := range verts {
+		if i == 0 {
+			points, incoming = append(points, v), append(incoming, 0)
+			continue
+		}
+		points, incoming = appendBulgeEdge(points, incoming, verts[i-1], v, bulges[i-1])
+	}
+	if p.Closed && len(verts) > 1 {
+		n := len(verts)
+		points, incoming = appendBulgeEdge(points, incoming, verts[n-1], verts[0], bulges[n-1])
+		// The closing edge reaches the first point.
+		incoming[0] = incoming[len(incoming)-1]
+		points, incoming = points[:len(points)-1], incoming[:len(incoming)-1]
+	}
+	prev := points[len(points)-1]
+	for i, v := range points {
+		cp := pb.Add(v)
+		if b := incoming[i]; b != 0 {
+			chord := ms2.Norm(ms2.Sub(v, prev))
+			radius := chord * (1 + b*b) / (4 * b)
+			cp.Arc(radius, arcFacets(radius, 4*math.Atan(b), tolerance))
+		}
+		prev = v
+	}
+	return pb
+}
+
+// appendBulgeEdge appends the end point of the edge from a to b with the bulge of the edge reaching it.
+// Edges spanning more than a quarter circle are split recursively at the arc midpoint.
+func appendBulgeEdge(points []ms2.Vec, incoming []float32, a, b ms2.Vec, bulge float32) ([]ms2.Vec, []float32) {
+	if math.Abs(bulge) > math.Tan(math.Pi/8) {
+		// The arc lies to the right of the chord for positive bulges, at a sagitta of bulge*chord/2.
+		chord := ms2.Sub(b, a)
+		mid := ms2.Add(ms2.Scale(0.5, ms2.Add(a, b)), ms2.Scale(bulge/2, ms2.Vec{X: chord.Y, Y: -chord.X}))
+		bulge = math.Tan(math.Atan(bulge) / 2)
+		points, incoming = appendBulgeEdge(points, incoming, a, mid, bulge)
+		return appendBulgeEdge(points, incoming, mid, b, bulge)
+	}
+	return append(points, b), append(incoming, bulge)
+}
+
+// dedup returns the vertices of the polyline without repeated consecutive vertices and the bulges of their edges.
+func (p Polyline) dedup() (verts []ms2.Vec, bulges []float32) {
+	for i, v := range p.Vertices {
+		var b float32
+		if i < len(p.Bulges) {
+			b = p.Bulges[i]
+		}
+		if n := len(verts); n > 0 && verts[n-1] == v {
+			// Drop the zero length edge reaching v.
+			bulges[n-1] = b
+			continue
+		}
+		verts, bulges = append(verts, v), append(bulges, b)
+	}
+	for p.Closed && len(verts) > 1 && verts[len(verts)-1] == verts[0] {
+		verts, bulges = verts[:len(verts)-1], bulges[:len(bulges)-1]
+	}
+	return verts, bulges
+}
+
+// AppendPolyline appends the points of the polyline with its arcs discretized within tolerance to dst, see
+// [Polyline.PolygonBuilder]. Closed polylines repeat their first point at the end.
+func (p Polyline) AppendPolyline(dst ms2.Polyline, tolerance float32) (ms2.Polyline, error) {
+	if verts, _ := p.dedup(); len(verts) < 2 {
+		return append(dst, verts...), nil
+	}
+	pb := p.PolygonBuilder(tolerance)
+	start := len(dst)
+	vecs, err := pb.AppendVecs(dst)
+	if err != nil {
+		return dst, err
+	}
+	dst = vecs
+	if p.Closed && len(dst) > start {
+		dst = append(dst, dst[start])
+	}
+	return dst, nil
+}
+
+// AppendPolyline appends the points of the arc to dst, including its end points. Chords lie within tolerance of the arc.
+func (a Arc) AppendPolyline(dst ms2.Polyline, tolerance float32) ms2.Polyline {
+	sweep := math.Mod(a.End-a.Start, 2*math.Pi)
+	if sweep <= 0 {
+		sweep += 2 * math.Pi
+	}
+	return appendArc(dst, a.Center, a.Radius, a.Start, sweep, tolerance)
+}
+
+// AppendPolyline appends the points of the circle starting at angle zero to dst. Chords lie within
+// tolerance of the circle. The first point is repeated at the end.
+func (c Circle) AppendPolyline(dst ms2.Polyline, tolerance float32) ms2.Polyline {
+	start := len(dst)
+	dst = appendArc(dst, c.Center, c.Radius, 0, 2*math.Pi, tolerance)
+	dst[len(dst)-1] = dst[start]
+	return dst
+}
+
+func appendArc(dst ms2.Polyline, center ms2.Vec, radius, start, sweep, tolerance float32) ms2.Polyline {
+	if tolerance <= 0 {
+		panic("tolerance must be positive")
+	}
+	n := arcFacets(radius, sweep, tolerance)
+	for i := 0; i <= n; i++ {
+		s, c := math.Sincos(start + sweep*float32(i)/float32(n))
+		dst = append(dst, ms2.Add(center, ms2.Vec{X: radius * c, Y: radius * s}))
+	}
+	return dst
+}
+
+// maxArcFacets limits the amount of chords of arcs with a tolerance negligible compared to their radius.
+const maxArcFacets = 1 << 16
+
+// arcFacets returns the amount of chords needed to discretize an arc so chords lie within tolerance of it.
+func arcFacets(radius, angle, tolerance float32) int {
+	ratio := math.Min(tolerance/math.Abs(radius), 1)
+	// Chords subtending step have sagitta radius*(1-cos(step/2)) = 2*radius*sin²(step/4), written
+	// with the sine so small ratios do not round to a zero step.
+	step := 4 * math.Asin(math.Sqrt(ratio/2))
+	return int(math.Max(1, math.Min(maxArcFacets, math.Ceil(math.Abs(angle)/step))))
+}
+
+// AppendPolyline appends the points of the ellipse to dst, including its end points. Chords lie within
+// tolerance of the ellipse. Full ellipses repeat their first point at the end.
+func (e Ellipse) AppendPolyline(dst ms2.Polyline, tolerance float32) ms2.Polyline {
+	if tolerance <= 0 {
+		panic("tolerance must be positive")
+	}
+	end := e.End
+	for end <= e.Start {
+		end += 2 * math.Pi
+	}
+	f := func(t float32) ms2.Vec {
+		s, c := math.Sincos(t)
+		return ms2.Add(e.Center, ms2.Add(ms2.Scale(c, e.Major), ms2.Scale(s, e.Minor)))
+	}
+	start := len(dst)
+	dst = append(dst, f(e.Start))
+	dst = appendCurve(dst, f, e.Start, end, tolerance, 0)
+	if end-e.Start >= 2*math.Pi-1e-5 {
+		dst[len(dst)-1] = dst[start]
+	}
+	return dst
+}
+
+// AppendPolyline appends the points of the spline to dst, including its end points. Chords lie within tolerance
+// of the curve. Closed splines repeat their first point at the end.
+func (s Spline) AppendPolyline(dst ms2.Polyline, tolerance float32) ms2.Polyline {
+	if tolerance <= 0 {
+		panic("tolerance must be positive")
+	}
+	start := len(dst)
+	if s.Curve.Degree() == 0 {
+		dst = append(dst, s.FitPoints...)
+	} else {
+		knots := s.Curve.Knots()
+		t0, t1 := s.Curve.Domain()
+		dst = append(dst, s.Curve.Evaluate(t0))
+		for i := 1; i < len(knots); i++ {
+			lo, hi := math.Max(knots[i-1], t0), math.Min(knots[i], t1)
+			if lo < hi {
+				dst = appendCurve(dst, s.Curve.Evaluate, lo, hi, tolerance, 0)
+			}
+		}
+	}
+	if s.Closed && len(dst)-start > 2 {
+		if dst[len(dst)-1] != dst[start] {
+			dst = append(dst, dst[start])
+		}
+	}
+	return dst
+}
+
+// appendCurve appends the points of f over (t0,t1] to dst, bisecting the parameter range until the
+// chord lies within tolerance of the curve midpoint.
+func appendCurve(dst ms2.Polyline, f func(float32) ms2.Vec, t0, t1, tolerance float32, depth int) ms2.Polyline {
+	p0, p1 := f(t0), f(t1)
+	tm := (t0 + t1) / 2
+	pm := f(tm)
+	closest, _ := ms2.Line{p0, p1}.Closest(pm)
+	if depth < curveMaxDepth && (depth < curveMinDepth || ms2.Norm(ms2.Sub(pm, closest)) > tolerance) {
+		dst = appendCurve(dst, f, t0, tm, tolerance, depth+1)
+		return appendCurve(dst, f, tm, t1, tolerance, depth+1)
+	}
+	return append(dst, p1)
+}
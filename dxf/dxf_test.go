@@ -0,0 +1,475 @@
+package dxf
+
+import (
+	"strings"
+	"testing"
+
+	math "github.com/chewxy/math32"
+	"github.com/soypat/geometry/ms2"
+)
+
+// testDXF has on layer CUT a closed LWPOLYLINE square with a half circle bulging out of its right side and a
+// circular hole, next to the same outline drawn as separate lines, an arc and a POLYLINE. Layer ENGRAVE holds
+// a mirrored arc, a quadratic Bézier spline, an ellipse and a polygon mesh which is ignored.
+const testDXF = `  0
+SECTION
+  2
+HEADER
+  9
+$ACADVER
+  1
+AC1015
+  0
+ENDSEC
+  0
+SECTION
+  2
+BLOCKS
+  0
+LINE
+  8
+CUT
+ 10
+100
+ 20
+100
+ 11
+200
+ 21
+200
+  0
+ENDSEC
+  0
+SECTION
+  2
+ENTITIES
+  0
+LWPOLYLINE
+  5
+2F
+100
+AcDbPolyline
+  8
+CUT
+ 90
+4
+ 70
+1
+ 10
+0
+ 20
+0
+ 10
+10
+ 20
+0
+ 42
+1
+ 10
+10
+ 20
+10
+ 10
+0.0
+ 20
+10.0
+  0
+CIRCLE
+  8
+CUT
+ 10
+5
+ 20
+5
+ 30
+0
+ 40
+2
+  0
+LINE
+  8
+CUT
+ 10
+20
+ 20
+0
+ 11
+30
+ 21
+0
+  0
+LINE
+  8
+CUT
+ 10
+20
+ 20
+10
+ 11
+30
+ 21
+10
+  0
+ARC
+  8
+CUT
+ 10
+30
+ 20
+5
+ 40
+5
+ 50
+270
+ 51
+90
+  0
+POLYLINE
+  8
+CUT
+ 66
+1
+ 70
+0
+  0
+VERTEX
+  8
+CUT
+ 10
+20
+ 20
+10
+  0
+VERTEX
+  8
+CUT
+ 10
+20
+ 20
+0
+  0
+SEQEND
+  0
+ARC
+  8
+ENGRAVE
+ 10
+-40
+ 20
+5
+ 40
+5
+ 50
+0
+ 51
+90
+210
+0
+220
+0
+230
+-1
+  0
+SPLINE
+  8
+ENGRAVE
+ 70
+8
+ 71
+2
+ 72
+6
+ 73
+3
+ 40
+0
+ 40
+0
+ 40
+0
+ 40
+1
+ 40
+1
+ 40
+1
+ 10
+0
+ 20
+20
+ 10
+5
+ 20
+30
+ 10
+10
+ 20
+20
+  0
+ELLIPSE
+  8
+ENGRAVE
+ 10
+50
+ 20
+50
+ 11
+10
+ 21
+0
+ 40
+0.5
+ 41
+0
+ 42
+6.283185307179586
+  0
+POLYLINE
+  8
+ENGRAVE
+ 66
+1
+ 70
+64
+  0
+VERTEX
+  8
+ENGRAVE
+ 10
+1
+ 20
+1
+  0
+SEQEND
+  0
+ENDSEC
+  0
+EOF
+`
+
+func TestParse(t *testing.T) {
+	const tol = 1e-3
+	d, err := Parse([]byte(strings.ReplaceAll(testDXF, "\n", "\r\n")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(d.Lines) != 2 || len(d.Polylines) != 2 || len(d.Arcs) != 2 || len(d.Circles) != 1 ||
+		len(d.Splines) != 1 || len(d.Ellipses) != 1 {
+		t.Fatalf("unexpected entity counts %d %d %d %d %d %d", len(d.Lines), len(d.Polylines), len(d.Arcs),
+			len(d.Circles), len(d.Splines), len(d.Ellipses))
+	}
+	if layers := d.Layers(); len(layers) != 2 || layers[0] != "CUT" || layers[1] != "ENGRAVE" {
+		t.Errorf("got layers %q", layers)
+	}
+	lw := d.Polylines[0]
+	if !lw.Closed || len(lw.Vertices) != 4 || lw.Bulges[1] != 1 || lw.Vertices[3] != (ms2.Vec{X: 0, Y: 10}) {
+		t.Errorf("bad LWPOLYLINE %+v", lw)
+	}
+	if pl := d.Polylines[1]; pl.Closed || len(pl.Vertices) != 2 || pl.Vertices[1] != (ms2.Vec{X: 20}) {
+		t.Errorf("bad POLYLINE %+v", pl)
+	}
+
+	// Mirrored arc from (40,10) to (35,5) counter-clockwise.
+	arc := d.Arcs[1].AppendPolyline(nil, tol)
+	if !ms2.EqualElem(arc[0], ms2.Vec{X: 40, Y: 10}, tol) || !ms2.EqualElem(arc[len(arc)-1], ms2.Vec{X: 35, Y: 5}, tol) {
+		t.Errorf("mirrored arc from %v to %v", arc[0], arc[len(arc)-1])
+	}
+	for _, p := range arc {
+		if r := ms2.Norm(ms2.Sub(p, ms2.Vec{X: 40, Y: 5})); math.Abs(r-5) > tol {
+			t.Fatalf("arc point %v at radius %g", p, r)
+		}
+	}
+	spline := d.Splines[0].AppendPolyline(nil, tol)
+	if !ms2.EqualElem(spline[0], ms2.Vec{X: 0, Y: 20}, tol) || !ms2.EqualElem(spline[len(spline)-1], ms2.Vec{X: 10, Y: 20}, tol) {
+		t.Errorf("spline from %v to %v", spline[0], spline[len(spline)-1])
+	}
+	if mid := d.Splines[0].Curve.Evaluate(0.5); !ms2.EqualElem(mid, ms2.Vec{X: 5, Y: 25}, tol) {
+		t.Errorf("spline midpoint %v", mid)
+	}
+	ellipse := d.Ellipses[0].AppendPolyline(nil, tol)
+	if area := ms2.RingSignedArea(ellipse[:len(ellipse)-1]); math.Abs(area-math.Pi*10*5) > 0.05 {
+		t.Errorf("ellipse area %g, want %g", area, math.Pi*10*5)
+	}
+
+	// Both outlines on CUT are 10x10 squares with a half circle of radius 5, the first has a hole of radius 2.
+	cut := d.OnLayer("cut")
+	shape, err := cut.AppendShape(ms2.Shape{}, tol)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(shape.Rings) != 3 {
+		t.Fatalf("got %d rings, want 3", len(shape.Rings))
+	}
+	want := 2*(100+math.Pi*25/2) - math.Pi*4
+	if area := shape.SignedArea(); math.Abs(area-want) > 0.05 {
+		t.Errorf("shape area %g, want %g", area, want)
+	}
+	if !shape.Contains(ms2.Vec{X: 34, Y: 5}, ms2.FillNonZero) || shape.Contains(ms2.Vec{X: 5, Y: 5}, ms2.FillNonZero) {
+		t.Error("bad shape containment")
+	}
+
+	// Mirroring of a POLYLINE with negative Z extrusion does not carry over to the next POLYLINE.
+	vertex := "  0\nVERTEX\n 10\n1\n 20\n2\n 42\n0.5\n  0\nSEQEND\n"
+	d, err = Parse([]byte("  0\nSECTION\n  2\nENTITIES\n" +
+		"  0\nPOLYLINE\n  8\nA\n 66\n1\n230\n-1\n" + vertex +
+		"  0\nPOLYLINE\n  8\nB\n 66\n1\n" + vertex + "  0\nENDSEC\n  0\nEOF\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(d.Polylines) != 2 {
+		t.Fatalf("got %d polylines, want 2", len(d.Polylines))
+	}
+	if a := d.Polylines[0]; a.Vertices[0] != (ms2.Vec{X: -1, Y: 2}) || a.Bulges[0] != -0.5 {
+		t.Errorf("mirrored POLYLINE got vertex %v bulge %g", a.Vertices[0], a.Bulges[0])
+	}
+	if b := d.Polylines[1]; b.Vertices[0] != (ms2.Vec{X: 1, Y: 2}) || b.Bulges[0] != 0.5 {
+		t.Errorf("unmirrored POLYLINE got vertex %v bulge %g", b.Vertices[0], b.Bulges[0])
+	}
+
+	for _, bad := range []string{
+		"  0\nSECTION\n  2\nENTITIES\n  0\nCIRCLE\n 40\nabc\n",
+		"  0\nSECTION\n  2\nENTITIES\n  0\nVERTEX\n",
+		"  0\nSECTION\n  2\nENTITIES\nX\nCIRCLE\n",
+		"  0\nSECTION\n  2\nENTITIES\n  0",
+	} {
+		if _, err := Parse([]byte(bad)); err == nil {
+			t.Errorf("expected error parsing %q", bad)
+		}
+	}
+}
+
+func TestPolylineBulges(t *testing.T) {
+	const tol = 1e-3
+	// Three quarter and quarter circle arcs of radius sqrt(2) forming a full circle.
+	circle := Polyline{
+		Vertices: []ms2.Vec{{X: 0, Y: 0}, {X: 2, Y: 0}},
+		Bulges:   []float32{math.Tan(3 * math.Pi / 8), math.Tan(math.Pi / 8)},
+		Closed:   true,
+	}
+	pl, err := circle.AppendPolyline(nil, tol)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pl[0] != pl[len(pl)-1] {
+		t.Error("closed polyline should repeat first point")
+	}
+	if area := ms2.RingSignedArea(pl[:len(pl)-1]); math.Abs(area-2*math.Pi) > 0.01 {
+		t.Errorf("circle area %g, want %g", area, 2*math.Pi)
+	}
+	center := ms2.Vec{X: 1, Y: -1}
+	for _, p := range pl {
+		if r := ms2.Norm(ms2.Sub(p, center)); math.Abs(r-math.Sqrt2) > tol {
+			t.Fatalf("point %v at radius %g", p, r)
+		}
+	}
+	// Clockwise half circle with repeated vertices.
+	half := Polyline{
+		Vertices: []ms2.Vec{{X: 0, Y: 0}, {X: 0, Y: 0}, {X: 2, Y: 0}, {X: 2, Y: 0}},
+		Bulges:   []float32{0, -1, 0, 0},
+	}
+	pl, err = half.AppendPolyline(nil, tol)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pl[0] != (ms2.Vec{}) || pl[len(pl)-1] != (ms2.Vec{X: 2}) || len(pl) < 10 {
+		t.Fatalf("bad half circle %v", pl)
+	}
+	for _, p := range pl {
+		if p.Y < -tol {
+			t.Fatalf("clockwise half circle should lie above chord, got %v", p)
+		}
+	}
+	// Arcs just short of a half circle.
+	for _, bulge := range []float32{0.97, -0.97} {
+		nearHalf := Polyline{Vertices: []ms2.Vec{{X: 0, Y: 0}, {X: 2, Y: 0}}, Bulges: []float32{bulge}}
+		pl, err = nearHalf.AppendPolyline(nil, tol)
+		if err != nil {
+			t.Fatal(err)
+		}
+		radius := 2 * (1 + bulge*bulge) / (4 * bulge)
+		center := ms2.Vec{X: 1, Y: radius - bulge} // Arc midpoint at a sagitta of bulge below the chord.
+		for _, p := range pl {
+			if r := ms2.Norm(ms2.Sub(p, center)); math.Abs(r-math.Abs(radius)) > tol {
+				t.Fatalf("bulge %g: point %v at radius %g, want %g", bulge, p, r, math.Abs(radius))
+			}
+		}
+	}
+}
+
+func TestArcSmallTolerance(t *testing.T) {
+	// Tolerance below float32 precision relative to the radius.
+	const radius, tol = 1000, 1e-5
+	circle := Circle{Radius: radius}.AppendPolyline(nil, tol)
+	if len(circle) < 1000 || circle[0] != circle[len(circle)-1] {
+		t.Fatalf("bad circle with %d points", len(circle))
+	}
+	arc := Arc{Radius: radius, End: math.Pi / 2}.AppendPolyline(nil, tol)
+	if len(arc) < 250 {
+		t.Fatalf("bad quarter arc with %d points", len(arc))
+	}
+	for _, p := range append(circle, arc...) {
+		if r := ms2.Norm(p); math.Abs(r-radius) > 1e-2 {
+			t.Fatalf("point %v at radius %g", p, r)
+		}
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	const tol = 1e-3
+	var d Drawing
+	d.AddShape("part", ms2.Shape{Rings: [][]ms2.Vec{
+		{{X: 0, Y: 0}, {X: 10, Y: 0}, {X: 10, Y: 10}, {X: 0, Y: 10}},
+		{{X: 2, Y: 2}, {X: 2, Y: 8}, {X: 8, Y: 8}, {X: 8, Y: 2}},
+	}})
+	d.AddPolyline("engrave", ms2.Polyline{{X: 1, Y: 1}, {X: 2, Y: 3}, {X: 4, Y: 1.5}})
+	d.AddArc("engrave", ms2.Vec{X: 5, Y: 5}, 1.5, 0.25, 3)
+	d.Polylines = append(d.Polylines, Polyline{
+		Vertices: []ms2.Vec{{X: -1, Y: 0}, {X: -3, Y: 0}},
+		Bulges:   []float32{0.5, 1},
+		Closed:   true,
+	})
+	d.Ellipses = append(d.Ellipses, Ellipse{Layer: "engrave", Center: ms2.Vec{X: 20}, Major: ms2.Vec{Y: 2}, Minor: ms2.Vec{X: -1}, End: 2 * math.Pi})
+	data := d.AppendDXF(nil, tol)
+	got, err := Parse(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if layers := got.Layers(); len(layers) != 3 || layers[0] != "part" || layers[1] != "engrave" || layers[2] != "0" {
+		t.Errorf("got layers %q", layers)
+	}
+	if len(got.Polylines) != len(d.Polylines)+1 || len(got.Arcs) != 1 {
+		t.Fatalf("got %d polylines and %d arcs", len(got.Polylines), len(got.Arcs))
+	}
+	for i, want := range d.Polylines {
+		p := got.Polylines[i]
+		if layerName(p.Layer) != layerName(want.Layer) || p.Closed != want.Closed || len(p.Vertices) != len(want.Vertices) {
+			t.Fatalf("polyline %d: got %+v, want %+v", i, p, want)
+		}
+		for j := range want.Vertices {
+			var wantBulge float32
+			if j < len(want.Bulges) {
+				wantBulge = want.Bulges[j]
+			}
+			if p.Vertices[j] != want.Vertices[j] || p.Bulges[j] != wantBulge {
+				t.Errorf("polyline %d vertex %d: got %v bulge %g", i, j, p.Vertices[j], p.Bulges[j])
+			}
+		}
+	}
+	arc := got.Arcs[0]
+	if arc.Layer != "engrave" || arc.Center != (ms2.Vec{X: 5, Y: 5}) || arc.Radius != 1.5 ||
+		math.Abs(arc.Start-0.25) > tol || math.Abs(arc.End-3) > tol {
+		t.Errorf("bad arc %+v", arc)
+	}
+	// The ellipse is written as a closed polyline.
+	ellipse := got.Polylines[len(got.Polylines)-1]
+	if !ellipse.Closed || math.Abs(ms2.RingSignedArea(ellipse.Vertices)-2*math.Pi) > 0.01 {
+		t.Errorf("bad ellipse polyline area %g", ms2.RingSignedArea(ellipse.Vertices))
+	}
+	shape, err := got.OnLayer("part").AppendShape(ms2.Shape{}, tol)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if area := shape.SignedArea(); area != 100-36 {
+		t.Errorf("part area %g, want 64", area)
+	}
+}
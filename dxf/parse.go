@@ -0,0 +1,373 @@
+package dxf
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+	"strings"
+
+	math "github.com/chewxy/math32"
+	"github.com/soypat/geometry/ms2"
+)
+
+var (
+	errBinaryDXF      = errors.New("binary DXF is not supported")
+	errMissingValue   = errors.New("group code without value")
+	errGroupCode      = errors.New("invalid group code")
+	errNumber         = errors.New("invalid number")
+	errVertexOutside  = errors.New("VERTEX outside of POLYLINE")
+	errSplineKnots    = errors.New("SPLINE knot count does not match degree and control points")
+	errEllipseNoMajor = errors.New("ELLIPSE with zero major axis")
+)
+
+// dxfLineErr is an error at a line of a DXF file.
+type dxfLineErr struct {
+	line int
+	msg  error
+}
+
+func (lerr *dxfLineErr) Error() string {
+	return "DXF line " + strconv.Itoa(lerr.line) + ": " + lerr.msg.Error()
+}
+
+// group is a group code and value pair of a DXF file.
+type group struct {
+	code  int
+	value string
+	line  int // Line of the group code.
+}
+
+func (g group) float() (float32, error) {
+	f, err := strconv.ParseFloat(g.value, 32)
+	if err != nil {
+		return 0, &dxfLineErr{line: g.line + 1, msg: errNumber}
+	}
+	return float32(f), nil
+}
+
+// Parse reads the LINE, LWPOLYLINE, POLYLINE, ARC, CIRCLE, SPLINE and ELLIPSE entities of the ENTITIES section of
+// an ASCII DXF file. Other entities and sections are ignored. Errors report the offending line number.
+func Parse(data []byte) (Drawing, error) {
+	if bytes.HasPrefix(data, []byte("AutoCAD Binary DXF")) {
+		return Drawing{}, errBinaryDXF
+	}
+	groups, err := splitGroups(data)
+	if err != nil {
+		return Drawing{}, err
+	}
+	var p parser
+	inEntities := false
+	for i := 0; i < len(groups); {
+		start := groups[i]
+		end := i + 1
+		for end < len(groups) && groups[end].code != 0 {
+			end++
+		}
+		body := groups[i+1 : end]
+		i = end
+		if start.code != 0 {
+			continue // Groups before the first entity or section.
+		}
+		switch start.value {
+		case "SECTION":
+			inEntities = len(body) > 0 && body[0].code == 2 && body[0].value == "ENTITIES"
+			continue
+		case "ENDSEC":
+			inEntities = false
+		}
+		if inEntities {
+			err = p.parseEntity(start, body)
+			if err != nil {
+				return Drawing{}, err
+			}
+		}
+	}
+	p.endPolyline()
+	return p.d, nil
+}
+
+// splitGroups splits DXF data into its group code and value pairs.
+func splitGroups(data []byte) ([]group, error) {
+	lines := strings.Split(string(data), "\n")
+	var groups []group
+	for i := 0; i < len(lines); i += 2 {
+		codeStr := strings.TrimSpace(lines[i])
+		if i+1 >= len(lines) {
+			if codeStr == "" {
+				break // Trailing newline.
+			}
+			return nil, &dxfLineErr{line: i + 1, msg: errMissingValue}
+		}
+		code, err := strconv.Atoi(codeStr)
+		if err != nil {
+			return nil, &dxfLineErr{line: i + 1, msg: errGroupCode}
+		}
+		groups = append(groups, group{code: code, value: strings.TrimSpace(lines[i+1]), line: i + 1})
+	}
+	return groups, nil
+}
+
+// parser accumulates entities into a drawing, tracking POLYLINE entities whose vertices follow as VERTEX entities.
+type parser struct {
+	d Drawing
+	// inPolyline is set between a POLYLINE and its SEQEND. poly is nil for skipped POLYLINE meshes.
+	// polyMirror is set for a POLYLINE with negative Z extrusion until its SEQEND.
+	inPolyline bool
+	poly       *Polyline
+	polyMirror bool
+}
+
+func (p *parser) endPolyline() {
+	if p.poly != nil {
+		p.d.Polylines = append(p.d.Polylines, *p.poly)
+	}
+	p.inPolyline, p.poly, p.polyMirror = false, nil, false
+}
+
+func (p *parser) parseEntity(start group, body []group) (err error) {
+	kind := start.value
+	if kind != "VERTEX" && kind != "SEQEND" {
+		p.endPolyline()
+	}
+	switch kind {
+	case "LINE":
+		var e Line
+		err = parseGroups(body, &e.Layer, nil, func(g group, v float32) {
+			setCoord(&e.Line[0], &e.Line[1], g.code, v)
+		})
+		p.d.Lines = append(p.d.Lines, e)
+	case "CIRCLE":
+		var e Circle
+		var mirror bool
+		err = parseGroups(body, &e.Layer, &mirror, func(g group, v float32) {
+			setCoord(&e.Center, nil, g.code, v)
+			if g.code == 40 {
+				e.Radius = v
+			}
+		})
+		e.Center = mirrorOCS(e.Center, mirror)
+		p.d.Circles = append(p.d.Circles, e)
+	case "ARC":
+		var e Arc
+		var mirror bool
+		err = parseGroups(body, &e.Layer, &mirror, func(g group, v float32) {
+			setCoord(&e.Center, nil, g.code, v)
+			switch g.code {
+			case 40:
+				e.Radius = v
+			case 50:
+				e.Start = v * math.Pi / 180
+			case 51:
+				e.End = v * math.Pi / 180
+			}
+		})
+		if mirror {
+			e.Center = mirrorOCS(e.Center, mirror)
+			e.Start, e.End = math.Pi-e.End, math.Pi-e.Start
+		}
+		p.d.Arcs = append(p.d.Arcs, e)
+	case "LWPOLYLINE":
+		var e Polyline
+		var mirror bool
+		err = parseGroups(body, &e.Layer, &mirror, func(g group, v float32) {
+			n := len(e.Vertices)
+			switch {
+			case g.code == 10:
+				e.Vertices = append(e.Vertices, ms2.Vec{X: v})
+				e.Bulges = append(e.Bulges, 0)
+			case g.code == 20 && n > 0:
+				e.Vertices[n-1].Y = v
+			case g.code == 42 && n > 0:
+				e.Bulges[n-1] = v
+			case g.code == 70:
+				e.Closed = int(v)&1 != 0
+			}
+		})
+		mirrorPolyline(&e, mirror)
+		p.d.Polylines = append(p.d.Polylines, e)
+	case "POLYLINE":
+		var e Polyline
+		var flags int
+		err = parseGroups(body, &e.Layer, &p.polyMirror, func(g group, v float32) {
+			if g.code == 70 {
+				flags = int(v)
+			}
+		})
+		p.inPolyline = true
+		if flags&(16|64) != 0 {
+			break // Polygon and polyface meshes.
+		}
+		e.Closed = flags&1 != 0
+		p.poly = &e
+	case "VERTEX":
+		if !p.inPolyline {
+			return &dxfLineErr{line: start.line, msg: errVertexOutside}
+		} else if p.poly == nil {
+			break
+		}
+		var v ms2.Vec
+		var bulge float32
+		var flags int
+		err = parseGroups(body, nil, nil, func(g group, f float32) {
+			setCoord(&v, nil, g.code, f)
+			switch g.code {
+			case 42:
+				bulge = f
+			case 70:
+				flags = int(f)
+			}
+		})
+		if flags&16 != 0 {
+			break // Spline frame control point.
+		}
+		if p.polyMirror {
+			v, bulge = mirrorOCS(v, true), -bulge
+		}
+		p.poly.Vertices = append(p.poly.Vertices, v)
+		p.poly.Bulges = append(p.poly.Bulges, bulge)
+	case "SEQEND":
+		p.endPolyline()
+	case "SPLINE":
+		err = p.parseSpline(start, body)
+	case "ELLIPSE":
+		var e Ellipse
+		var ratio float32
+		var mirror bool
+		err = parseGroups(body, &e.Layer, &mirror, func(g group, v float32) {
+			setCoord(&e.Center, &e.Major, g.code, v)
+			switch g.code {
+			case 40:
+				ratio = v
+			case 41:
+				e.Start = v
+			case 42:
+				e.End = v
+			}
+		})
+		if e.Major == (ms2.Vec{}) {
+			return &dxfLineErr{line: start.line, msg: errEllipseNoMajor}
+		}
+		// The minor axis is the major axis rotated a quarter turn about the extrusion direction.
+		e.Minor = ms2.Scale(ratio, ms2.Vec{X: -e.Major.Y, Y: e.Major.X})
+		if mirror {
+			e.Minor = ms2.Scale(-1, e.Minor)
+		}
+		p.d.Ellipses = append(p.d.Ellipses, e)
+	}
+	return err
+}
+
+func (p *parser) parseSpline(start group, body []group) error {
+	var e Spline
+	var degree, flags int
+	var knots, weights []float32
+	var ctl []ms2.Vec
+	err := parseGroups(body, &e.Layer, nil, func(g group, v float32) {
+		switch g.code {
+		case 10:
+			ctl = append(ctl, ms2.Vec{X: v})
+		case 20:
+			if len(ctl) > 0 {
+				ctl[len(ctl)-1].Y = v
+			}
+		case 11:
+			e.FitPoints = append(e.FitPoints, ms2.Vec{X: v})
+		case 21:
+			if len(e.FitPoints) > 0 {
+				e.FitPoints[len(e.FitPoints)-1].Y = v
+			}
+		case 40:
+			knots = append(knots, v)
+		case 41:
+			weights = append(weights, v)
+		case 70:
+			flags = int(v)
+		case 71:
+			degree = int(v)
+		}
+	})
+	if err != nil {
+		return err
+	}
+	e.Closed = flags&1 != 0
+	if len(ctl) > 0 {
+		if len(knots) != len(ctl)+degree+1 {
+			return &dxfLineErr{line: start.line, msg: errSplineKnots}
+		}
+		if len(weights) != len(ctl) {
+			weights = nil
+		}
+		e.Curve, err = ms2.NewNURBS(degree, ctl, weights, knots)
+		if err != nil {
+			return &dxfLineErr{line: start.line, msg: err}
+		}
+	}
+	p.d.Splines = append(p.d.Splines, e)
+	return nil
+}
+
+// parseGroups parses the layer and extrusion groups of an entity and calls fn with the other numeric groups.
+// mirror is set if the extrusion direction is -Z.
+func parseGroups(body []group, layer *string, mirror *bool, fn func(g group, v float32)) error {
+	for _, g := range body {
+		switch {
+		case g.code == 8:
+			if layer != nil {
+				*layer = g.value
+			}
+			continue
+		case g.code == 230:
+			z, err := g.float()
+			if err != nil {
+				return err
+			}
+			if mirror != nil {
+				*mirror = z < 0
+			}
+			continue
+		case g.code < 10 || g.code >= 100 && g.code < 200 || g.code >= 300:
+			continue // Strings, handles and other non-numeric groups.
+		}
+		v, err := g.float()
+		if err != nil {
+			return err
+		}
+		fn(g, v)
+	}
+	return nil
+}
+
+// setCoord sets the X and Y coordinates of p from groups 10 and 20 and of q from groups 11 and 21.
+func setCoord(p, q *ms2.Vec, code int, v float32) {
+	switch {
+	case code == 10:
+		p.X = v
+	case code == 20:
+		p.Y = v
+	case code == 11 && q != nil:
+		q.X = v
+	case code == 21 && q != nil:
+		q.Y = v
+	}
+}
+
+// mirrorOCS maps a point of an object coordinate system with extrusion -Z to world coordinates
+// by the arbitrary axis algorithm, which mirrors the X axis.
+func mirrorOCS(p ms2.Vec, mirror bool) ms2.Vec {
+	if mirror {
+		p.X = -p.X
+	}
+	return p
+}
+
+func mirrorPolyline(e *Polyline, mirror bool) {
+	if !mirror {
+		return
+	}
+	for i := range e.Vertices {
+		e.Vertices[i] = mirrorOCS(e.Vertices[i], true)
+	}
+	for i := range e.Bulges {
+		e.Bulges[i] = -e.Bulges[i]
+	}
+}
@@ -0,0 +1,141 @@
+package dxf
+
+import (
+	"strconv"
+
+	math "github.com/chewxy/math32"
+	"github.com/soypat/geometry/ms2"
+)
+
+// AppendDXF appends the drawing encoded as an ASCII AutoCAD R12 DXF file to dst, with a layer table listing the
+// layers of its entities. Polylines are written as POLYLINE entities keeping their bulges. Splines and ellipses,
+// which R12 lacks, are written as polylines sampled within tolerance, which must be positive if the drawing has any.
+func (d Drawing) AppendDXF(dst []byte, tolerance float32) []byte {
+	dst = appendGroup(dst, 0, "SECTION")
+	dst = appendGroup(dst, 2, "HEADER")
+	dst = appendGroup(dst, 9, "$ACADVER")
+	dst = appendGroup(dst, 1, "AC1009")
+	dst = appendGroup(dst, 0, "ENDSEC")
+
+	dst = appendGroup(dst, 0, "SECTION")
+	dst = appendGroup(dst, 2, "TABLES")
+	dst = appendGroup(dst, 0, "TABLE")
+	dst = appendGroup(dst, 2, "LTYPE")
+	dst = appendIntGroup(dst, 70, 1)
+	dst = appendGroup(dst, 0, "LTYPE")
+	dst = appendGroup(dst, 2, "CONTINUOUS")
+	dst = appendIntGroup(dst, 70, 0)
+	dst = appendGroup(dst, 3, "Solid line")
+	dst = appendIntGroup(dst, 72, 65)
+	dst = appendIntGroup(dst, 73, 0)
+	dst = appendFloatGroup(dst, 40, 0)
+	dst = appendGroup(dst, 0, "ENDTAB")
+	layers := d.Layers()
+	dst = appendGroup(dst, 0, "TABLE")
+	dst = appendGroup(dst, 2, "LAYER")
+	dst = appendIntGroup(dst, 70, len(layers))
+	for _, layer := range layers {
+		dst = appendGroup(dst, 0, "LAYER")
+		dst = appendGroup(dst, 2, layer)
+		dst = appendIntGroup(dst, 70, 0)
+		dst = appendIntGroup(dst, 62, 7)
+		dst = appendGroup(dst, 6, "CONTINUOUS")
+	}
+	dst = appendGroup(dst, 0, "ENDTAB")
+	dst = appendGroup(dst, 0, "ENDSEC")
+
+	dst = appendGroup(dst, 0, "SECTION")
+	dst = appendGroup(dst, 2, "ENTITIES")
+	for _, e := range d.Lines {
+		dst = appendEntity(dst, "LINE", e.Layer)
+		dst = appendPoint(dst, 10, e.Line[0])
+		dst = appendPoint(dst, 11, e.Line[1])
+	}
+	for _, e := range d.Polylines {
+		dst = appendPolyline(dst, e)
+	}
+	for _, e := range d.Arcs {
+		dst = appendEntity(dst, "ARC", e.Layer)
+		dst = appendPoint(dst, 10, e.Center)
+		dst = appendFloatGroup(dst, 40, e.Radius)
+		dst = appendFloatGroup(dst, 50, e.Start*180/math.Pi)
+		dst = appendFloatGroup(dst, 51, e.End*180/math.Pi)
+	}
+	for _, e := range d.Circles {
+		dst = appendEntity(dst, "CIRCLE", e.Layer)
+		dst = appendPoint(dst, 10, e.Center)
+		dst = appendFloatGroup(dst, 40, e.Radius)
+	}
+	for _, e := range d.Splines {
+		dst = appendSampled(dst, e.Layer, e.AppendPolyline(nil, tolerance))
+	}
+	for _, e := range d.Ellipses {
+		dst = appendSampled(dst, e.Layer, e.AppendPolyline(nil, tolerance))
+	}
+	dst = appendGroup(dst, 0, "ENDSEC")
+	return appendGroup(dst, 0, "EOF")
+}
+
+// appendSampled appends a sampled curve as a POLYLINE entity, closed if its first point is repeated at the end.
+func appendSampled(dst []byte, layer string, pl ms2.Polyline) []byte {
+	e := Polyline{Layer: layer, Vertices: pl}
+	if isClosed(pl) {
+		e.Vertices, e.Closed = pl[:len(pl)-1], true
+	}
+	return appendPolyline(dst, e)
+}
+
+func appendPolyline(dst []byte, e Polyline) []byte {
+	dst = appendEntity(dst, "POLYLINE", e.Layer)
+	dst = appendIntGroup(dst, 66, 1) // Vertices follow.
+	dst = appendPoint(dst, 10, ms2.Vec{})
+	if e.Closed {
+		dst = appendIntGroup(dst, 70, 1)
+	}
+	for i, v := range e.Vertices {
+		dst = appendEntity(dst, "VERTEX", e.Layer)
+		dst = appendPoint(dst, 10, v)
+		if i < len(e.Bulges) && e.Bulges[i] != 0 {
+			dst = appendFloatGroup(dst, 42, e.Bulges[i])
+		}
+	}
+	return appendEntity(dst, "SEQEND", e.Layer)
+}
+
+func appendEntity(dst []byte, kind, layer string) []byte {
+	dst = appendGroup(dst, 0, kind)
+	return appendGroup(dst, 8, layerName(layer))
+}
+
+// appendPoint appends the X and Y coordinates of v with the group codes of a point, code and code+10.
+func appendPoint(dst []byte, code int, v ms2.Vec) []byte {
+	dst = appendFloatGroup(dst, code, v.X)
+	return appendFloatGroup(dst, code+10, v.Y)
+}
+
+func appendGroup(dst []byte, code int, value string) []byte {
+	dst = appendCode(dst, code)
+	dst = append(dst, value...)
+	return append(dst, '\n')
+}
+
+func appendIntGroup(dst []byte, code, value int) []byte {
+	dst = appendCode(dst, code)
+	dst = strconv.AppendInt(dst, int64(value), 10)
+	return append(dst, '\n')
+}
+
+func appendFloatGroup(dst []byte, code int, value float32) []byte {
+	dst = appendCode(dst, code)
+	dst = strconv.AppendFloat(dst, float64(value), 'f', -1, 32)
+	return append(dst, '\n')
+}
+
+// appendCode appends a group code right aligned to three characters as AutoCAD does.
+func appendCode(dst []byte, code int) []byte {
+	for w := 100; w > 1 && code < w; w /= 10 {
+		dst = append(dst, ' ')
+	}
+	dst = strconv.AppendInt(dst, int64(code), 10)
+	return append(dst, '\n')
+}
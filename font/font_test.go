@@ -0,0 +1,254 @@
+package font
+
+import (
+	"encoding/binary"
+	"testing"
+
+	math "github.com/chewxy/math32"
+	"github.com/soypat/geometry/ms2"
+)
+
+func TestTrueType(t *testing.T) {
+	data := testFont()
+	f, err := ParseTrueType(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.UnitsPerEm() != 1000 || f.NumGlyphs() != 4 {
+		t.Fatalf("got unitsPerEm=%d numGlyphs=%d", f.UnitsPerEm(), f.NumGlyphs())
+	}
+	if a, d, g := f.VerticalMetrics(); a != 900 || d != -300 || g != 100 {
+		t.Errorf("got vertical metrics %d,%d,%d", a, d, g)
+	}
+	for r, want := range map[rune]GlyphIndex{'O': 1, 'Q': 2, 'V': 3, 'R': 0, 'Z': 0, 'A': 0, 0x1f600: 0} {
+		if got := f.GlyphIndex(r); got != want {
+			t.Errorf("GlyphIndex(%q)=%d, want %d", r, got, want)
+		}
+	}
+	if f.Advance(1) != 1100 || f.Advance(3) != 1100 || f.Advance(0) != 500 {
+		t.Errorf("bad advances %d %d %d", f.Advance(0), f.Advance(1), f.Advance(3))
+	}
+	if f.Kerning(1, 3) != -100 || f.Kerning(3, 1) != 0 {
+		t.Errorf("bad kerning %d %d", f.Kerning(1, 3), f.Kerning(3, 1))
+	}
+
+	// Square with curved top and a square hole.
+	const wantArea = 1000*1000 + 2./3*1000*250 - 500*500
+	shape, err := f.AppendGlyph(ms2.Shape{}, 1, ms2.Vec{}, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(shape.Rings) != 2 {
+		t.Fatalf("got %d rings, want 2", len(shape.Rings))
+	}
+	if area := shape.SignedArea(); math.Abs(area-wantArea) > 2e-3*wantArea {
+		t.Errorf("glyph area=%g, want %g", area, float32(wantArea))
+	}
+	if ms2.RingSignedArea(shape.Rings[0]) <= 0 || ms2.RingSignedArea(shape.Rings[1]) >= 0 {
+		t.Error("outer ring should be counter-clockwise and hole clockwise")
+	}
+	// Compound glyph is the same outline offset.
+	compound, err := f.AppendGlyph(ms2.Shape{}, 2, ms2.Vec{}, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, want := compound.Bounds(), shape.Bounds().Add(ms2.Vec{X: 100, Y: -50})
+	if !ms2.EqualElem(got.Min, want.Min, 1e-3) || !ms2.EqualElem(got.Max, want.Max, 1e-3) {
+		t.Errorf("compound bounds=%v, want %v", got, want)
+	}
+	// Contour made only of off-curve points.
+	offOnly, err := f.AppendGlyph(ms2.Shape{}, 3, ms2.Vec{}, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const wantOffOnlyArea = 600*600/2 + 4*2./3*300*300/2
+	if area := offOnly.SignedArea(); math.Abs(area-wantOffOnlyArea) > 2e-3*wantOffOnlyArea {
+		t.Errorf("off-curve glyph area=%g, want %g", area, float32(wantOffOnlyArea))
+	}
+
+	text, pen, err := f.AppendText(ms2.Shape{}, "OV\nQ", ms2.Vec{X: 10, Y: 20}, 500)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(text.Rings) != 5 {
+		t.Errorf("got %d text rings, want 5", len(text.Rings))
+	}
+	if want := (ms2.Vec{X: 10 + 550, Y: 20 - 650}); !ms2.EqualElem(pen, want, 1e-3) {
+		t.Errorf("pen=%v, want %v", pen, want)
+	}
+	if w := f.TextWidth("OV\nQ", 500); math.Abs(w-1050) > 1e-3 {
+		t.Errorf("text width=%g, want 1050", w)
+	}
+
+	if _, err := ParseTrueType(nil); err == nil {
+		t.Error("expected error parsing empty data")
+	}
+	if _, err := ParseTrueType(data[:len(data)/2]); err == nil {
+		t.Error("expected error parsing truncated data")
+	}
+}
+
+func TestHershey(t *testing.T) {
+	for i, glyph := range hersheySimplex {
+		count, coords := int(glyph[0]), glyph[2:]
+		if len(coords) != 2*count {
+			t.Fatalf("glyph %q has %d coordinates, want %d", rune(i+' '), len(coords), 2*count)
+		}
+		for j := 0; j < len(coords); j += 2 {
+			x, y := coords[j], coords[j+1]
+			if x == -1 && y == -1 {
+				if j == 0 || j == len(coords)-2 {
+					t.Errorf("glyph %q begins or ends lifting the pen", rune(i+' '))
+				}
+				continue
+			}
+			if x < 0 || x > glyph[1]+2 || y < -7 || y > 25 {
+				t.Errorf("glyph %q coordinate (%d,%d) outside of glyph box", rune(i+' '), x, y)
+			}
+		}
+	}
+	var h Hershey
+	const size = 32
+	strokes, pen := h.AppendText(nil, "HI\nl", ms2.Vec{}, size)
+	if len(strokes) != 3+1+1 {
+		t.Errorf("got %d strokes, want 5", len(strokes))
+	}
+	if want := (ms2.Vec{X: 8, Y: -size}); pen != want {
+		t.Errorf("pen=%v, want %v", pen, want)
+	}
+	if w := h.TextWidth("HI\nl", size); w != 22+8 {
+		t.Errorf("text width=%g, want 30", w)
+	}
+	// 'H' crossbar at half the capital height.
+	if bar := strokes[2]; bar[0] != (ms2.Vec{X: 4, Y: 11}) || bar[1] != (ms2.Vec{X: 18, Y: 11}) {
+		t.Errorf("bad H crossbar %v", bar)
+	}
+	if h.Advance('\t') != h.Advance('?') {
+		t.Error("unsupported characters should be drawn as '?'")
+	}
+}
+
+// testFont builds a TrueType font with 4 glyphs:
+//   - 0: missing glyph without outline
+//   - 1: 'O', a 1000 unit square with a quadratic top edge bulging 250 units and a centered 500 unit square hole
+//   - 2: 'Q', compound of glyph 1 offset by (100,-50)
+//   - 3: 'V', a contour of four off-curve points on the corners of a 600 unit square
+func testFont() []byte {
+	on := func(x, y float32) ContourPoint { return ContourPoint{X: x, Y: y, OnCurve: true} }
+	off := func(x, y float32) ContourPoint { return ContourPoint{X: x, Y: y} }
+	glyphs := [][]byte{
+		nil,
+		simpleGlyph([][]ContourPoint{
+			{on(0, 0), on(0, 1000), off(500, 1500), on(1000, 1000), on(1000, 0)},
+			{on(250, 250), on(750, 250), on(750, 750), on(250, 750)},
+		}),
+		compoundGlyph(1, 100, -50),
+		simpleGlyph([][]ContourPoint{{off(0, 0), off(0, 600), off(600, 600), off(600, 0)}}),
+	}
+	var glyf, loca []byte
+	for _, g := range glyphs {
+		loca = be32(loca, uint32(len(glyf)))
+		glyf = append(glyf, g...)
+	}
+	loca = be32(loca, uint32(len(glyf)))
+
+	head := make([]byte, 54)
+	binary.BigEndian.PutUint16(head[18:], 1000)
+	binary.BigEndian.PutUint16(head[50:], 1) // Long loca offsets.
+	maxp := be16(be32(nil, 0x5000), uint16(len(glyphs)))
+	hhea := make([]byte, 36)
+	binary.BigEndian.PutUint16(hhea[4:], 900)
+	binary.BigEndian.PutUint16(hhea[6:], uint16(0x10000-300))
+	binary.BigEndian.PutUint16(hhea[8:], 100)
+	binary.BigEndian.PutUint16(hhea[34:], 3)
+	// Three long metrics, the last glyph shares the advance of glyph 2.
+	hmtx := be16(be16(be16(be16(be16(be16(be16(nil, 500), 0), 1100), 0), 1100), 0), 0)
+
+	// cmap format 4 with a delta mapped segment for 'O', a glyph array segment for 'Q'-'V' and the final segment.
+	const segCount = 3
+	glyphArray := []uint16{2, 0, 0, 0, 0, 3}
+	var sub []byte
+	sub = be16(sub, 4)
+	sub = be16(sub, uint16(16+8*segCount+2*len(glyphArray)))
+	sub = be16(sub, 0)
+	sub = be16(be16(be16(be16(sub, 2*segCount), 4), 1), 2)
+	sub = be16(be16(be16(sub, 'O'), 'V'), 0xffff) // End codes.
+	sub = be16(sub, 0)
+	sub = be16(be16(be16(sub, 'O'), 'Q'), 0xffff)            // Start codes.
+	sub = be16(be16(be16(sub, uint16(0x10000+1-'O')), 0), 1) // Deltas.
+	// Range offsets are relative to their own position: the second one is followed by one more offset then the glyph array.
+	sub = be16(be16(be16(sub, 0), 4), 0)
+	for _, g := range glyphArray {
+		sub = be16(sub, g)
+	}
+	cmap := be32(be16(be16(be16(be16(nil, 0), 1), 3), 1), 12)
+	cmap = append(cmap, sub...)
+
+	kern := be16(be16(nil, 0), 1)
+	kern = be16(be16(be16(kern, 0), 14+6), 1) // Horizontal format 0 subtable.
+	kern = be16(be16(be16(be16(kern, 1), 6), 0), 0)
+	kern = be16(be16(be16(kern, 1), 3), uint16(0x10000-100))
+
+	tables := []struct {
+		tag  string
+		data []byte
+	}{
+		{"cmap", cmap}, {"glyf", glyf}, {"head", head}, {"hhea", hhea},
+		{"hmtx", hmtx}, {"kern", kern}, {"loca", loca}, {"maxp", maxp},
+	}
+	font := be16(be16(be16(be16(be32(nil, 0x00010000), uint16(len(tables))), 0), 0), 0)
+	offset := len(font) + 16*len(tables)
+	var body []byte
+	for _, table := range tables {
+		font = append(font, table.tag...)
+		font = be32(be32(be32(font, 0), uint32(offset+len(body))), uint32(len(table.data)))
+		body = append(body, table.data...)
+	}
+	return append(font, body...)
+}
+
+func simpleGlyph(contours [][]ContourPoint) []byte {
+	var b []byte
+	b = be16(b, uint16(len(contours)))
+	b = be16(be16(be16(be16(b, 0), 0), 0), 0) // Bounding box is not used by the parser.
+	n := 0
+	for _, c := range contours {
+		n += len(c)
+		b = be16(b, uint16(n-1))
+	}
+	b = be16(b, 0) // No instructions.
+	for _, c := range contours {
+		for _, p := range c {
+			flag := byte(0)
+			if p.OnCurve {
+				flag = 1
+			}
+			b = append(b, flag)
+		}
+	}
+	// Coordinates as 16 bit deltas, x first.
+	for axis := 0; axis < 2; axis++ {
+		prev := 0
+		for _, c := range contours {
+			for _, p := range c {
+				v := int(p.X)
+				if axis == 1 {
+					v = int(p.Y)
+				}
+				b = be16(b, uint16(int16(v-prev)))
+				prev = v
+			}
+		}
+	}
+	return b
+}
+
+func compoundGlyph(component uint16, dx, dy int16) []byte {
+	b := be16(nil, 0xffff) // Negative contour count.
+	b = be16(be16(be16(be16(b, 0), 0), 0), 0)
+	b = be16(be16(b, 0x0001|0x0002), component) // Word arguments that are x,y offsets.
+	return be16(be16(b, uint16(dx)), uint16(dy))
+}
+
+func be16(b []byte, v uint16) []byte { return append(b, byte(v>>8), byte(v)) }
+func be32(b []byte, v uint32) []byte { return append(b, byte(v>>24), byte(v>>16), byte(v>>8), byte(v)) }
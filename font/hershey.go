@@ -0,0 +1,82 @@
+package font
+
+import (
+	math "github.com/chewxy/math32"
+	"github.com/soypat/geometry/ms2"
+)
+
+// hersheyEm is the size of the em square of the Hershey Roman Simplex font in font units,
+// spanning from the bottom of descenders to the top of brackets.
+const hersheyEm = 32
+
+// Hershey is the embedded single-stroke Roman Simplex font of Dr. A. V. Hershey covering printable ASCII.
+// Glyphs are drawn as open polylines, which suits engraving and plotting where tools trace strokes
+// rather than fill regions. Capitals measure 21/32 of the em size above the baseline.
+// Characters outside printable ASCII are drawn as '?'.
+type Hershey struct{}
+
+// Advance returns the horizontal advance width of the glyph of r for an em size of 1.
+func (Hershey) Advance(r rune) float32 {
+	return float32(hersheyGlyph(r)[1]) / hersheyEm
+}
+
+// AppendGlyph appends the strokes of the glyph of r to dst scaled so the em square measures size and with
+// the glyph's origin at origin. It returns the horizontal advance of the glyph.
+func (Hershey) AppendGlyph(dst []ms2.Polyline, r rune, origin ms2.Vec, size float32) ([]ms2.Polyline, float32) {
+	glyph := hersheyGlyph(r)
+	scale := size / hersheyEm
+	var stroke ms2.Polyline
+	coords := glyph[2:]
+	for i := 0; i+1 < len(coords); i += 2 {
+		x, y := coords[i], coords[i+1]
+		if x == -1 && y == -1 {
+			if len(stroke) > 1 {
+				dst = append(dst, stroke)
+			}
+			stroke = nil
+			continue
+		}
+		stroke = append(stroke, ms2.Vec{X: origin.X + scale*float32(x), Y: origin.Y + scale*float32(y)})
+	}
+	if len(stroke) > 1 {
+		dst = append(dst, stroke)
+	}
+	return dst, scale * float32(glyph[1])
+}
+
+// AppendText lays out text with the font, appends the strokes of its glyphs to dst and returns
+// the pen position after the last glyph. The em square of the glyphs measures size.
+func (h Hershey) AppendText(dst []ms2.Polyline, text string, origin ms2.Vec, size float32) ([]ms2.Polyline, ms2.Vec) {
+	pen := origin
+	for _, r := range text {
+		if r == '\n' {
+			pen = ms2.Vec{X: origin.X, Y: pen.Y - size}
+			continue
+		}
+		var advance float32
+		dst, advance = h.AppendGlyph(dst, r, pen, size)
+		pen.X += advance
+	}
+	return dst, pen
+}
+
+// TextWidth returns the width of the longest line of text laid out with the font at the argument size, see [Hershey.AppendText].
+func (h Hershey) TextWidth(text string, size float32) float32 {
+	var width, lineWidth float32
+	for _, r := range text {
+		if r == '\n' {
+			lineWidth = 0
+			continue
+		}
+		lineWidth += size * h.Advance(r)
+		width = math.Max(width, lineWidth)
+	}
+	return width
+}
+
+func hersheyGlyph(r rune) []int8 {
+	if r < ' ' || r > '~' {
+		r = '?'
+	}
+	return hersheySimplex[r-' ']
+}
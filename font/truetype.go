@@ -0,0 +1,675 @@
+// Package font converts text into geometry for engraving, plotting and cutting. It provides a dependency free
+// parser of TrueType fonts that outlines glyphs as filled [ms2.Shape]s and an embedded single-stroke Hershey font
+// that draws glyphs as [ms2.Polyline]s for environments without font files.
+//
+// Text is laid out on a baseline starting at an origin with the Y axis pointing up, advancing along +X.
+// Newlines start a new line below the previous one.
+package font
+
+import (
+	"encoding/binary"
+	"errors"
+
+	math "github.com/chewxy/math32"
+	"github.com/soypat/geometry/ms2"
+)
+
+var (
+	errNotTrueType       = errors.New("data is not a TrueType font")
+	errTruncated         = errors.New("TrueType data truncated")
+	errMissingTable      = errors.New("TrueType font missing required table (head, maxp, hhea, hmtx, loca, glyf or cmap)")
+	errBadLocaFormat     = errors.New("TrueType head table has invalid loca format")
+	errBadGlyphIndex     = errors.New("glyph index out of range")
+	errUnsupportedCmap   = errors.New("TrueType font has no supported Unicode cmap subtable (format 4 or 12)")
+	errCompoundDepth     = errors.New("TrueType compound glyph nesting too deep")
+	errCompoundPointArgs = errors.New("TrueType compound glyphs positioned by point matching are not supported")
+)
+
+const (
+	// defaultTolerance is the default sampling tolerance of glyph curves, see [ms2.Spline3Sampler].
+	defaultTolerance = 1e-2
+	// curveMaxDepth bounds the subdivisions of a glyph curve to 2**curveMaxDepth segments.
+	curveMaxDepth = 8
+	// compoundMaxDepth bounds the nesting of compound glyphs.
+	compoundMaxDepth = 8
+)
+
+// GlyphIndex identifies a glyph within a font. Index 0 is the glyph displayed for missing characters.
+type GlyphIndex uint16
+
+// ContourPoint is a point of a TrueType glyph contour in font units. Consecutive on-curve points are joined by
+// lines and off-curve points are the control points of quadratic Bézier curves. Two consecutive off-curve points
+// imply an on-curve point at their midpoint.
+type ContourPoint struct {
+	X, Y    float32
+	OnCurve bool
+}
+
+// TrueType is a parsed TrueType font. It references the data it was parsed from, which must not be modified.
+// Methods are safe for concurrent use provided Tolerance is not modified.
+type TrueType struct {
+	// Tolerance is the tolerance with which glyph curves are sampled into line segments, see [ms2.Spline3Sampler].
+	// ParseTrueType sets it to a default value.
+	Tolerance float32
+
+	unitsPerEm      int
+	numGlyphs       int
+	longLoca        bool
+	ascent          int
+	descent         int
+	lineGap         int
+	numHMetrics     int
+	glyf, loca      []byte
+	hmtx            []byte
+	cmap            []byte
+	cmapFormat      uint16
+	kernPairs       []byte
+	kernPairsAmount int
+}
+
+// ParseTrueType parses the glyf, loca, cmap, hmtx, hhea, head and maxp tables of a TrueType font and
+// the kern table if present. Fonts with PostScript (CFF) outlines are not supported.
+func ParseTrueType(data []byte) (*TrueType, error) {
+	if len(data) < 12 {
+		return nil, errNotTrueType
+	}
+	version := u32(data, 0)
+	if version != 0x00010000 && version != 0x74727565 { // 'true'
+		return nil, errNotTrueType
+	}
+	numTables := int(u16(data, 4))
+	if len(data) < 12+16*numTables {
+		return nil, errTruncated
+	}
+	tables := make(map[string][]byte, numTables)
+	for i := 0; i < numTables; i++ {
+		rec := data[12+16*i:]
+		offset, length := int(u32(rec, 8)), int(u32(rec, 12))
+		if offset < 0 || length < 0 || offset+length > len(data) || offset+length < offset {
+			return nil, errTruncated
+		}
+		tables[string(rec[:4])] = data[offset : offset+length]
+	}
+	head, maxp, hhea := tables["head"], tables["maxp"], tables["hhea"]
+	f := &TrueType{
+		Tolerance: defaultTolerance,
+		glyf:      tables["glyf"],
+		loca:      tables["loca"],
+		hmtx:      tables["hmtx"],
+	}
+	if head == nil || maxp == nil || hhea == nil || f.glyf == nil || f.loca == nil || f.hmtx == nil || tables["cmap"] == nil {
+		return nil, errMissingTable
+	} else if len(head) < 54 || len(maxp) < 6 || len(hhea) < 36 {
+		return nil, errTruncated
+	}
+	f.unitsPerEm = int(u16(head, 18))
+	switch int16(u16(head, 50)) {
+	case 0:
+	case 1:
+		f.longLoca = true
+	default:
+		return nil, errBadLocaFormat
+	}
+	f.numGlyphs = int(u16(maxp, 4))
+	f.ascent = int(int16(u16(hhea, 4)))
+	f.descent = int(int16(u16(hhea, 6)))
+	f.lineGap = int(int16(u16(hhea, 8)))
+	f.numHMetrics = int(u16(hhea, 34))
+	locaSize := 2
+	if f.longLoca {
+		locaSize = 4
+	}
+	if f.unitsPerEm == 0 || f.numHMetrics == 0 || f.numHMetrics > f.numGlyphs {
+		return nil, errNotTrueType
+	} else if len(f.loca) < locaSize*(f.numGlyphs+1) || len(f.hmtx) < 4*f.numHMetrics+2*(f.numGlyphs-f.numHMetrics) {
+		return nil, errTruncated
+	}
+	err := f.parseCmap(tables["cmap"])
+	if err != nil {
+		return nil, err
+	}
+	f.parseKern(tables["kern"])
+	return f, nil
+}
+
+// parseCmap selects the best Unicode subtable of the cmap table.
+func (f *TrueType) parseCmap(cmap []byte) error {
+	if len(cmap) < 4 {
+		return errTruncated
+	}
+	n := int(u16(cmap, 2))
+	if len(cmap) < 4+8*n {
+		return errTruncated
+	}
+	bestScore := 0
+	for i := 0; i < n; i++ {
+		rec := cmap[4+8*i:]
+		platform, encoding, offset := u16(rec, 0), u16(rec, 2), int(u32(rec, 4))
+		if offset < 0 || offset+4 > len(cmap) {
+			return errTruncated
+		}
+		sub := cmap[offset:]
+		format := u16(sub, 0)
+		// Prefer full Unicode repertoire subtables over the Basic Multilingual Plane ones.
+		score := 0
+		switch {
+		case format == 12 && (platform == 0 || (platform == 3 && encoding == 10)):
+			score = 2
+		case format == 4 && (platform == 0 || (platform == 3 && (encoding == 1 || encoding == 0))):
+			score = 1
+		}
+		if score <= bestScore {
+			continue
+		}
+		var length int
+		if format == 12 {
+			if len(sub) < 16 {
+				return errTruncated
+			}
+			length = 16 + 12*int(u32(sub, 12))
+		} else {
+			if len(sub) < 14 {
+				return errTruncated
+			}
+			length = 16 + 8*int(u16(sub, 6)/2)
+		}
+		if length > len(sub) {
+			return errTruncated
+		}
+		bestScore = score
+		f.cmap, f.cmapFormat = sub, format
+	}
+	if bestScore == 0 {
+		return errUnsupportedCmap
+	}
+	return nil
+}
+
+// parseKern finds the first horizontal format 0 subtable of a version 0 kern table. Invalid tables are ignored.
+func (f *TrueType) parseKern(kern []byte) {
+	if len(kern) < 4 || u16(kern, 0) != 0 {
+		return
+	}
+	n := int(u16(kern, 2))
+	off := 4
+	for i := 0; i < n && off+14 <= len(kern); i++ {
+		length, coverage := int(u16(kern, off+2)), u16(kern, off+4)
+		format, horizontal, crossStream := coverage>>8, coverage&1 != 0, coverage&4 != 0
+		if format == 0 && horizontal && !crossStream {
+			pairs := int(u16(kern, off+6))
+			start := off + 14
+			if start+6*pairs <= len(kern) {
+				f.kernPairs = kern[start : start+6*pairs]
+				f.kernPairsAmount = pairs
+			}
+			return
+		}
+		if length < 14 {
+			return
+		}
+		off += length
+	}
+}
+
+// UnitsPerEm returns the amount of font units in the em square, the scale of the font's coordinates.
+func (f *TrueType) UnitsPerEm() int { return f.unitsPerEm }
+
+// NumGlyphs returns the amount of glyphs in the font.
+func (f *TrueType) NumGlyphs() int { return f.numGlyphs }
+
+// VerticalMetrics returns the typographic ascent, descent and line gap of the font in font units.
+// Descent is usually negative. The distance between consecutive baselines is ascent-descent+lineGap.
+func (f *TrueType) VerticalMetrics() (ascent, descent, lineGap int) {
+	return f.ascent, f.descent, f.lineGap
+}
+
+// GlyphIndex returns the index of the glyph that represents r. It returns 0 if the font has no glyph for r.
+func (f *TrueType) GlyphIndex(r rune) GlyphIndex {
+	if r < 0 {
+		return 0
+	}
+	c := uint32(r)
+	if f.cmapFormat == 12 {
+		// Binary search over the sequential map groups.
+		lo, hi := 0, int(u32(f.cmap, 12))
+		for lo < hi {
+			mid := (lo + hi) / 2
+			group := f.cmap[16+12*mid:]
+			start, end := u32(group, 0), u32(group, 4)
+			switch {
+			case c < start:
+				hi = mid
+			case c > end:
+				lo = mid + 1
+			default:
+				return f.checkGlyph(u32(group, 8) + c - start)
+			}
+		}
+		return 0
+	}
+	if c > 0xffff {
+		return 0
+	}
+	segCount := int(u16(f.cmap, 6) / 2)
+	endCodes := 14
+	startCodes := endCodes + 2*segCount + 2
+	idDeltas := startCodes + 2*segCount
+	idRangeOffsets := idDeltas + 2*segCount
+	lo, hi := 0, segCount
+	for lo < hi {
+		mid := (lo + hi) / 2
+		end := uint32(u16(f.cmap, endCodes+2*mid))
+		if c > end {
+			lo = mid + 1
+		} else {
+			hi = mid
+		}
+	}
+	if lo == segCount {
+		return 0
+	}
+	start := uint32(u16(f.cmap, startCodes+2*lo))
+	if c < start {
+		return 0
+	}
+	delta := u16(f.cmap, idDeltas+2*lo)
+	rangeOffset := int(u16(f.cmap, idRangeOffsets+2*lo))
+	if rangeOffset == 0 {
+		return f.checkGlyph(uint32(uint16(c) + delta))
+	}
+	// idRangeOffset is relative to its own position in the subtable.
+	off := idRangeOffsets + 2*lo + rangeOffset + 2*int(c-start)
+	if off+2 > len(f.cmap) {
+		return 0
+	}
+	g := u16(f.cmap, off)
+	if g == 0 {
+		return 0
+	}
+	return f.checkGlyph(uint32(g + delta))
+}
+
+func (f *TrueType) checkGlyph(g uint32) GlyphIndex {
+	if g >= uint32(f.numGlyphs) {
+		return 0
+	}
+	return GlyphIndex(g)
+}
+
+// Advance returns the horizontal advance width of the glyph in font units.
+func (f *TrueType) Advance(g GlyphIndex) int {
+	i := int(g)
+	if i >= f.numHMetrics {
+		// Glyphs past the last long metric share its advance width.
+		i = f.numHMetrics - 1
+	}
+	return int(u16(f.hmtx, 4*i))
+}
+
+// Kerning returns the adjustment in font units of the advance between the left and right glyphs
+// given by the font's kern table. It returns 0 if the font has no kerning for the pair.
+func (f *TrueType) Kerning(left, right GlyphIndex) int {
+	key := uint32(left)<<16 | uint32(right)
+	lo, hi := 0, f.kernPairsAmount
+	for lo < hi {
+		mid := (lo + hi) / 2
+		pair := f.kernPairs[6*mid:]
+		k := u32(pair, 0)
+		switch {
+		case key < k:
+			hi = mid
+		case key > k:
+			lo = mid + 1
+		default:
+			return int(int16(u16(pair, 4)))
+		}
+	}
+	return 0
+}
+
+// AppendContours appends the quadratic contours of the glyph in font units to dst.
+// Components of compound glyphs are resolved and transformed.
+func (f *TrueType) AppendContours(dst [][]ContourPoint, g GlyphIndex) ([][]ContourPoint, error) {
+	return f.appendContours(dst, g, 0)
+}
+
+func (f *TrueType) appendContours(dst [][]ContourPoint, g GlyphIndex, depth int) ([][]ContourPoint, error) {
+	if int(g) >= f.numGlyphs {
+		return dst, errBadGlyphIndex
+	} else if depth > compoundMaxDepth {
+		return dst, errCompoundDepth
+	}
+	var start, end int
+	if f.longLoca {
+		start, end = int(u32(f.loca, 4*int(g))), int(u32(f.loca, 4*int(g)+4))
+	} else {
+		start, end = 2*int(u16(f.loca, 2*int(g))), 2*int(u16(f.loca, 2*int(g)+2))
+	}
+	if start == end {
+		return dst, nil // Glyph without outline, i.e: space.
+	} else if start > end || end > len(f.glyf) || end-start < 10 {
+		return dst, errTruncated
+	}
+	data := f.glyf[start:end]
+	numContours := int(int16(u16(data, 0)))
+	if numContours < 0 {
+		return f.appendCompound(dst, data[10:], depth)
+	}
+	return appendSimple(dst, data[10:], numContours)
+}
+
+// appendSimple decodes the contours of a simple glyph description following the glyph header.
+func appendSimple(dst [][]ContourPoint, data []byte, numContours int) ([][]ContourPoint, error) {
+	const (
+		flagOnCurve = 1 << iota
+		flagXShort
+		flagYShort
+		flagRepeat
+		flagXSameOrPositive
+		flagYSameOrPositive
+	)
+	if len(data) < 2*numContours+2 {
+		return dst, errTruncated
+	}
+	numPoints := 0
+	if numContours > 0 {
+		numPoints = int(u16(data, 2*(numContours-1))) + 1
+	}
+	off := 2*numContours + 2 + int(u16(data, 2*numContours)) // Skip instructions.
+	if off > len(data) {
+		return dst, errTruncated
+	}
+	points := make([]ContourPoint, numPoints)
+	flags := make([]byte, numPoints)
+	for i := 0; i < numPoints; {
+		if off >= len(data) {
+			return dst, errTruncated
+		}
+		flag := data[off]
+		off++
+		repeat := 1
+		if flag&flagRepeat != 0 {
+			if off >= len(data) {
+				return dst, errTruncated
+			}
+			repeat += int(data[off])
+			off++
+		}
+		for ; repeat > 0 && i < numPoints; repeat-- {
+			flags[i] = flag
+			i++
+		}
+	}
+	// Coordinates are delta encoded, first all x then all y.
+	for axis := 0; axis < 2; axis++ {
+		short, sameOrPositive := byte(flagXShort), byte(flagXSameOrPositive)
+		if axis == 1 {
+			short, sameOrPositive = flagYShort, flagYSameOrPositive
+		}
+		var v int
+		for i, flag := range flags {
+			switch {
+			case flag&short != 0:
+				if off >= len(data) {
+					return dst, errTruncated
+				}
+				d := int(data[off])
+				off++
+				if flag&sameOrPositive == 0 {
+					d = -d
+				}
+				v += d
+			case flag&sameOrPositive == 0:
+				if off+2 > len(data) {
+					return dst, errTruncated
+				}
+				v += int(int16(u16(data, off)))
+				off += 2
+			}
+			if axis == 0 {
+				points[i].X = float32(v)
+			} else {
+				points[i].Y = float32(v)
+			}
+		}
+	}
+	first := 0
+	for c := 0; c < numContours; c++ {
+		last := int(u16(data, 2*c))
+		if last < first || last >= numPoints {
+			return dst, errTruncated
+		}
+		contour := points[first : last+1 : last+1]
+		for i := range contour {
+			contour[i].OnCurve = flags[first+i]&flagOnCurve != 0
+		}
+		dst = append(dst, contour)
+		first = last + 1
+	}
+	return dst, nil
+}
+
+// appendCompound decodes the components of a compound glyph description following the glyph header.
+func (f *TrueType) appendCompound(dst [][]ContourPoint, data []byte, depth int) ([][]ContourPoint, error) {
+	const (
+		flagArgWords       = 0x0001
+		flagArgsXY         = 0x0002
+		flagScale          = 0x0008
+		flagMoreComponents = 0x0020
+		flagXYScale        = 0x0040
+		flagTwoByTwo       = 0x0080
+	)
+	off := 0
+	for {
+		if off+4 > len(data) {
+			return dst, errTruncated
+		}
+		flags, component := u16(data, off), GlyphIndex(u16(data, off+2))
+		off += 4
+		var dx, dy float32
+		if flags&flagArgWords != 0 {
+			if off+4 > len(data) {
+				return dst, errTruncated
+			}
+			dx, dy = float32(int16(u16(data, off))), float32(int16(u16(data, off+2)))
+			off += 4
+		} else {
+			if off+2 > len(data) {
+				return dst, errTruncated
+			}
+			dx, dy = float32(int8(data[off])), float32(int8(data[off+1]))
+			off += 2
+		}
+		if flags&flagArgsXY == 0 {
+			return dst, errCompoundPointArgs
+		}
+		// Transform matrix in F2Dot14 fixed point: x' = a*x + c*y + dx, y' = b*x + d*y + dy.
+		a, b, c, d := float32(1), float32(0), float32(0), float32(1)
+		f2dot14 := func(i int) float32 { return float32(int16(u16(data, off+2*i))) / (1 << 14) }
+		switch {
+		case flags&flagScale != 0:
+			if off+2 > len(data) {
+				return dst, errTruncated
+			}
+			a = f2dot14(0)
+			d = a
+			off += 2
+		case flags&flagXYScale != 0:
+			if off+4 > len(data) {
+				return dst, errTruncated
+			}
+			a, d = f2dot14(0), f2dot14(1)
+			off += 4
+		case flags&flagTwoByTwo != 0:
+			if off+8 > len(data) {
+				return dst, errTruncated
+			}
+			a, b, c, d = f2dot14(0), f2dot14(1), f2dot14(2), f2dot14(3)
+			off += 8
+		}
+		n := len(dst)
+		var err error
+		dst, err = f.appendContours(dst, component, depth+1)
+		if err != nil {
+			return dst, err
+		}
+		for _, contour := range dst[n:] {
+			for i, p := range contour {
+				contour[i].X = a*p.X + c*p.Y + dx
+				contour[i].Y = b*p.X + d*p.Y + dy
+			}
+		}
+		if flags&flagMoreComponents == 0 {
+			return dst, nil
+		}
+	}
+}
+
+// AppendGlyph appends the outline of the glyph to dst as closed rings scaled so the em square measures size
+// and with the glyph's origin at origin. Quadratic curves are sampled with [ms2.SplineBezierQuadratic].
+// Rings are oriented so that outer rings are counter-clockwise and holes clockwise, see [ms2.Shape].
+func (f *TrueType) AppendGlyph(dst ms2.Shape, g GlyphIndex, origin ms2.Vec, size float32) (ms2.Shape, error) {
+	contours, err := f.AppendContours(nil, g)
+	if err != nil {
+		return dst, err
+	}
+	scale := size / float32(f.unitsPerEm)
+	sampler := ms2.Spline3Sampler{Spline: ms2.SplineBezierQuadratic(), Tolerance: f.Tolerance}
+	if sampler.Tolerance <= 0 {
+		sampler.Tolerance = defaultTolerance
+	}
+	for _, contour := range contours {
+		ring := appendQuadraticContour(nil, contour, &sampler)
+		if len(ring) < 3 {
+			continue
+		}
+		// TrueType outer contours are clockwise.
+		for i, j := 0, len(ring)-1; i < j; i, j = i+1, j-1 {
+			ring[i], ring[j] = ring[j], ring[i]
+		}
+		for i, v := range ring {
+			ring[i] = ms2.Add(origin, ms2.Scale(scale, v))
+		}
+		dst.Rings = append(dst.Rings, ring)
+	}
+	return dst, nil
+}
+
+// appendQuadraticContour appends the vertices of a closed TrueType contour to dst, sampling its curves with sampler.
+// The last vertex is not a repetition of the first.
+func appendQuadraticContour(dst []ms2.Vec, contour []ContourPoint, sampler *ms2.Spline3Sampler) []ms2.Vec {
+	n := len(contour)
+	if n == 0 {
+		return dst
+	}
+	vec := func(i int) ms2.Vec {
+		p := contour[i%n]
+		return ms2.Vec{X: p.X, Y: p.Y}
+	}
+	// Start on an on-curve point, or the implied one between the first two off-curve points.
+	startIdx := -1
+	for i, p := range contour {
+		if p.OnCurve {
+			startIdx = i
+			break
+		}
+	}
+	var start ms2.Vec
+	if startIdx < 0 {
+		start = ms2.Scale(0.5, ms2.Add(vec(0), vec(1)))
+		startIdx = 1
+	} else {
+		start = vec(startIdx)
+		startIdx++
+	}
+	dst = append(dst, start)
+	current := start
+	var control ms2.Vec
+	hasControl := false
+	for k := 0; k < n; k++ {
+		i := startIdx + k
+		p, on := vec(i), contour[i%n].OnCurve
+		switch {
+		case on && hasControl:
+			dst = appendQuadratic(dst, sampler, current, control, p)
+			current, hasControl = p, false
+		case on:
+			current = p
+			dst = append(dst, p)
+		case hasControl:
+			mid := ms2.Scale(0.5, ms2.Add(control, p))
+			dst = appendQuadratic(dst, sampler, current, control, mid)
+			current, control = mid, p
+		default:
+			control, hasControl = p, true
+		}
+	}
+	if hasControl {
+		dst = appendQuadratic(dst, sampler, current, control, start)
+	}
+	// Drop the closing vertex and repeated vertices from degenerate segments.
+	if len(dst) > 1 && dst[len(dst)-1] == dst[0] {
+		dst = dst[:len(dst)-1]
+	}
+	return dst
+}
+
+// appendQuadratic appends the sampled quadratic Bézier curve from p0 to p1 with control point c, excluding p0.
+func appendQuadratic(dst []ms2.Vec, sampler *ms2.Spline3Sampler, p0, c, p1 ms2.Vec) []ms2.Vec {
+	sampler.SetSplinePoints(p0, c, p1, p1)
+	dst = sampler.SampleBisect(dst, curveMaxDepth)
+	return append(dst, p1)
+}
+
+// AppendText lays out text with the font, appends the outlines of its glyphs to dst and returns the pen position
+// after the last glyph. The em square of the glyphs measures size. Advances between glyphs are adjusted by kerning
+// when the font provides it. Characters the font lacks are drawn with its missing glyph.
+func (f *TrueType) AppendText(dst ms2.Shape, text string, origin ms2.Vec, size float32) (ms2.Shape, ms2.Vec, error) {
+	scale := size / float32(f.unitsPerEm)
+	lineHeight := float32(f.ascent-f.descent+f.lineGap) * scale
+	pen := origin
+	prev, hasPrev := GlyphIndex(0), false
+	var err error
+	for _, r := range text {
+		if r == '\n' {
+			pen = ms2.Vec{X: origin.X, Y: pen.Y - lineHeight}
+			hasPrev = false
+			continue
+		}
+		g := f.GlyphIndex(r)
+		if hasPrev {
+			pen.X += float32(f.Kerning(prev, g)) * scale
+		}
+		dst, err = f.AppendGlyph(dst, g, pen, size)
+		if err != nil {
+			return dst, pen, err
+		}
+		pen.X += float32(f.Advance(g)) * scale
+		prev, hasPrev = g, true
+	}
+	return dst, pen, nil
+}
+
+// TextWidth returns the width of the longest line of text laid out with the font at the argument size, see [TrueType.AppendText].
+func (f *TrueType) TextWidth(text string, size float32) float32 {
+	scale := size / float32(f.unitsPerEm)
+	var width, lineWidth float32
+	prev, hasPrev := GlyphIndex(0), false
+	for _, r := range text {
+		if r == '\n' {
+			lineWidth, hasPrev = 0, false
+			continue
+		}
+		g := f.GlyphIndex(r)
+		if hasPrev {
+			lineWidth += float32(f.Kerning(prev, g)) * scale
+		}
+		lineWidth += float32(f.Advance(g)) * scale
+		width = math.Max(width, lineWidth)
+		prev, hasPrev = g, true
+	}
+	return width
+}
+
+func u16(b []byte, off int) uint16 { return binary.BigEndian.Uint16(b[off:]) }
+func u32(b []byte, off int) uint32 { return binary.BigEndian.Uint32(b[off:]) }
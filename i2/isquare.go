@@ -0,0 +1,69 @@
+package i2
+
+// Square implements a tree square for Quadtree algorithms.
+type Square struct {
+	// Vec stores the shifted
+	Vec
+	// Level keeps track of the level in the tree.
+	//  - Level==1 means the square is the smallest possible square.
+	//  - Level==0 is an invalid level. May be used as a flag to signal the square has been discarded or processed and ready for discard.
+	Level int
+}
+
+// IsSmallest returns true if Lvl==1. This means the square cannot be decomposed further with [Square.Quadtree].
+func (c Square) IsSmallest() bool { return c.Level == 1 }
+
+// IsSecondSmallest returns true if Lvl==2. This means the square can be decomposed once more with [Square.Quadtree].
+func (c Square) IsSecondSmallest() bool { return c.Level == 2 }
+
+// DecomposesTo returns the amount of squares generated from decomposing the square down to squares of the argument target level.
+func (c Square) DecomposesTo(targetLevel int) uint64 {
+	if targetLevel > c.Level {
+		panic("invalid targetLvl to isquare.decomposesTo")
+	}
+	return Pow4(c.Level - targetLevel)
+}
+
+// Size returns the length of one of the isquare's sides.
+func (c Square) Size() (resUnits int) {
+	return 1 << (c.Level - 1)
+}
+
+// Supersquare returns the Square's parent quadtree Square.
+func (c Square) Supersquare() Square {
+	upLevel := c.Level + 1
+	bitmask := (1 << upLevel) - 1
+	return Square{
+		Vec:   c.Vec.AndnotScalar(bitmask),
+		Level: upLevel,
+	}
+}
+
+// Index returns the indices corresponding to the Square in the root square.
+// By multiplying the resulting indices by the smallest square size one can obtain the origin of the Square in space.
+func (c Square) Index() Vec {
+	return c.Vec.ShiftRight(c.Level) // isquare indices per level in the quadtree.
+}
+
+// Quadtree returns the 4 sub-squares of the receiver in counter-clockwise order starting at the square's origin.
+func (c Square) Quadtree() [4]Square {
+	level := c.Level - 1
+	if level <= 0 {
+		panic("invalid operation: quadtree for level<=1")
+	}
+	s := 1 << level
+	return [4]Square{
+		{Vec: c.Add(Vec{0, 0}), Level: level},
+		{Vec: c.Add(Vec{s, 0}), Level: level},
+		{Vec: c.Add(Vec{s, s}), Level: level},
+		{Vec: c.Add(Vec{0, s}), Level: level},
+	}
+}
+
+// Pow4 returns 4**y.
+func Pow4(y int) uint64 {
+	if y < 0 || y > 31 {
+		panic("overflow Pow4")
+	}
+	return 1 << (2 * uint(y))
+}
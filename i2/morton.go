@@ -0,0 +1,98 @@
+package i2
+
+// Morton returns the Morton (Z-order) code of a by interleaving the bits of its components,
+// with X occupying the even bits and Y the odd bits. Components must be non-negative and less than 2**32.
+func (a Vec) Morton() uint64 {
+	return spread2(uint64(a.X)) | spread2(uint64(a.Y))<<1
+}
+
+// MortonDecode returns the vector whose Morton code is key. It is the inverse of [Vec.Morton].
+func MortonDecode(key uint64) Vec {
+	return Vec{X: int(compact2(key)), Y: int(compact2(key >> 1))}
+}
+
+// Hilbert returns the index of a along a 2D Hilbert curve filling a square grid of side 2**order.
+// Components must be non-negative and less than 2**order, with order in range [1,32].
+// Consecutive Hilbert indices are always adjacent grid cells, giving better locality than the Morton code.
+func (a Vec) Hilbert(order int) uint64 {
+	if order < 1 || order > 32 {
+		panic("invalid Hilbert order")
+	}
+	// John Skilling's "Programming the Hilbert curve" transpose algorithm.
+	x := [2]uint32{uint32(a.X), uint32(a.Y)}
+	m := uint32(1) << (order - 1)
+	// Inverse undo excess work.
+	for q := m; q > 1; q >>= 1 {
+		p := q - 1
+		for i := range x {
+			if x[i]&q != 0 {
+				x[0] ^= p // Invert.
+			} else {
+				t := (x[0] ^ x[i]) & p // Exchange.
+				x[0] ^= t
+				x[i] ^= t
+			}
+		}
+	}
+	// Gray encode.
+	x[1] ^= x[0]
+	var t uint32
+	for q := m; q > 1; q >>= 1 {
+		if x[1]&q != 0 {
+			t ^= q - 1
+		}
+	}
+	x[0] ^= t
+	x[1] ^= t
+	// Transposed index has most significant bits in x[0].
+	return spread2(uint64(x[1])) | spread2(uint64(x[0]))<<1
+}
+
+// HilbertDecode returns the grid cell with Hilbert index h on a square grid of side 2**order. It is the inverse of [Vec.Hilbert].
+func HilbertDecode(h uint64, order int) Vec {
+	if order < 1 || order > 32 {
+		panic("invalid Hilbert order")
+	}
+	x := [2]uint32{uint32(compact2(h >> 1)), uint32(compact2(h))}
+	n := uint32(2) << (order - 1)
+	// Gray decode.
+	t := x[1] >> 1
+	x[1] ^= x[0]
+	x[0] ^= t
+	// Undo excess work.
+	for q := uint32(2); q != n; q <<= 1 {
+		p := q - 1
+		for i := len(x) - 1; i >= 0; i-- {
+			if x[i]&q != 0 {
+				x[0] ^= p
+			} else {
+				t := (x[0] ^ x[i]) & p
+				x[0] ^= t
+				x[i] ^= t
+			}
+		}
+	}
+	return Vec{X: int(x[0]), Y: int(x[1])}
+}
+
+// spread2 spreads the lower 32 bits of x so that there is a zero bit between each bit.
+func spread2(x uint64) uint64 {
+	x &= 0xffffffff
+	x = (x | x<<16) & 0x0000ffff0000ffff
+	x = (x | x<<8) & 0x00ff00ff00ff00ff
+	x = (x | x<<4) & 0x0f0f0f0f0f0f0f0f
+	x = (x | x<<2) & 0x3333333333333333
+	x = (x | x<<1) & 0x5555555555555555
+	return x
+}
+
+// compact2 is the inverse of spread2. It packs the even bits of x into the lower 32 bits.
+func compact2(x uint64) uint64 {
+	x &= 0x5555555555555555
+	x = (x | x>>1) & 0x3333333333333333
+	x = (x | x>>2) & 0x0f0f0f0f0f0f0f0f
+	x = (x | x>>4) & 0x00ff00ff00ff00ff
+	x = (x | x>>8) & 0x0000ffff0000ffff
+	x = (x | x>>16) & 0x00000000ffffffff
+	return x
+}
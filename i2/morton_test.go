@@ -0,0 +1,35 @@
+package i2
+
+import "testing"
+
+func TestMortonHilbert(t *testing.T) {
+	const order = 4
+	const side = 1 << order
+	seen := make(map[uint64]bool)
+	for x := 0; x < side; x++ {
+		for y := 0; y < side; y++ {
+			v := Vec{X: x, Y: y}
+			if got := MortonDecode(v.Morton()); got != v {
+				t.Fatalf("Morton roundtrip %v: got %v", v, got)
+			}
+			h := v.Hilbert(order)
+			if h >= side*side || seen[h] {
+				t.Fatalf("Hilbert index %d of %v out of range or repeated", h, v)
+			}
+			seen[h] = true
+			if got := HilbertDecode(h, order); got != v {
+				t.Fatalf("Hilbert roundtrip %v: got %v", v, got)
+			}
+		}
+	}
+	// Consecutive Hilbert indices are adjacent cells.
+	prev := HilbertDecode(0, order)
+	for h := uint64(1); h < side*side; h++ {
+		v := HilbertDecode(h, order)
+		d := v.Sub(prev)
+		if d.X*d.X+d.Y*d.Y != 1 {
+			t.Fatalf("Hilbert cells %d and %d not adjacent: %v %v", h-1, h, prev, v)
+		}
+		prev = v
+	}
+}
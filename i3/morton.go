@@ -0,0 +1,163 @@
+package i3
+
+import "math/bits"
+
+// Morton returns the Morton (Z-order) code of a by interleaving the bits of its components,
+// with X occupying bits 0,3,6..., Y bits 1,4,7... and Z bits 2,5,8... Components must be non-negative and less than 2**21.
+func (a Vec) Morton() uint64 {
+	return spread3(uint64(a.X)) | spread3(uint64(a.Y))<<1 | spread3(uint64(a.Z))<<2
+}
+
+// MortonDecode returns the vector whose Morton code is key. It is the inverse of [Vec.Morton].
+func MortonDecode(key uint64) Vec {
+	return Vec{X: int(compact3(key)), Y: int(compact3(key >> 1)), Z: int(compact3(key >> 2))}
+}
+
+// Hilbert returns the index of a along a 3D Hilbert curve filling a cubic grid of side 2**order.
+// Components must be non-negative and less than 2**order, with order in range [1,21].
+// Consecutive Hilbert indices are always adjacent grid cells, giving better locality than the Morton code.
+func (a Vec) Hilbert(order int) uint64 {
+	if order < 1 || order > 21 {
+		panic("invalid Hilbert order")
+	}
+	// John Skilling's "Programming the Hilbert curve" transpose algorithm.
+	x := [3]uint32{uint32(a.X), uint32(a.Y), uint32(a.Z)}
+	m := uint32(1) << (order - 1)
+	// Inverse undo excess work.
+	for q := m; q > 1; q >>= 1 {
+		p := q - 1
+		for i := range x {
+			if x[i]&q != 0 {
+				x[0] ^= p // Invert.
+			} else {
+				t := (x[0] ^ x[i]) & p // Exchange.
+				x[0] ^= t
+				x[i] ^= t
+			}
+		}
+	}
+	// Gray encode.
+	x[1] ^= x[0]
+	x[2] ^= x[1]
+	var t uint32
+	for q := m; q > 1; q >>= 1 {
+		if x[2]&q != 0 {
+			t ^= q - 1
+		}
+	}
+	x[0] ^= t
+	x[1] ^= t
+	x[2] ^= t
+	// Transposed index has most significant bits in x[0].
+	return spread3(uint64(x[2])) | spread3(uint64(x[1]))<<1 | spread3(uint64(x[0]))<<2
+}
+
+// HilbertDecode returns the grid cell with Hilbert index h on a cubic grid of side 2**order. It is the inverse of [Vec.Hilbert].
+func HilbertDecode(h uint64, order int) Vec {
+	if order < 1 || order > 21 {
+		panic("invalid Hilbert order")
+	}
+	x := [3]uint32{uint32(compact3(h >> 2)), uint32(compact3(h >> 1)), uint32(compact3(h))}
+	n := uint32(2) << (order - 1)
+	// Gray decode.
+	t := x[2] >> 1
+	x[2] ^= x[1]
+	x[1] ^= x[0]
+	x[0] ^= t
+	// Undo excess work.
+	for q := uint32(2); q != n; q <<= 1 {
+		p := q - 1
+		for i := len(x) - 1; i >= 0; i-- {
+			if x[i]&q != 0 {
+				x[0] ^= p
+			} else {
+				t := (x[0] ^ x[i]) & p
+				x[0] ^= t
+				x[i] ^= t
+			}
+		}
+	}
+	return Vec{X: int(x[0]), Y: int(x[1]), Z: int(x[2])}
+}
+
+// MortonKey returns a key which identifies the cube and its level within a linear octree.
+// The key is the Morton code of the cube's origin in smallest cube units followed by a sentinel bit
+// whose position encodes the level, so a cube's key lies in between the keys of its descendants:
+//
+//	key = Morton(origin)<<1 | 1<<(3*(Level-1))
+//
+// Sorting cubes by key sorts them in Z-order. The cube's level must be in range [1,21].
+// See [Cube.MortonRange] and [MortonKeyAncestor] for queries on keys.
+func (c Cube) MortonKey() uint64 {
+	if c.Level < 1 || c.Level > 21 {
+		panic("invalid level for Morton key")
+	}
+	return c.Vec.ShiftRightScalar(1).Morton()<<1 | mortonKeyLSB(c.Level)
+}
+
+// CubeFromMortonKey returns the cube identified by key. It is the inverse of [Cube.MortonKey].
+func CubeFromMortonKey(key uint64) Cube {
+	level := MortonKeyLevel(key)
+	return Cube{Vec: MortonDecode(key >> 1).ShiftLeftScalar(1).AndnotScalar(1<<level - 1), Level: level}
+}
+
+// MortonKeyLevel returns the level of the cube identified by a key returned by [Cube.MortonKey].
+func MortonKeyLevel(key uint64) int {
+	if key == 0 {
+		panic("invalid zero Morton key")
+	}
+	return bits.TrailingZeros64(key)/3 + 1
+}
+
+// MortonKeyAncestor returns the key of the ancestor at level of the cube identified by key.
+// The level must not be smaller than the cube's level.
+func MortonKeyAncestor(key uint64, level int) uint64 {
+	if level < MortonKeyLevel(key) || level > 21 {
+		panic("invalid ancestor level")
+	}
+	lsb := mortonKeyLSB(level)
+	return key&-lsb | lsb
+}
+
+// MortonRange returns the inclusive range of keys of the cube's descendants of all levels,
+// including the cube itself. A cube d descends from c if c.MortonRange contains d.MortonKey.
+// Smallest cubes descending from c have consecutive keys separated by a stride of 2 within the range.
+func (c Cube) MortonRange() (first, last uint64) {
+	key := c.MortonKey()
+	lsb := mortonKeyLSB(c.Level)
+	return key - (lsb - 1), key + (lsb - 1)
+}
+
+// Ancestor returns the cube at level containing c. The level must not be smaller than c's level.
+func (c Cube) Ancestor(level int) Cube {
+	if level < c.Level {
+		panic("invalid ancestor level")
+	}
+	return Cube{Vec: c.Vec.AndnotScalar(1<<level - 1), Level: level}
+}
+
+func mortonKeyLSB(level int) uint64 {
+	return 1 << (3 * (level - 1))
+}
+
+// spread3 spreads the lower 21 bits of x so that there are two zero bits between each bit.
+func spread3(x uint64) uint64 {
+	x &= 0x1fffff
+	x = (x | x<<32) & 0x001f00000000ffff
+	x = (x | x<<16) & 0x001f0000ff0000ff
+	x = (x | x<<8) & 0x100f00f00f00f00f
+	x = (x | x<<4) & 0x10c30c30c30c30c3
+	x = (x | x<<2) & 0x1249249249249249
+	return x
+}
+
+// compact3 is the inverse of spread3. It packs every third bit of x into the lower 21 bits.
+func compact3(x uint64) uint64 {
+	x &= 0x1249249249249249
+	x = (x | x>>2) & 0x10c30c30c30c30c3
+	x = (x | x>>4) & 0x100f00f00f00f00f
+	x = (x | x>>8) & 0x001f0000ff0000ff
+	x = (x | x>>16) & 0x001f00000000ffff
+	x = (x | x>>32) & 0x1fffff
+	return x
+}
@@ -0,0 +1,80 @@
+package i3
+
+import "testing"
+
+func TestMortonHilbert(t *testing.T) {
+	const order = 3
+	const side = 1 << order
+	seen := make(map[uint64]bool)
+	for x := 0; x < side; x++ {
+		for y := 0; y < side; y++ {
+			for z := 0; z < side; z++ {
+				v := Vec{X: x, Y: y, Z: z}
+				if got := MortonDecode(v.Morton()); got != v {
+					t.Fatalf("Morton roundtrip %v: got %v", v, got)
+				}
+				h := v.Hilbert(order)
+				if h >= side*side*side || seen[h] {
+					t.Fatalf("Hilbert index %d of %v out of range or repeated", h, v)
+				}
+				seen[h] = true
+				if got := HilbertDecode(h, order); got != v {
+					t.Fatalf("Hilbert roundtrip %v: got %v", v, got)
+				}
+			}
+		}
+	}
+	// Consecutive Hilbert indices are adjacent cells.
+	prev := HilbertDecode(0, order)
+	for h := uint64(1); h < side*side*side; h++ {
+		v := HilbertDecode(h, order)
+		d := v.Sub(prev)
+		if d.X*d.X+d.Y*d.Y+d.Z*d.Z != 1 {
+			t.Fatalf("Hilbert cells %d and %d not adjacent: %v %v", h-1, h, prev, v)
+		}
+		prev = v
+	}
+	big := Vec{X: 1<<21 - 1, Y: 12345, Z: 1 << 20}
+	if got := MortonDecode(big.Morton()); got != big {
+		t.Errorf("Morton roundtrip %v: got %v", big, got)
+	}
+}
+
+func TestCubeMortonKey(t *testing.T) {
+	root := Cube{Level: 4}
+	first, last := root.MortonRange()
+	cubes := []Cube{root}
+	for i := 0; i < len(cubes); i++ {
+		c := cubes[i]
+		key := c.MortonKey()
+		if key < first || key > last {
+			t.Fatalf("cube %+v key %d outside root range [%d,%d]", c, key, first, last)
+		}
+		if got := CubeFromMortonKey(key); got != c {
+			t.Fatalf("key roundtrip %+v: got %+v", c, got)
+		}
+		for lvl := c.Level; lvl <= root.Level; lvl++ {
+			anc := c.Ancestor(lvl)
+			if MortonKeyAncestor(key, lvl) != anc.MortonKey() {
+				t.Fatalf("ancestor of %+v at level %d mismatch", c, lvl)
+			}
+			if lo, hi := anc.MortonRange(); key < lo || key > hi {
+				t.Fatalf("cube %+v not in range of ancestor %+v", c, anc)
+			}
+		}
+		if c.Level > 1 {
+			sub := c.Octree()
+			cubes = append(cubes, sub[:]...)
+		}
+	}
+	// Sibling ranges must not overlap.
+	sub := root.Octree()
+	for i := range sub {
+		lo, hi := sub[i].MortonRange()
+		for j := range sub {
+			if k := sub[j].MortonKey(); i != j && k >= lo && k <= hi {
+				t.Errorf("sibling %+v inside range of %+v", sub[j], sub[i])
+			}
+		}
+	}
+}
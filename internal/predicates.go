@@ -0,0 +1,279 @@
+package internal
+
+import "math"
+
+// Adaptive precision geometric predicates after Jonathan Richard Shewchuk's
+// "Adaptive Precision Floating-Point Arithmetic and Fast Robust Geometric Predicates".
+// Each predicate first evaluates its determinant in float64 and compares the
+// result against a forward error bound. Only when the sign cannot be certified
+// is the determinant evaluated exactly using floating-point expansions.
+//
+// All predicates return a value whose sign is exact for finite float64 inputs.
+// The magnitude is an approximation of the determinant. float32 inputs are
+// exactly representable as float64 so the results are exact for them too.
+
+const (
+	// epsilon is half the distance between 1 and the next float64, the unit roundoff.
+	epsilon = 0x1p-53
+
+	orient2ErrBound  = (3 + 16*epsilon) * epsilon
+	orient3ErrBound  = (7 + 56*epsilon) * epsilon
+	inCircleErrBound = (10 + 96*epsilon) * epsilon
+	inSphereErrBound = (16 + 224*epsilon) * epsilon
+)
+
+// Orient2 returns a positive value if the points a, b and c occur in counter-clockwise
+// order, a negative value if they occur in clockwise order and zero if they are collinear.
+// The result is twice the signed area of the triangle abc, with exact sign.
+func Orient2(ax, ay, bx, by, cx, cy float64) float64 {
+	detLeft := (ax - cx) * (by - cy)
+	detRight := (ay - cy) * (bx - cx)
+	det := detLeft - detRight
+	if math.Abs(det) > orient2ErrBound*(math.Abs(detLeft)+math.Abs(detRight)) {
+		return det
+	}
+	acx, acy := twoDiff(ax, cx), twoDiff(ay, cy)
+	bcx, bcy := twoDiff(bx, cx), twoDiff(by, cy)
+	return mulExpansion(acx, bcy).sub(mulExpansion(acy, bcx)).estimate()
+}
+
+// Orient3 returns a positive value if the point d lies below the plane passing through
+// a, b and c, where "below" is defined so that a, b and c appear in counter-clockwise order
+// when viewed from above the plane. It returns a negative value if d lies above the
+// plane and zero if the points are coplanar. The result is six times the signed volume of
+// the tetrahedron abcd, with exact sign.
+func Orient3(ax, ay, az, bx, by, bz, cx, cy, cz, dx, dy, dz float64) float64 {
+	adx, ady, adz := ax-dx, ay-dy, az-dz
+	bdx, bdy, bdz := bx-dx, by-dy, bz-dz
+	cdx, cdy, cdz := cx-dx, cy-dy, cz-dz
+	bdxcdy, cdxbdy := bdx*cdy, cdx*bdy
+	cdxady, adxcdy := cdx*ady, adx*cdy
+	adxbdy, bdxady := adx*bdy, bdx*ady
+	det := adz*(bdxcdy-cdxbdy) + bdz*(cdxady-adxcdy) + cdz*(adxbdy-bdxady)
+	permanent := math.Abs(adz)*(math.Abs(bdxcdy)+math.Abs(cdxbdy)) +
+		math.Abs(bdz)*(math.Abs(cdxady)+math.Abs(adxcdy)) +
+		math.Abs(cdz)*(math.Abs(adxbdy)+math.Abs(bdxady))
+	if math.Abs(det) > orient3ErrBound*permanent {
+		return det
+	}
+	eadx, eady, eadz := twoDiff(ax, dx), twoDiff(ay, dy), twoDiff(az, dz)
+	ebdx, ebdy, ebdz := twoDiff(bx, dx), twoDiff(by, dy), twoDiff(bz, dz)
+	ecdx, ecdy, ecdz := twoDiff(cx, dx), twoDiff(cy, dy), twoDiff(cz, dz)
+	bc := mulExpansion(ebdx, ecdy).sub(mulExpansion(ecdx, ebdy))
+	ca := mulExpansion(ecdx, eady).sub(mulExpansion(eadx, ecdy))
+	ab := mulExpansion(eadx, ebdy).sub(mulExpansion(ebdx, eady))
+	return mulExpansion(eadz, bc).add(mulExpansion(ebdz, ca)).add(mulExpansion(ecdz, ab)).estimate()
+}
+
+// InCircle returns a positive value if the point d lies inside the circle passing
+// through a, b and c, a negative value if it lies outside and zero if the four points
+// are cocircular. The points a, b and c must be in counter-clockwise order or the
+// sign of the result is reversed. The sign of the result is exact.
+func InCircle(ax, ay, bx, by, cx, cy, dx, dy float64) float64 {
+	adx, ady := ax-dx, ay-dy
+	bdx, bdy := bx-dx, by-dy
+	cdx, cdy := cx-dx, cy-dy
+	bdxcdy, cdxbdy := bdx*cdy, cdx*bdy
+	cdxady, adxcdy := cdx*ady, adx*cdy
+	adxbdy, bdxady := adx*bdy, bdx*ady
+	alift := adx*adx + ady*ady
+	blift := bdx*bdx + bdy*bdy
+	clift := cdx*cdx + cdy*cdy
+	det := alift*(bdxcdy-cdxbdy) + blift*(cdxady-adxcdy) + clift*(adxbdy-bdxady)
+	permanent := (math.Abs(bdxcdy)+math.Abs(cdxbdy))*alift +
+		(math.Abs(cdxady)+math.Abs(adxcdy))*blift +
+		(math.Abs(adxbdy)+math.Abs(bdxady))*clift
+	if math.Abs(det) > inCircleErrBound*permanent {
+		return det
+	}
+	eadx, eady := twoDiff(ax, dx), twoDiff(ay, dy)
+	ebdx, ebdy := twoDiff(bx, dx), twoDiff(by, dy)
+	ecdx, ecdy := twoDiff(cx, dx), twoDiff(cy, dy)
+	bc := mulExpansion(ebdx, ecdy).sub(mulExpansion(ecdx, ebdy))
+	ca := mulExpansion(ecdx, eady).sub(mulExpansion(eadx, ecdy))
+	ab := mulExpansion(eadx, ebdy).sub(mulExpansion(ebdx, eady))
+	eAlift := mulExpansion(eadx, eadx).add(mulExpansion(eady, eady))
+	eBlift := mulExpansion(ebdx, ebdx).add(mulExpansion(ebdy, ebdy))
+	eClift := mulExpansion(ecdx, ecdx).add(mulExpansion(ecdy, ecdy))
+	return mulExpansion(eAlift, bc).add(mulExpansion(eBlift, ca)).add(mulExpansion(eClift, ab)).estimate()
+}
+
+// InSphere returns a positive value if the point e lies inside the sphere passing
+// through a, b, c and d, a negative value if it lies outside and zero if the five points
+// are cospherical. The points a, b, c and d must be ordered so that [Orient3] is
+// positive or the sign of the result is reversed. The sign of the result is exact.
+func InSphere(ax, ay, az, bx, by, bz, cx, cy, cz, dx, dy, dz, ex, ey, ez float64) float64 {
+	aex, aey, aez := ax-ex, ay-ey, az-ez
+	bex, bey, bez := bx-ex, by-ey, bz-ez
+	cex, cey, cez := cx-ex, cy-ey, cz-ez
+	dex, dey, dez := dx-ex, dy-ey, dz-ez
+	aexbey, bexaey := aex*bey, bex*aey
+	bexcey, cexbey := bex*cey, cex*bey
+	cexdey, dexcey := cex*dey, dex*cey
+	dexaey, aexdey := dex*aey, aex*dey
+	aexcey, cexaey := aex*cey, cex*aey
+	bexdey, dexbey := bex*dey, dex*bey
+	ab := aexbey - bexaey
+	bc := bexcey - cexbey
+	cd := cexdey - dexcey
+	da := dexaey - aexdey
+	ac := aexcey - cexaey
+	bd := bexdey - dexbey
+	abc := aez*bc - bez*ac + cez*ab
+	bcd := bez*cd - cez*bd + dez*bc
+	cda := cez*da + dez*ac + aez*cd
+	dab := dez*ab + aez*bd + bez*da
+	alift := aex*aex + aey*aey + aez*aez
+	blift := bex*bex + bey*bey + bez*bez
+	clift := cex*cex + cey*cey + cez*cez
+	dlift := dex*dex + dey*dey + dez*dez
+	det := (dlift*abc - clift*dab) + (blift*cda - alift*bcd)
+
+	abP := math.Abs(aexbey) + math.Abs(bexaey)
+	bcP := math.Abs(bexcey) + math.Abs(cexbey)
+	cdP := math.Abs(cexdey) + math.Abs(dexcey)
+	daP := math.Abs(dexaey) + math.Abs(aexdey)
+	acP := math.Abs(aexcey) + math.Abs(cexaey)
+	bdP := math.Abs(bexdey) + math.Abs(dexbey)
+	permanent := (cdP*math.Abs(bez)+bdP*math.Abs(cez)+bcP*math.Abs(dez))*alift +
+		(daP*math.Abs(cez)+acP*math.Abs(dez)+cdP*math.Abs(aez))*blift +
+		(abP*math.Abs(dez)+bdP*math.Abs(aez)+daP*math.Abs(bez))*clift +
+		(bcP*math.Abs(aez)+acP*math.Abs(bez)+abP*math.Abs(cez))*dlift
+	if math.Abs(det) > inSphereErrBound*permanent {
+		return det
+	}
+
+	eaex, eaey, eaez := twoDiff(ax, ex), twoDiff(ay, ey), twoDiff(az, ez)
+	ebex, ebey, ebez := twoDiff(bx, ex), twoDiff(by, ey), twoDiff(bz, ez)
+	ecex, ecey, ecez := twoDiff(cx, ex), twoDiff(cy, ey), twoDiff(cz, ez)
+	edex, edey, edez := twoDiff(dx, ex), twoDiff(dy, ey), twoDiff(dz, ez)
+	eab := mulExpansion(eaex, ebey).sub(mulExpansion(ebex, eaey))
+	ebc := mulExpansion(ebex, ecey).sub(mulExpansion(ecex, ebey))
+	ecd := mulExpansion(ecex, edey).sub(mulExpansion(edex, ecey))
+	eda := mulExpansion(edex, eaey).sub(mulExpansion(eaex, edey))
+	eac := mulExpansion(eaex, ecey).sub(mulExpansion(ecex, eaey))
+	ebd := mulExpansion(ebex, edey).sub(mulExpansion(edex, ebey))
+	eabc := mulExpansion(eaez, ebc).sub(mulExpansion(ebez, eac)).add(mulExpansion(ecez, eab))
+	ebcd := mulExpansion(ebez, ecd).sub(mulExpansion(ecez, ebd)).add(mulExpansion(edez, ebc))
+	ecda := mulExpansion(ecez, eda).add(mulExpansion(edez, eac)).add(mulExpansion(eaez, ecd))
+	edab := mulExpansion(edez, eab).add(mulExpansion(eaez, ebd)).add(mulExpansion(ebez, eda))
+	eAlift := mulExpansion(eaex, eaex).add(mulExpansion(eaey, eaey)).add(mulExpansion(eaez, eaez))
+	eBlift := mulExpansion(ebex, ebex).add(mulExpansion(ebey, ebey)).add(mulExpansion(ebez, ebez))
+	eClift := mulExpansion(ecex, ecex).add(mulExpansion(ecey, ecey)).add(mulExpansion(ecez, ecez))
+	eDlift := mulExpansion(edex, edex).add(mulExpansion(edey, edey)).add(mulExpansion(edez, edez))
+	left := mulExpansion(eDlift, eabc).sub(mulExpansion(eClift, edab))
+	right := mulExpansion(eBlift, ecda).sub(mulExpansion(eAlift, ebcd))
+	return left.add(right).estimate()
+}
+
+// expansion is a sum of non-overlapping float64 components sorted by increasing
+// magnitude with zero components eliminated. The value of the expansion is the exact sum
+// of its components. The empty expansion represents zero.
+type expansion []float64
+
+// twoSum returns the exact sum of a and b as the rounded sum and its roundoff error.
+func twoSum(a, b float64) (x, y float64) {
+	x = a + b
+	bv := x - a
+	av := x - bv
+	y = (a - av) + (b - bv)
+	return x, y
+}
+
+// twoDiff returns the exact difference a-b as an expansion.
+func twoDiff(a, b float64) expansion {
+	x, y := twoSum(a, -b)
+	return expansion{y, x}.compress()
+}
+
+// twoProduct returns the exact product of a and b as the rounded product and its roundoff error.
+func twoProduct(a, b float64) (x, y float64) {
+	x = a * b
+	return x, math.FMA(a, b, -x)
+}
+
+// compress eliminates zero components of e in place.
+func (e expansion) compress() expansion {
+	n := 0
+	for _, c := range e {
+		if c != 0 {
+			e[n] = c
+			n++
+		}
+	}
+	return e[:n]
+}
+
+// grow returns the exact sum of the expansion e and the scalar b.
+func (e expansion) grow(b float64) expansion {
+	h := make(expansion, 0, len(e)+1)
+	q := b
+	for _, c := range e {
+		var hh float64
+		q, hh = twoSum(q, c)
+		if hh != 0 {
+			h = append(h, hh)
+		}
+	}
+	if q != 0 || len(h) == 0 {
+		h = append(h, q)
+	}
+	return h.compress()
+}
+
+// add returns the exact sum of the expansions e and f.
+func (e expansion) add(f expansion) expansion {
+	h := e
+	for _, c := range f {
+		h = h.grow(c)
+	}
+	return h
+}
+
+// sub returns the exact difference of the expansions e and f.
+func (e expansion) sub(f expansion) expansion {
+	h := e
+	for _, c := range f {
+		h = h.grow(-c)
+	}
+	return h
+}
+
+// scale returns the exact product of the expansion e and the scalar b.
+func (e expansion) scale(b float64) expansion {
+	if len(e) == 0 || b == 0 {
+		return nil
+	}
+	h := make(expansion, 0, 2*len(e))
+	q, hh := twoProduct(e[0], b)
+	h = append(h, hh)
+	for _, c := range e[1:] {
+		p1, p0 := twoProduct(c, b)
+		sum, err := twoSum(q, p0)
+		h = append(h, err)
+		q, err = twoSum(p1, sum)
+		h = append(h, err)
+	}
+	h = append(h, q)
+	return h.compress()
+}
+
+// mulExpansion returns the exact product of the expansions e and f.
+func mulExpansion(e, f expansion) expansion {
+	var h expansion
+	for _, c := range f {
+		h = h.add(e.scale(c))
+	}
+	return h
+}
+
+// estimate returns an approximation of the value of the expansion with exact sign.
+func (e expansion) estimate() float64 {
+	// Components are non-overlapping so the sum of the smaller components is
+	// smaller in magnitude than the largest one and rounding preserves its sign.
+	var sum float64
+	for _, c := range e {
+		sum += c
+	}
+	return sum
+}
@@ -4,7 +4,11 @@
 
 package md2
 
-import ms1 "github.com/soypat/geometry/md1"
+import (
+	math "math"
+	"github.com/soypat/geometry/i2"
+	ms1 "github.com/soypat/geometry/md1"
+)
 
 // AppendGrid splits the argument bounds [Box] x,y axes by nx,ny, respectively
 // and generates points on the vertices generated by the division and appends them to dst, returning the result.
@@ -53,3 +57,118 @@ func GridSubdomain(domain Box, nxDomain, nyDomain int, subdomain Box) (iStart, n
 	iy0, nySub = ms1.GridSubdomain(domain.Min.Y, domain.Max.Y, nyDomain, subdomain.Min.Y, subdomain.Max.Y)
 	return ix0 + iy0*nxDomain, nxSub, nySub
 }
+
+// GridStep is a grid cell visited by a [GridDDA] traversal.
+type GridStep struct {
+	// Cell contains the x,y indices of the visited cell.
+	Cell i2.Vec
+	// TEnter and TExit are the ray parameters at which the ray enters and exits the cell.
+	TEnter, TExit float64
+	// Normal is the normal of the cell edge crossed by the ray on entering the cell, pointing against the ray direction.
+	// It is the zero vector if the ray starts inside the cell.
+	Normal i2.Vec
+}
+
+// GridDDA walks the cells of a regular grid pierced by a ray in order using the
+// Amanatides-Woo digital differential analyzer (DDA) algorithm. It visits every cell the ray passes through
+// exactly once, which makes it suitable for ray casting and line of sight queries. The ray is given by
+//
+//	P(t) = origin + t*dir
+//
+// To traverse a segment [Line] l use origin=l[0], dir=l[1]-l[0], tmin=0 and tmax=1. Use [NewGridDDA] to create a GridDDA.
+type GridDDA struct {
+	cell   [2]int
+	n      [2]int
+	step   [2]int
+	tNext  [2]float64
+	tDelta [2]float64
+	// entryAxis is the axis crossed to enter current cell. -1 if ray starts in cell.
+	entryAxis int
+	t         float64
+	tEnd      float64
+	done      bool
+}
+
+// NewGridDDA returns a [GridDDA] that traverses the cells of a grid that divides the domain box
+// in nx, ny cells along x,y axes, respectively, along the ray between parameters tmin and tmax.
+// Note nx,ny are the amount of cells, whereas [AppendGrid] receives the amount of vertices along each axis.
+func NewGridDDA(domain Box, nx, ny int, origin, dir Vec, tmin, tmax float64) GridDDA {
+	if nx <= 0 || ny <= 0 {
+		panic("NewGridDDA needs at least one cell per dimension")
+	}
+	dda := GridDDA{n: [2]int{nx, ny}, entryAxis: -1, done: true}
+	o, d := origin.Array(), dir.Array()
+	lo := domain.Min.Array()
+	// Clip ray to domain with the slab method keeping track of the axis through which the ray enters.
+	t0, t1, entryAxis, ok := domain.clipRay(origin, dir, tmin, tmax)
+	if !ok {
+		return dda // Ray misses domain.
+	}
+	dda.entryAxis = entryAxis
+	size := DivElem(domain.Size(), Vec{X: float64(nx), Y: float64(ny)}).Array()
+	start := Add(origin, Scale(t0, dir)).Array()
+	for axis := range o {
+		c := int(math.Floor((start[axis] - lo[axis]) / size[axis]))
+		c = max(0, min(dda.n[axis]-1, c)) // Rounding errors may place start point outside domain.
+		dda.cell[axis] = c
+		dda.tNext[axis] = math.Inf(1)
+		dda.tDelta[axis] = math.Inf(1)
+		if d[axis] > 0 {
+			dda.step[axis] = 1
+			dda.tNext[axis] = (lo[axis] + float64(c+1)*size[axis] - o[axis]) / d[axis]
+			dda.tDelta[axis] = size[axis] / d[axis]
+		} else if d[axis] < 0 {
+			dda.step[axis] = -1
+			dda.tNext[axis] = (lo[axis] + float64(c)*size[axis] - o[axis]) / d[axis]
+			dda.tDelta[axis] = -size[axis] / d[axis]
+		}
+	}
+	dda.t = t0
+	dda.tEnd = t1
+	dda.done = false
+	return dda
+}
+
+// Next returns the next cell pierced by the ray. The boolean return value is false when the traversal is complete.
+func (dda *GridDDA) Next() (GridStep, bool) {
+	if dda.done {
+		return GridStep{}, false
+	}
+	axis := 0
+	if dda.tNext[1] < dda.tNext[0] {
+		axis = 1
+	}
+	tExit := math.Min(dda.tNext[axis], dda.tEnd)
+	step := GridStep{
+		Cell:   i2.Vec{X: dda.cell[0], Y: dda.cell[1]},
+		TEnter: dda.t,
+		TExit:  tExit,
+	}
+	if dda.entryAxis >= 0 {
+		var normal [2]int
+		normal[dda.entryAxis] = -dda.step[dda.entryAxis]
+		step.Normal = i2.Vec{X: normal[0], Y: normal[1]}
+	}
+	if tExit >= dda.tEnd {
+		dda.done = true
+		return step, true
+	}
+	// Advance to the neighboring cell across the nearest boundary.
+	dda.cell[axis] += dda.step[axis]
+	dda.t = tExit
+	dda.tNext[axis] += dda.tDelta[axis]
+	dda.entryAxis = axis
+	dda.done = dda.cell[axis] < 0 || dda.cell[axis] >= dda.n[axis]
+	return step, true
+}
+
+// AppendSteps appends the remaining cells of the traversal to dst and returns the result.
+func (dda *GridDDA) AppendSteps(dst []GridStep) []GridStep {
+	for {
+		step, ok := dda.Next()
+		if !ok {
+			return dst
+		}
+		dst = append(dst, step)
+	}
+}
@@ -0,0 +1,655 @@
+// DO NOT EDIT.
+// This file was generated automatically
+// from gen.go. Please do not edit this file.
+
+package md2
+
+import (
+	math "math"
+)
+
+// DefaultInfill returns an [Infill] with the argument line spacing and recommended parameters.
+func DefaultInfill(spacing float64) Infill {
+	return Infill{
+		Spacing:    spacing,
+		Resolution: spacing / 8,
+	}
+}
+
+// Infill generates fill patterns clipped to the region of a [Shape], such as the toolpaths of 3D printers,
+// laser engravers and plotters. The region may be a polygon with holes as output by [PolygonBuilder].
+//
+// Patterns are returned as polylines ordered greedily so that each one starts near where the previous one ended,
+// reversing open polylines and rotating the start of closed polylines to reduce travel moves between them.
+// Closed polylines repeat their first point at the end.
+type Infill struct {
+	// Spacing is the distance between adjacent lines or walls of the pattern. Parameter is required.
+	Spacing float64
+	// Angle is the direction of the pattern in radians, measured counter-clockwise from the x axis.
+	Angle float64
+	// Rule decides which regions enclosed by the rings of the shape are filled.
+	Rule FillRule
+	// Resolution is the grid cell size with which contour based patterns are traced,
+	// see [Infill.AppendConcentric] and [Infill.AppendGyroid]. Parameter is required for those patterns.
+	Resolution float64
+}
+
+// AppendHatch appends parallel lines at the infill angle spaced Spacing apart that fill the shape's region.
+// Lines lie on a fixed grid perpendicular to the angle so consecutive layers of a part line up.
+func (inf Infill) AppendHatch(dst []Polyline, s Shape) []Polyline {
+	inf.validate()
+	start := len(dst)
+	dst = inf.appendHatch(dst, s, inf.Angle)
+	return orderPolylines(dst, start)
+}
+
+// AppendCrosshatch appends two perpendicular sets of hatch lines, see [Infill.AppendHatch].
+func (inf Infill) AppendCrosshatch(dst []Polyline, s Shape) []Polyline {
+	inf.validate()
+	start := len(dst)
+	dst = inf.appendHatch(dst, s, inf.Angle)
+	dst = inf.appendHatch(dst, s, inf.Angle+math.Pi/2)
+	return orderPolylines(dst, start)
+}
+
+// AppendZigzag appends hatch lines, see [Infill.AppendHatch], where consecutive lines are joined into continuous
+// zigzag polylines wherever the straight connection between their ends lies inside the shape's region.
+func (inf Infill) AppendZigzag(dst []Polyline, s Shape) []Polyline {
+	inf.validate()
+	rot := RotationMat2(-inf.Angle)
+	local := transformShape(s, rot)
+	type chain struct {
+		pts Polyline
+		row int
+	}
+	var chains []*chain
+	var open, nextOpen []*chain
+	inf.scanRows(local, func(row int, y float64, xs []float64) {
+		nextOpen = nextOpen[:0]
+		for i := 0; i+1 < len(xs); i += 2 {
+			left, right := Vec{X: xs[i], Y: y}, Vec{X: xs[i+1], Y: y}
+			var best *chain
+			bestIdx := -1
+			bestDist := float64(math.Inf(1))
+			for j, c := range open {
+				if c == nil || c.row != row-1 {
+					continue
+				}
+				end := c.pts[len(c.pts)-1]
+				near := left
+				if Norm2(Sub(end, right)) < Norm2(Sub(end, left)) {
+					near = right
+				}
+				d := Norm2(Sub(end, near))
+				if d < bestDist && segmentInside(local, inf.Rule, end, near) {
+					best, bestIdx, bestDist = c, j, d
+				}
+			}
+			if best == nil {
+				best = &chain{}
+				chains = append(chains, best)
+			} else {
+				open[bestIdx] = nil // Each chain is extended at most once per row.
+				end := best.pts[len(best.pts)-1]
+				if Norm2(Sub(end, right)) < Norm2(Sub(end, left)) {
+					left, right = right, left
+				}
+			}
+			best.pts = append(best.pts, left, right)
+			best.row = row
+			nextOpen = append(nextOpen, best)
+		}
+		open, nextOpen = nextOpen, open
+	})
+	start := len(dst)
+	inv := rot.Transpose()
+	for _, c := range chains {
+		dst = append(dst, transformPolyline(c.pts, inv))
+	}
+	return orderPolylines(dst, start)
+}
+
+// AppendConcentric appends closed contours of the shape's region inset by odd multiples of Spacing/2,
+// so that adjacent contours are Spacing apart and the outermost contour lies Spacing/2 inside the boundary.
+// Contours are traced with marching squares on the signed distance to the shape, see [AppendContour].
+// The distance is sampled on a grid of Resolution spacing covering the shape's bounds, so time and memory grow
+// with the amount of grid nodes, the area of the bounds over Resolution squared, plus the boundary's length over Resolution.
+// Contours of all insets are traced in a single pass over the grid.
+func (inf Infill) AppendConcentric(dst []Polyline, s Shape) []Polyline {
+	inf.validate()
+	inf.validateResolution()
+	bounds := s.Bounds()
+	if bounds.Empty() {
+		return dst
+	}
+	domain, nx, ny := inf.contourGrid(bounds)
+	values := appendDistanceField(nil, s, inf.Rule, domain, nx, ny)
+	// Trace all contour levels in a single pass over the grid cells: each cell only spans a few levels.
+	level := func(k int) float64 { return -(float64(k) + 0.5) * inf.Spacing }
+	var segs [][]Line // Segments of each level.
+	d := DivElem(domain.Size(), Vec{X: float64(nx - 1), Y: float64(ny - 1)})
+	for j := 0; j < ny-1; j++ {
+		y0 := domain.Min.Y + d.Y*float64(j)
+		y1 := domain.Min.Y + d.Y*float64(j+1)
+		lo, hi := values[j*nx:(j+1)*nx], values[(j+1)*nx:(j+2)*nx]
+		for i := 0; i < nx-1; i++ {
+			val := [4]float64{lo[i], lo[i+1], hi[i+1], hi[i]}
+			vmin := math.Min(math.Min(val[0], val[1]), math.Min(val[2], val[3]))
+			vmax := math.Max(math.Max(val[0], val[1]), math.Max(val[2], val[3]))
+			if vmin >= level(0) {
+				continue // Cell outside of all levels.
+			}
+			x0 := domain.Min.X + d.X*float64(i)
+			x1 := domain.Min.X + d.X*float64(i+1)
+			pos := [4]Vec{{X: x0, Y: y0}, {X: x1, Y: y0}, {X: x1, Y: y1}, {X: x0, Y: y1}}
+			// Levels crossed by the cell satisfy vmin < level <= vmax, widened by one to absorb rounding.
+			kmin := max(0, int(-vmax/inf.Spacing-0.5)-1)
+			kmax := int(-vmin/inf.Spacing-0.5) + 1
+			for k := kmin; k <= kmax; k++ {
+				if l := level(k); vmin < l && l <= vmax {
+					for len(segs) <= k {
+						segs = append(segs, nil)
+					}
+					segs[k] = appendContourCell(segs[k], pos, val, l)
+				}
+			}
+		}
+	}
+	start := len(dst)
+	var lines [][]Vec
+	for _, levelSegs := range segs {
+		lines = AppendPolylines(lines[:0], levelSegs)
+		for _, line := range lines {
+			dst = append(dst, Polyline(line))
+		}
+	}
+	return orderPolylines(dst, start)
+}
+
+// AppendHoneycomb appends a hexagonal cell pattern clipped to the shape's region. Cells are regular hexagons whose
+// opposite walls are Spacing apart, traced as rows of zigzag polylines that share the cells' walls parallel to the infill angle.
+func (inf Infill) AppendHoneycomb(dst []Polyline, s Shape) []Polyline {
+	inf.validate()
+	rot := RotationMat2(-inf.Angle)
+	local := transformShape(s, rot)
+	bounds := local.Bounds()
+	if bounds.Empty() {
+		return dst
+	}
+	// Hexagon of side a with walls parallel to the x axis: rows of horizontal walls are h apart.
+	h := inf.Spacing / 2
+	a := inf.Spacing / math.Sqrt(3)
+	period := 3 * a
+	x0 := math.Floor(bounds.Min.X/period)*period - period
+	x1 := bounds.Max.X + period
+	start := len(dst)
+	inv := rot.Transpose()
+	var wave Polyline
+	for k := int(math.Floor(bounds.Min.Y / h)); float64(k)*h < bounds.Max.Y; k++ {
+		// The wave between wall rows k and k+1. Even rows have walls starting at multiples of the period,
+		// odd rows have walls offset by half a period.
+		ylo, yhi := float64(k)*h, float64(k+1)*h
+		phaseLo := float64(0)
+		if k&1 != 0 {
+			phaseLo = period / 2
+		}
+		wave = wave[:0]
+		for x := x0 + phaseLo; x < x1; x += period {
+			wave = append(wave,
+				Vec{X: x, Y: ylo}, Vec{X: x + a, Y: ylo},
+				Vec{X: x + 1.5*a, Y: yhi}, Vec{X: x + 2.5*a, Y: yhi},
+			)
+		}
+		for _, piece := range appendClippedPolyline(nil, local, inf.Rule, wave) {
+			dst = append(dst, transformPolyline(piece, inv))
+		}
+	}
+	return orderPolylines(dst, start)
+}
+
+// AppendGyroid appends the slice at height z of a gyroid surface clipped to the shape's region. The gyroid's
+// period is 2*Spacing so that adjacent walls are about Spacing apart. Varying z between layers yields
+// the 3D gyroid infill pattern. The slice is traced with marching squares, see [AppendContourFunc].
+func (inf Infill) AppendGyroid(dst []Polyline, s Shape, z float64) []Polyline {
+	inf.validate()
+	inf.validateResolution()
+	bounds := s.Bounds()
+	if bounds.Empty() {
+		return dst
+	}
+	rot := RotationMat2(-inf.Angle)
+	k := math.Pi / inf.Spacing
+	sinz, cosz := math.Sincos(k * z)
+	gyroid := func(p Vec) float64 {
+		p = MulMatVec(rot, p)
+		sinx, cosx := math.Sincos(k * p.X)
+		siny, cosy := math.Sincos(k * p.Y)
+		return sinx*cosy + siny*cosz + sinz*cosx
+	}
+	domain, nx, ny := inf.contourGrid(bounds)
+	segs := AppendContourFunc(nil, domain, nx, ny, gyroid, 0)
+	start := len(dst)
+	for _, line := range AppendPolylines(nil, segs) {
+		dst = appendClippedPolyline(dst, s, inf.Rule, line)
+	}
+	return orderPolylines(dst, start)
+}
+
+func (inf Infill) validate() {
+	if inf.Spacing <= 0 || math.IsNaN(inf.Spacing) || math.IsInf(inf.Spacing, 0) {
+		panic("infill spacing must be positive and finite")
+	}
+}
+
+func (inf Infill) validateResolution() {
+	if inf.Resolution <= 0 || inf.Resolution > inf.Spacing {
+		panic("infill resolution must be positive and not larger than spacing")
+	}
+}
+
+// contourGrid returns a grid of Resolution sized cells that covers bounds with a margin of one cell.
+func (inf Infill) contourGrid(bounds Box) (domain Box, nx, ny int) {
+	domain = bounds.expand(inf.Resolution)
+	size := domain.Size()
+	nx = int(math.Ceil(size.X/inf.Resolution)) + 1
+	ny = int(math.Ceil(size.Y/inf.Resolution)) + 1
+	domain.Max = Add(domain.Min, Scale(inf.Resolution, Vec{X: float64(nx - 1), Y: float64(ny - 1)}))
+	return domain, nx, ny
+}
+
+// appendHatch appends unordered hatch segments at the argument angle.
+func (inf Infill) appendHatch(dst []Polyline, s Shape, angle float64) []Polyline {
+	rot := RotationMat2(-angle)
+	inv := rot.Transpose()
+	local := transformShape(s, rot)
+	inf.scanRows(local, func(row int, y float64, xs []float64) {
+		for i := 0; i+1 < len(xs); i += 2 {
+			dst = append(dst, Polyline{
+				MulMatVec(inv, Vec{X: xs[i], Y: y}),
+				MulMatVec(inv, Vec{X: xs[i+1], Y: y}),
+			})
+		}
+	})
+	return dst
+}
+
+// scanRows calls fn for each horizontal scanline at odd multiples of Spacing/2 that crosses the shape with
+// the increasing x coordinates of the start and end of the intervals of the scanline inside the shape.
+func (inf Infill) scanRows(s Shape, fn func(row int, y float64, xs []float64)) {
+	bounds := s.Bounds()
+	if bounds.Empty() {
+		return
+	}
+	type crossing struct {
+		x   float64
+		dir int
+	}
+	var crossings []crossing
+	var xs []float64
+	kmin := int(math.Ceil(bounds.Min.Y/inf.Spacing - 0.5))
+	kmax := int(math.Floor(bounds.Max.Y/inf.Spacing - 0.5))
+	for k := kmin; k <= kmax; k++ {
+		y := (float64(k) + 0.5) * inf.Spacing
+		crossings = crossings[:0]
+		for _, ring := range s.Rings {
+			if len(ring) < 3 {
+				continue
+			}
+			prev := ring[len(ring)-1]
+			for _, v := range ring {
+				// Half-open rule so scanlines through vertices count each crossing once.
+				if (prev.Y <= y) != (v.Y <= y) {
+					t := (y - prev.Y) / (v.Y - prev.Y)
+					dir := 1
+					if v.Y < prev.Y {
+						dir = -1
+					}
+					crossings = append(crossings, crossing{x: prev.X + t*(v.X-prev.X), dir: dir})
+				}
+				prev = v
+			}
+		}
+		// Insertion sort: scanlines cross few edges.
+		for i := 1; i < len(crossings); i++ {
+			for j := i; j > 0 && crossings[j].x < crossings[j-1].x; j-- {
+				crossings[j], crossings[j-1] = crossings[j-1], crossings[j]
+			}
+		}
+		xs = xs[:0]
+		winding := 0
+		for _, c := range crossings {
+			wasInside := fillRuleInside(winding, inf.Rule)
+			winding += c.dir
+			if fillRuleInside(winding, inf.Rule) != wasInside {
+				xs = append(xs, c.x)
+			}
+		}
+		// Drop degenerate intervals from coincident crossings.
+		n := 0
+		for i := 0; i+1 < len(xs); i += 2 {
+			if xs[i+1] > xs[i] {
+				xs[n], xs[n+1] = xs[i], xs[i+1]
+				n += 2
+			}
+		}
+		if n > 0 {
+			fn(k, y, xs[:n])
+		}
+	}
+}
+
+func fillRuleInside(winding int, rule FillRule) bool {
+	if rule == FillEvenOdd {
+		return winding&1 != 0
+	}
+	return winding != 0
+}
+
+// appendClippedPolyline appends the portions of the polyline that lie inside the shape's region to dst.
+func appendClippedPolyline(dst []Polyline, s Shape, rule FillRule, pl []Vec) []Polyline {
+	var current Polyline
+	var ts []float64
+	flush := func() {
+		if len(current) >= 2 {
+			dst = append(dst, current)
+		}
+		current = nil
+	}
+	for i := 0; i+1 < len(pl); i++ {
+		a, b := pl[i], pl[i+1]
+		d := Sub(b, a)
+		ts = append(ts[:0], 0, 1)
+		ts = appendShapeCrossings(ts, s, a, b)
+		for k := 1; k < len(ts); k++ {
+			for m := k; m > 0 && ts[m] < ts[m-1]; m-- {
+				ts[m], ts[m-1] = ts[m-1], ts[m]
+			}
+		}
+		for j := 0; j+1 < len(ts); j++ {
+			t0, t1 := ts[j], ts[j+1]
+			if t1 <= t0 {
+				continue
+			}
+			if !s.Contains(Add(a, Scale((t0+t1)/2, d)), rule) {
+				flush()
+				continue
+			}
+			p0, p1 := Add(a, Scale(t0, d)), Add(a, Scale(t1, d))
+			if len(current) == 0 {
+				current = append(current, p0)
+			}
+			current = append(current, p1)
+		}
+	}
+	flush()
+	return dst
+}
+
+// appendShapeCrossings appends the parameters in (0,1) at which the segment ab crosses the shape's rings.
+func appendShapeCrossings(ts []float64, s Shape, a, b Vec) []float64 {
+	d := Sub(b, a)
+	for _, ring := range s.Rings {
+		if len(ring) < 2 {
+			continue
+		}
+		prev := ring[len(ring)-1]
+		for _, v := range ring {
+			e := Sub(v, prev)
+			denom := Cross(d, e)
+			if denom != 0 {
+				ap := Sub(prev, a)
+				t := Cross(ap, e) / denom
+				u := Cross(ap, d) / denom
+				if t > 0 && t < 1 && u >= 0 && u <= 1 {
+					ts = append(ts, t)
+				}
+			}
+			prev = v
+		}
+	}
+	return ts
+}
+
+// segmentInside reports whether the segment ab lies inside the shape's region, allowing its end points to lie on the boundary.
+func segmentInside(s Shape, rule FillRule, a, b Vec) bool {
+	const eps = 1e-4
+	ts := appendShapeCrossings(nil, s, a, b)
+	for _, t := range ts {
+		if t > eps && t < 1-eps {
+			return false
+		}
+	}
+	// Segments running along the boundary are accepted.
+	return shapeDistance(s, rule, Scale(0.5, Add(a, b))) <= eps*Norm(Sub(b, a))
+}
+
+// shapeDistance returns the signed distance from p to the boundary of the shape's region, negative inside.
+func shapeDistance(s Shape, rule FillRule, p Vec) float64 {
+	dist := float64(math.Inf(1))
+	for _, ring := range s.Rings {
+		if len(ring) < 2 {
+			continue
+		}
+		prev := ring[len(ring)-1]
+		for _, v := range ring {
+			dist = math.Min(dist, segmentDistance(prev, v, p))
+			prev = v
+		}
+	}
+	if s.Contains(p, rule) {
+		return -dist
+	}
+	return dist
+}
+
+// appendDistanceField appends the signed distance to the boundary of s at the nodes of the nx*ny grid over
+// domain to dst in the order of [AppendGrid]. Distances are negative inside the shape's region.
+// Nodes near an edge get their exact distance to it, and the nearest edges found are propagated to the remaining
+// nodes by two raster sweeps over the grid (vector distance transform), which is exact but for rare small errors
+// far from the boundary. Insideness is decided per grid row from the sorted edge crossings of the row.
+// Time is proportional to the amount of nodes plus the boundary length in grid cells, unlike [shapeDistance]
+// which visits every edge for every node.
+func appendDistanceField(dst []float64, s Shape, rule FillRule, domain Box, nx, ny int) []float64 {
+	d := DivElem(domain.Size(), Vec{X: float64(nx - 1), Y: float64(ny - 1)})
+	node := func(i, j int) Vec {
+		return Vec{X: domain.Min.X + d.X*float64(i), Y: domain.Min.Y + d.Y*float64(j)}
+	}
+	var edges []Line
+	for _, ring := range s.Rings {
+		if len(ring) < 3 {
+			continue
+		}
+		prev := ring[len(ring)-1]
+		for _, v := range ring {
+			edges = append(edges, Line{prev, v})
+			prev = v
+		}
+	}
+	start := len(dst)
+	for k := 0; k < nx*ny; k++ {
+		dst = append(dst, float64(math.Inf(1)))
+	}
+	dist := dst[start:]
+	nearest := make([]int32, nx*ny)
+	for k := range nearest {
+		nearest[k] = -1
+	}
+	try := func(k, i, j int, e int32) {
+		if e < 0 || e == nearest[k] {
+			return
+		}
+		if dd := segmentDistance2(edges[e][0], edges[e][1], node(i, j)); dd < dist[k] {
+			dist[k], nearest[k] = dd, e
+		}
+	}
+	// Seed nodes around each edge by walking it in steps of half a grid cell.
+	step := math.Min(d.X, d.Y) / 2
+	for e, edge := range edges {
+		n := int(Norm(Sub(edge[1], edge[0]))/step) + 1
+		for m := 0; m <= n; m++ {
+			q := DivElem(Sub(edge.Interpolate(float64(m)/float64(n)), domain.Min), d)
+			i0, j0 := int(math.Floor(q.X)), int(math.Floor(q.Y))
+			for j := max(0, j0-1); j <= min(ny-1, j0+2); j++ {
+				for i := max(0, i0-1); i <= min(nx-1, i0+2); i++ {
+					try(j*nx+i, i, j, int32(e))
+				}
+			}
+		}
+	}
+	// Propagate nearest edges with a forward and a backward sweep, each visiting rows in both directions.
+	for j := 0; j < ny; j++ {
+		for i := 0; i < nx; i++ {
+			k := j*nx + i
+			if i > 0 {
+				try(k, i, j, nearest[k-1])
+			}
+			if j > 0 {
+				for di := -1; di <= 1; di++ {
+					if i+di >= 0 && i+di < nx {
+						try(k, i, j, nearest[k-nx+di])
+					}
+				}
+			}
+		}
+		for i := nx - 2; i >= 0; i-- {
+			try(j*nx+i, i, j, nearest[j*nx+i+1])
+		}
+	}
+	for j := ny - 1; j >= 0; j-- {
+		for i := nx - 1; i >= 0; i-- {
+			k := j*nx + i
+			if i < nx-1 {
+				try(k, i, j, nearest[k+1])
+			}
+			if j < ny-1 {
+				for di := -1; di <= 1; di++ {
+					if i+di >= 0 && i+di < nx {
+						try(k, i, j, nearest[k+nx+di])
+					}
+				}
+			}
+		}
+		for i := 1; i < nx; i++ {
+			try(j*nx+i, i, j, nearest[j*nx+i-1])
+		}
+	}
+	for k, dd := range dist {
+		dist[k] = math.Sqrt(dd) // Squared distances were compared up to here.
+	}
+	// Negate distances inside the shape, crossings of each row are bucketed from the rows spanned by each edge.
+	type crossing struct {
+		x   float64
+		dir int
+	}
+	rows := make([][]crossing, ny)
+	for _, edge := range edges {
+		a, b := edge[0], edge[1]
+		if a.Y == b.Y {
+			continue
+		}
+		lo, hi := math.Min(a.Y, b.Y), math.Max(a.Y, b.Y)
+		// Half-open rule so rows through vertices count each crossing once, see [Infill.scanRows].
+		jmin := max(0, int(math.Floor((lo-domain.Min.Y)/d.Y)))
+		jmax := min(ny-1, int(math.Ceil((hi-domain.Min.Y)/d.Y)))
+		dir := 1
+		if b.Y < a.Y {
+			dir = -1
+		}
+		for j := jmin; j <= jmax; j++ {
+			y := node(0, j).Y
+			if (a.Y <= y) != (b.Y <= y) {
+				t := (y - a.Y) / (b.Y - a.Y)
+				rows[j] = append(rows[j], crossing{x: a.X + t*(b.X-a.X), dir: dir})
+			}
+		}
+	}
+	for j, crossings := range rows {
+		// Insertion sort: rows cross few edges.
+		for i := 1; i < len(crossings); i++ {
+			for m := i; m > 0 && crossings[m].x < crossings[m-1].x; m-- {
+				crossings[m], crossings[m-1] = crossings[m-1], crossings[m]
+			}
+		}
+		winding, c := 0, 0
+		for i := 0; i < nx; i++ {
+			x := node(i, j).X
+			for ; c < len(crossings) && crossings[c].x < x; c++ {
+				winding += crossings[c].dir
+			}
+			if fillRuleInside(winding, rule) {
+				dist[j*nx+i] = -dist[j*nx+i]
+			}
+		}
+	}
+	return dst
+}
+
+func transformShape(s Shape, m Mat2) Shape {
+	rings := make([][]Vec, len(s.Rings))
+	for i, ring := range s.Rings {
+		rings[i] = transformPolyline(ring, m)
+	}
+	return Shape{Rings: rings}
+}
+
+func transformPolyline(pl []Vec, m Mat2) Polyline {
+	out := make(Polyline, len(pl))
+	for i, v := range pl {
+		out[i] = MulMatVec(m, v)
+	}
+	return out
+}
+
+// orderPolylines reorders dst[start:] greedily so that each polyline starts at the closest available end point to where
+// the previous polyline ended. Open polylines may be reversed and closed polylines are rotated to start at their closest vertex.
+func orderPolylines(dst []Polyline, start int) []Polyline {
+	paths := dst[start:]
+	if len(paths) == 0 {
+		return dst
+	}
+	var cur Vec
+	if start > 0 && len(dst[start-1]) > 0 {
+		prev := dst[start-1]
+		cur = prev[len(prev)-1]
+	} else {
+		cur = paths[0][0]
+	}
+	for i := range paths {
+		best, bestVertex := i, 0
+		bestDist := float64(math.Inf(1))
+		for j := i; j < len(paths); j++ {
+			pl := paths[j]
+			if isClosedPolyline(pl) {
+				for k, v := range pl[:len(pl)-1] {
+					if d := Norm2(Sub(v, cur)); d < bestDist {
+						best, bestVertex, bestDist = j, k, d
+					}
+				}
+				continue
+			}
+			if d := Norm2(Sub(pl[0], cur)); d < bestDist {
+				best, bestVertex, bestDist = j, 0, d
+			}
+			if d := Norm2(Sub(pl[len(pl)-1], cur)); d < bestDist {
+				best, bestVertex, bestDist = j, len(pl)-1, d
+			}
+		}
+		paths[i], paths[best] = paths[best], paths[i]
+		pl := paths[i]
+		switch {
+		case isClosedPolyline(pl) && bestVertex != 0:
+			// Rotate the ring so it starts and ends at bestVertex.
+			ring := append(Polyline{}, pl[bestVertex:len(pl)-1]...)
+			ring = append(ring, pl[:bestVertex+1]...)
+			paths[i] = ring
+		case !isClosedPolyline(pl) && bestVertex != 0:
+			pl.Reverse()
+		}
+		cur = paths[i][len(paths[i])-1]
+	}
+	return dst
+}
+
+func isClosedPolyline(pl Polyline) bool {
+	return len(pl) > 2 && pl[0] == pl[len(pl)-1]
+}
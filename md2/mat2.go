@@ -167,3 +167,45 @@ func RotationMat2(a float64) Mat2 {
 		s, c,
 	}
 }
+
+// Affine is a 2D affine transformation composed of a linear transformation followed by a translation:
+//
+//	result = Mat * v + Translation
+type Affine struct {
+	Mat         Mat2
+	Translation Vec
+}
+
+// IdentityAffine returns the identity affine transformation.
+func IdentityAffine() Affine {
+	return Affine{Mat: IdentityMat2()}
+}
+
+// Apply applies the affine transformation to v.
+func (a Affine) Apply(v Vec) Vec {
+	return Add(MulMatVec(a.Mat, v), a.Translation)
+}
+
+// Inverse returns the inverse transformation of a. The result contains NaN values if a is singular.
+func (a Affine) Inverse() Affine {
+	inv := a.Mat.Inverse()
+	return Affine{Mat: inv, Translation: Scale(-1, MulMatVec(inv, a.Translation))}
+}
+
+// MulAffine returns the composition of a and b, which applies b first and then a:
+//
+//	MulAffine(a, b).Apply(v) == a.Apply(b.Apply(v))
+func MulAffine(a, b Affine) Affine {
+	return Affine{Mat: MulMat2(a.Mat, b.Mat), Translation: a.Apply(b.Translation)}
+}
+
+// ApplyBox returns the bounding box of the transformed corners of box b.
+func (a Affine) ApplyBox(b Box) Box {
+	v := b.Vertices()
+	p := a.Apply(v[0])
+	result := Box{Min: p, Max: p}
+	for _, vert := range v[1:] {
+		result = result.IncludePoint(a.Apply(vert))
+	}
+	return result
+}
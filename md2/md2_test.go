@@ -5,10 +5,12 @@
 package md2
 
 import (
+	"math/big"
 	"math/rand"
 	"testing"
 
 	math "math"
+	"github.com/soypat/geometry/i2"
 )
 
 // TestLineDistanceInfinite2 checks DistanceInfinite2 equals DistanceInfinite squared.
@@ -227,3 +229,733 @@ func TestTriangleClosestFlags(t *testing.T) {
 		}
 	}
 }
+
+func TestQuadtreeDecomposeDFS(t *testing.T) {
+	qt := Quadtree{Resolution: 0.5, Origin: Vec{X: -1, Y: 2}}
+	root := i2.Square{Level: 4}
+	rootBox := qt.SquareBox(root, qt.SquareSize(root))
+	if rootBox.Size() != (Vec{X: 4, Y: 4}) {
+		t.Fatalf("unexpected root box %v", rootBox)
+	}
+	squares := make([]i2.Square, 1, 64)
+	squares[0] = root
+	// Limit corner buffer so decomposition must be resumed several times.
+	var corners []Vec
+	buf := make([]Vec, 0, 64)
+	for len(squares) > 0 {
+		buf, squares = qt.DecomposeDFS(buf[:0], squares)
+		if len(buf) == 0 {
+			t.Fatal("no progress made")
+		}
+		corners = append(corners, buf...)
+	}
+	wantSquares := int(root.DecomposesTo(1))
+	if len(corners) != 4*wantSquares {
+		t.Fatalf("want %d corners, got %d", 4*wantSquares, len(corners))
+	}
+	seen := make(map[Vec]bool)
+	for i := 0; i < len(corners); i += 4 {
+		origin := corners[i]
+		if seen[origin] {
+			t.Errorf("square at %v visited twice", origin)
+		}
+		seen[origin] = true
+		box := Box{Min: corners[i], Max: corners[i+2]}
+		if !rootBox.ContainsBox(box) {
+			t.Errorf("square %v outside root %v", box, rootBox)
+		}
+		if box.Size() != (Vec{X: qt.Resolution, Y: qt.Resolution}) {
+			t.Errorf("square %v not of resolution size", box)
+		}
+	}
+
+	// BFS decomposition down to level 2 yields all level-2 squares.
+	bfs, ok := qt.DecomposeBFS(make([]i2.Square, 0, 64), root, 2)
+	if !ok || len(bfs) != int(root.DecomposesTo(2)) {
+		t.Fatalf("BFS: ok=%v, want %d squares, got %d", ok, root.DecomposesTo(2), len(bfs))
+	}
+	for _, sq := range bfs {
+		if sq.Level != 2 || sq.Quadtree()[0].Supersquare() != sq {
+			t.Errorf("unexpected square %+v", sq)
+		}
+	}
+}
+
+func TestGridDDA(t *testing.T) {
+	domain := Box{Min: Vec{X: -1, Y: 0}, Max: Vec{X: 3, Y: 2}}
+	const nx, ny = 8, 5
+	size := DivElem(domain.Size(), Vec{X: nx, Y: ny})
+	rng := rand.New(rand.NewSource(1))
+	randVec := func() Vec {
+		return Vec{X: float64(rng.Float64()*8 - 3), Y: float64(rng.Float64()*6 - 2)}
+	}
+	for i := 0; i < 200; i++ {
+		seg := Line{randVec(), randVec()}
+		dir := Sub(seg[1], seg[0])
+		dda := NewGridDDA(domain, nx, ny, seg[0], dir, 0, 1)
+		steps := dda.AppendSteps(nil)
+		visited := make(map[i2.Vec]bool)
+		for j, step := range steps {
+			c := step.Cell
+			visited[c] = true
+			if step.TEnter > step.TExit || c.X < 0 || c.Y < 0 || c.X >= nx || c.Y >= ny {
+				t.Fatalf("seg %v: bad step %+v", seg, step)
+			}
+			if j == 0 {
+				continue
+			}
+			prev := steps[j-1]
+			d := c.Sub(prev.Cell)
+			if d.X*d.X+d.Y*d.Y != 1 || prev.Cell.Sub(c) != step.Normal || prev.TExit != step.TEnter {
+				t.Fatalf("seg %v: discontinuous steps %+v -> %+v", seg, prev, step)
+			}
+		}
+		// Points sampled along the segment must lie within visited cells.
+		for k := 1; k < 100; k++ {
+			p := Add(seg[0], Scale(float64(k)/100, dir))
+			rel := DivElem(Sub(p, domain.Min), size)
+			frac := Sub(rel, FloorElem(rel))
+			if !domain.Contains(p) || frac.Min() < 1e-3 || frac.Max() > 1-1e-3 {
+				continue // Outside or too close to cell boundary.
+			}
+			cell := i2.Vec{X: int(rel.X), Y: int(rel.Y)}
+			if !visited[cell] {
+				t.Fatalf("seg %v: cell %v containing %v not visited", seg, cell, p)
+			}
+		}
+	}
+}
+
+func TestContour(t *testing.T) {
+	domain := Box{Min: Vec{X: -3, Y: -2}, Max: Vec{X: 3, Y: 2}}
+	centers := []Vec{{X: -1.5}, {X: 1.5}}
+	const radius = 1
+	sdf := func(p Vec) float64 {
+		return math.Min(Norm(Sub(p, centers[0])), Norm(Sub(p, centers[1]))) - radius
+	}
+	const nx, ny = 61, 41
+	segs := AppendContourFunc(nil, domain, nx, ny, sdf, 0)
+	values := make([]float64, 0, nx*ny)
+	for _, p := range AppendGrid(nil, domain, nx, ny) {
+		values = append(values, sdf(p))
+	}
+	segsGrid := AppendContour(nil, domain, nx, ny, values, 0)
+	if len(segs) != len(segsGrid) {
+		t.Fatalf("func and grid contours differ: %d vs %d segments", len(segs), len(segsGrid))
+	}
+	lines := AppendPolylines(nil, segs)
+	if len(lines) != 2 {
+		t.Fatalf("want 2 contours, got %d", len(lines))
+	}
+	for _, line := range lines {
+		if line[0] != line[len(line)-1] {
+			t.Fatal("contour not closed")
+		}
+		var area float64
+		for i := 0; i < len(line)-1; i++ {
+			area += line[i].X*line[i+1].Y - line[i+1].X*line[i].Y
+			if d := math.Abs(sdf(line[i])); d > 0.01 {
+				t.Errorf("contour point %v off by %g", line[i], d)
+			}
+		}
+		area /= 2
+		if want := math.Pi * radius * radius; math.Abs(area-want) > 0.02*want {
+			t.Errorf("contour should be counter-clockwise with area %g, got %g", want, area)
+		}
+	}
+	// Saddle cell with corners (0,0) and (1,1) inside. Center value decides which corners are cut off.
+	unit := Box{Max: Vec{X: 1, Y: 1}}
+	saddle := []float64{-1, 1, 1, -1}
+	for _, level := range []float64{-0.5, 0.5} {
+		segs := AppendContour(nil, unit, 2, 2, saddle, level)
+		if len(segs) != 2 {
+			t.Fatalf("saddle should produce 2 segments, got %d", len(segs))
+		}
+		cutCorners := []Vec{{X: 0, Y: 0}, {X: 1, Y: 1}} // Inside corners isolated.
+		if level > 0 {
+			cutCorners = []Vec{{X: 1, Y: 0}, {X: 0, Y: 1}} // Outside corners isolated.
+		}
+		for _, seg := range segs {
+			mid := Scale(0.5, Add(seg[0], seg[1]))
+			if Norm(Sub(mid, cutCorners[0])) > 0.5 && Norm(Sub(mid, cutCorners[1])) > 0.5 {
+				t.Errorf("level %g: segment %v does not cut off corners %v", level, seg, cutCorners)
+			}
+		}
+	}
+}
+
+func TestVoronoiDelaunay(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+	bounds := Box{Min: Vec{X: -2, Y: -1}, Max: Vec{X: 3, Y: 2}}
+	var sites []Vec
+	for i := 0; i < 100; i++ {
+		sites = append(sites, Vec{X: float64(rng.Float64()*6 - 2.5), Y: float64(rng.Float64()*4 - 1.5)})
+	}
+	// Grid sites are cocircular, a degenerate case for the Delaunay triangulation.
+	for ix := 0; ix < 4; ix++ {
+		for iy := 0; iy < 4; iy++ {
+			sites = append(sites, Vec{X: float64(ix), Y: float64(iy)})
+		}
+	}
+	sites = append(sites, sites[5]) // Repeated site.
+	vor, err := NewVoronoi(sites, bounds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	del := vor.Delaunay()
+	if len(del.Triangles()) == 0 {
+		t.Fatal("no Delaunay triangles")
+	}
+	for i := range del.Triangles() {
+		tri := del.Triangle(i)
+		if tri.Area() <= 0 {
+			t.Fatalf("triangle %v not counter-clockwise", tri)
+		}
+		// Circumcircle must not contain other sites.
+		a, b, c := tri[0], tri[1], tri[2]
+		d := 2 * (a.X*(b.Y-c.Y) + b.X*(c.Y-a.Y) + c.X*(a.Y-b.Y))
+		center := Vec{
+			X: (Norm2(a)*(b.Y-c.Y) + Norm2(b)*(c.Y-a.Y) + Norm2(c)*(a.Y-b.Y)) / d,
+			Y: (Norm2(a)*(c.X-b.X) + Norm2(b)*(a.X-c.X) + Norm2(c)*(b.X-a.X)) / d,
+		}
+		r := Norm(Sub(a, center))
+		for _, s := range sites {
+			if Norm(Sub(s, center)) < r*(1-1e-3) {
+				t.Fatalf("site %v inside circumcircle of %v", s, tri)
+			}
+		}
+	}
+	var totalArea float64
+	for i := range sites {
+		cell := vor.Cell(i)
+		for k := range cell {
+			a, b := cell[k], cell[(k+1)%len(cell)]
+			totalArea += (a.X*b.Y - b.X*a.Y) / 2
+		}
+		for _, j := range vor.Neighbors(i) {
+			found := false
+			for _, k := range vor.Neighbors(j) {
+				found = found || k == i
+			}
+			if !found {
+				t.Errorf("neighbor relation %d-%d not symmetric", i, j)
+			}
+		}
+	}
+	if len(vor.Cell(len(sites)-1)) != 0 {
+		t.Error("repeated site should have empty cell")
+	}
+	if math.Abs(totalArea-bounds.Area()) > 1e-3*bounds.Area() {
+		t.Errorf("cells area %g does not add up to bounds area %g", totalArea, bounds.Area())
+	}
+	// Points belong to the cell of their nearest site.
+	for i := 0; i < 500; i++ {
+		p := Vec{X: float64(rng.Float64()*5 - 2), Y: float64(rng.Float64()*3 - 1)}
+		nearest, best := 0, float64(math.MaxFloat32)
+		for j, s := range sites {
+			if d := Norm(Sub(p, s)); d < best {
+				nearest, best = j, d
+			}
+		}
+		if got := vor.Nearest(p); Norm(Sub(p, sites[got])) > best*(1+1e-5) {
+			t.Fatalf("Nearest(%v)=%d want %d", p, got, nearest)
+		}
+		cell := vor.Cell(nearest)
+		for k := range cell {
+			a, b := cell[k], cell[(k+1)%len(cell)]
+			if cross := (b.X-a.X)*(p.Y-a.Y) - (b.Y-a.Y)*(p.X-a.X); cross < -1e-4 {
+				t.Fatalf("point %v outside cell of nearest site %d", p, nearest)
+			}
+		}
+	}
+	// Lloyd relaxation keeps sites within bounds.
+	centroids := vor.AppendCentroids(nil)
+	for i, c := range centroids {
+		if len(vor.Cell(i)) > 0 && !bounds.Contains(c) {
+			t.Errorf("centroid %v outside bounds", c)
+		}
+	}
+	// Small diagrams.
+	vor, err = NewVoronoi([]Vec{{X: -1}, {X: 1}}, bounds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(vor.Cell(0)) != 4 || len(vor.Neighbors(0)) != 1 {
+		t.Errorf("two site diagram: cell %v, neighbors %v", vor.Cell(0), vor.Neighbors(0))
+	}
+}
+
+func TestGJK(t *testing.T) {
+	const tol = 1e-3
+	gjk := DefaultGJKSolver()
+	ca := Circle{Center: Vec{}, Radius: 1}
+	cb := Circle{Center: Vec{X: 3, Y: 4}, Radius: 2}
+	dist, pa, pb := gjk.Distance(ca, cb)
+	if math.Abs(dist-2) > tol || !EqualElem(pa, Vec{X: 0.6, Y: 0.8}, 1e-2) || !EqualElem(pb, Vec{X: 1.8, Y: 2.4}, 1e-2) {
+		t.Errorf("circle distance: got %g %v %v", dist, pa, pb)
+	}
+	box := Box{Min: Vec{X: -1, Y: -1}, Max: Vec{X: 1, Y: 1}}
+	tri := Triangle{{X: 3, Y: -5}, {X: 6, Y: 0}, {X: 3, Y: 5}}
+	dist, pa, pb = gjk.Distance(box, tri)
+	if math.Abs(dist-2) > tol || math.Abs(pa.X-1) > tol || math.Abs(pb.X-3) > tol {
+		t.Errorf("box-triangle distance: got %g %v %v", dist, pa, pb)
+	}
+	if gjk.Overlap(box, tri) || !gjk.Overlap(box, Line{{X: -2, Y: 0.5}, {X: 2, Y: 0.7}}) {
+		t.Error("unexpected overlap result")
+	}
+	verts := box.Vertices()
+	hull := ConvexPoints(verts[:])
+	depth, normal, ok := gjk.Penetration(hull, box.Add(Vec{X: 1.6, Y: 0.3}))
+	if !ok || math.Abs(depth-0.4) > tol || !EqualElem(normal, Vec{X: 1}, tol) {
+		t.Errorf("box penetration: got %g %v %v", depth, normal, ok)
+	}
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 100; i++ {
+		c := Vec{X: float64(rng.Float64()*6 - 3), Y: float64(rng.Float64()*6 - 3)}
+		r := float64(rng.Float64()*2 + 0.1)
+		circ := Circle{Center: c, Radius: r}
+		want := Norm(c) - r - 1
+		if want > 0 {
+			if dist, _, _ := gjk.Distance(ca, circ); math.Abs(dist-want) > 1e-2 {
+				t.Fatalf("circle %v: got distance %g want %g", circ, dist, want)
+			}
+		} else if depth, normal, ok := gjk.Penetration(ca, circ); !ok || math.Abs(depth+want) > 1e-2*(1+r) || (Norm(c) > 0.1 && !EqualElem(normal, Unit(c), 0.05)) {
+			t.Fatalf("circle %v: got penetration %g %v want %g %v", circ, depth, normal, -want, Unit(c))
+		}
+	}
+}
+
+func TestSDF(t *testing.T) {
+	const tol = 1e-4
+	var pb PolygonBuilder
+	pb.Nagon(6, 2)
+	hexVerts, err := pb.AppendVecs(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	box := Box{Min: Vec{X: -1, Y: -0.5}, Max: Vec{X: 2, Y: 1}}
+	boxVerts := box.Vertices()
+	tri := Triangle{{X: 0, Y: 0}, {X: 1, Y: 3}, {X: 2, Y: -1}}
+	rot := Affine{Mat: RotationMat2(0.7), Translation: Vec{X: 1, Y: -2}}
+	pairs := []struct {
+		name string
+		a, b SDF
+	}{
+		{"nagon", SDFNagon(6, 2), SDFPolygon(hexVerts)},
+		{"box", SDFBox(box, 0), SDFPolygon(boxVerts[:])},
+		{"triangle", SDFTriangle(Triangle{tri[0], tri[2], tri[1]}), SDFPolygon(tri[:])},
+		{"ring", SDFArc(Circle{Radius: 2}, 0, 2*math.Pi, 0.25), SDFShell(SDFCircle(Circle{Radius: 2}), 0.5)},
+		{"capsule", SDFSegment(Line{{X: -1}, {X: 1}}, 0.5), SDFUnion(SDFCircle(Circle{Center: Vec{X: -1}, Radius: 0.5}), SDFCircle(Circle{Center: Vec{X: 1}, Radius: 0.5}), SDFBox(Box{Min: Vec{X: -1, Y: -0.5}, Max: Vec{X: 1, Y: 0.5}}, 0))},
+		{"rounded box", SDFBox(box, 0.25), SDFOffset(SDFBox(Box{Min: Vec{X: -0.75, Y: -0.25}, Max: Vec{X: 1.75, Y: 0.75}}, 0), 0.25)},
+		{"transform", SDFTransform(SDFBox(box, 0.1), rot), transformedSDF{SDFBox(box, 0.1), rot}},
+	}
+	rng := rand.New(rand.NewSource(1))
+	for _, pair := range pairs {
+		bounds := pair.a.Bounds().Union(pair.b.Bounds())
+		for i := 0; i < 1000; i++ {
+			p := Vec{X: float64(rng.Float64()*8 - 4), Y: float64(rng.Float64()*8 - 4)}
+			da, db := pair.a.Evaluate(p), pair.b.Evaluate(p)
+			if pair.name == "capsule" && db < 0 {
+				continue // Union is not exact inside.
+			}
+			if math.Abs(da-db) > tol*(1+math.Abs(da)) {
+				t.Fatalf("%s: p=%v got %g and %g", pair.name, p, da, db)
+			}
+			if da < 0 && !bounds.Contains(p) {
+				t.Fatalf("%s: p=%v inside shape but outside bounds %v", pair.name, p, bounds)
+			}
+		}
+	}
+	// Arc: points on both sides and at the rounded ends.
+	arc := SDFArc(Circle{Radius: 1}, 0, math.Pi/2, 0.1)
+	cases := []struct {
+		p    Vec
+		want float64
+	}{
+		{Vec{X: 0, Y: 1}, -0.1},
+		{Vec{X: 0.5, Y: 0}, 0.4},
+		{Vec{X: 1, Y: -1}, 0.9},
+		{Vec{X: -1, Y: 0}, math.Sqrt(2) - 0.1},
+		{Vec{X: 2 * math.Sqrt(0.5), Y: 2 * math.Sqrt(0.5)}, 0.9},
+	}
+	for _, c := range cases {
+		if got := arc.Evaluate(c.p); math.Abs(got-c.want) > tol {
+			t.Errorf("arc at %v: got %g want %g", c.p, got, c.want)
+		}
+	}
+	scaled := SDFTransform(SDFCircle(Circle{Radius: 1}), Affine{Mat: Diagonal2(2, 2), Translation: Vec{X: 1}})
+	if got := scaled.Evaluate(Vec{X: 4}); math.Abs(got-1) > tol {
+		t.Errorf("uniformly scaled circle: got %g want 1", got)
+	}
+	// CSG.
+	a, b := SDFCircle(Circle{Radius: 1}), SDFCircle(Circle{Center: Vec{X: 1}, Radius: 1})
+	p := Vec{X: 0.5}
+	if d := SDFDifference(a, b).Evaluate(p); d <= 0 {
+		t.Errorf("difference should exclude %v, got %g", p, d)
+	}
+	if d := SDFIntersection(a, b).Evaluate(p); d >= 0 {
+		t.Errorf("intersection should include %v, got %g", p, d)
+	}
+	if su, u := SDFSmoothUnion(0.5, a, b).Evaluate(Vec{Y: 1}), SDFUnion(a, b).Evaluate(Vec{Y: 1}); su > u || su < u-0.5/4 {
+		t.Errorf("smooth union %g not within k/4 below union %g", su, u)
+	}
+	if si, i := SDFSmoothIntersection(0.5, a, b).Evaluate(Vec{Y: 1}), SDFIntersection(a, b).Evaluate(Vec{Y: 1}); si < i || si > i+0.5/4 {
+		t.Errorf("smooth intersection %g not within k/4 above intersection %g", si, i)
+	}
+}
+
+// transformedSDF is a reference implementation of a rigid SDF transformation.
+type transformedSDF struct {
+	s SDF
+	a Affine
+}
+
+func (ts transformedSDF) Evaluate(p Vec) float64 { return ts.s.Evaluate(ts.a.Inverse().Apply(p)) }
+func (ts transformedSDF) Bounds() Box            { return ts.a.ApplyBox(ts.s.Bounds()) }
+
+func TestMat2Decompositions(t *testing.T) {
+	const tol = 1e-4
+	rng := rand.New(rand.NewSource(1))
+	random := func() float64 { return float64(rng.Float64()*10 - 5) }
+	isRotation := func(m Mat2) bool {
+		return EqualMat2(MulMat2(m.Transpose(), m), IdentityMat2(), tol) && math.Abs(m.Determinant()-1) < tol
+	}
+	for i := 0; i < 1000; i++ {
+		a := NewMat2([]float64{random(), random(), random(), random()})
+		if i%10 == 0 {
+			a.x10 = 0 // Upper triangular.
+		} else if i%10 == 1 {
+			a = ScaleMat2(RotationMat2(random()), random()) // Conformal.
+		}
+		u, s, v := a.SVD()
+		if !isRotation(u) || !isRotation(v) || s.x01 != 0 || s.x10 != 0 || s.x00 < 0 || s.x00 < math.Abs(s.x11)-tol {
+			t.Fatalf("bad SVD factors of %v: %v %v %v", a, u, s, v)
+		}
+		if got := MulMat2(MulMat2(u, s), v.Transpose()); !EqualMat2(got, a, tol) {
+			t.Fatalf("SVD of %v reconstructs %v", a, got)
+		}
+
+		q, r := a.QRDecomposition()
+		if !isRotation(q) || r.x10 != 0 || !EqualMat2(MulMat2(q, r), a, tol) {
+			t.Fatalf("bad QR of %v: %v %v", a, q, r)
+		}
+
+		rot, stretch := a.PolarDecomposition()
+		if !isRotation(rot) || stretch.x01 != stretch.x10 || !EqualMat2(MulMat2(rot, stretch), a, tol) {
+			t.Fatalf("bad polar decomposition of %v: %v %v", a, rot, stretch)
+		}
+		if vals, _ := stretch.EigsSym(); a.Determinant() >= 0 && vals.Y < -tol {
+			t.Fatalf("stretch %v of %v not positive semi-definite", stretch, a)
+		}
+
+		sym := MulMat2(a.Transpose(), a)
+		vals, vecs := sym.EigsSym()
+		if !isRotation(vecs) || vals.X < vals.Y {
+			t.Fatalf("bad eigen decomposition of %v: %v %v", sym, vals, vecs)
+		}
+		if got := MulMat2(MulMat2(vecs, Diagonal2(vals.X, vals.Y)), vecs.Transpose()); !EqualMat2(got, sym, 10*tol) {
+			t.Fatalf("eigen decomposition of %v reconstructs %v", sym, got)
+		}
+		// Eigenvalues of AᵀA are the squared singular values.
+		if math.Abs(vals.X-s.x00*s.x00) > 10*tol || math.Abs(vals.Y-s.x11*s.x11) > 10*tol {
+			t.Fatalf("eigenvalues %v do not match singular values %v", vals, s)
+		}
+	}
+}
+
+func TestTriangleGeometry(t *testing.T) {
+	const tol = 1e-4
+	// Right triangle with legs 3 and 4.
+	tri := Triangle{{X: 0, Y: 0}, {X: 4, Y: 0}, {X: 0, Y: 3}}
+	if got := tri.SignedArea(); got != 6 {
+		t.Errorf("signed area: want 6, got %g", got)
+	}
+	c, r := tri.Circumcircle()
+	if !EqualElem(c, Vec{X: 2, Y: 1.5}, tol) || math.Abs(r-2.5) > tol {
+		t.Errorf("circumcircle: got %v %g", c, r)
+	}
+	c, r = tri.Incircle()
+	if !EqualElem(c, Vec{X: 1, Y: 1}, tol) || math.Abs(r-1) > tol {
+		t.Errorf("incircle: got %v %g", c, r)
+	}
+	if h := tri.Orthocenter(); !EqualElem(h, tri[0], tol) {
+		t.Errorf("orthocenter of right triangle should be right angle vertex, got %v", h)
+	}
+	if a := tri.Angles(); math.Abs(a[0]-math.Pi/2) > tol || math.Abs(a[0]+a[1]+a[2]-math.Pi) > tol {
+		t.Errorf("bad angles %v", a)
+	}
+
+	rng := rand.New(rand.NewSource(1))
+	randVec := func() Vec { return Vec{X: float64(rng.Float64()*10 - 5), Y: float64(rng.Float64()*10 - 5)} }
+	for i := 0; i < 200; i++ {
+		tri := Triangle{randVec(), randVec(), randVec()}
+		if tri.IsDegenerate(0.5) {
+			continue
+		}
+		p := randVec()
+		b := tri.Barycentric(p)
+		inside := b[0] >= 0 && b[1] >= 0 && b[2] >= 0
+		if !EqualElem(tri.FromBarycentric(b), p, tol) || math.Abs(b[0]+b[1]+b[2]-1) > tol {
+			t.Fatalf("barycentric %v of %v does not reconstruct point", b, p)
+		} else if inside != tri.Contains(p) && math.Min(b[0], math.Min(b[1], b[2])) < -tol {
+			t.Fatalf("barycentric %v containment disagrees with Contains", b)
+		}
+		if got := InterpolateBarycentric(b, [3]float64{tri[0].X, tri[1].X, tri[2].X}); math.Abs(got-p.X) > tol {
+			t.Fatalf("interpolated x coordinate %g, want %g", got, p.X)
+		}
+		c, r := tri.Circumcircle()
+		for _, v := range tri {
+			if math.Abs(Norm(Sub(v, c))-r) > tol*r {
+				t.Fatalf("vertex %v not on circumcircle %v %g", v, c, r)
+			}
+		}
+		h := tri.Orthocenter()
+		for k := range tri {
+			altitude := Sub(tri[k], h)
+			side := Sub(tri[(k+2)%3], tri[(k+1)%3])
+			if math.Abs(Dot(altitude, side)) > tol*Norm2(side)*(1+Norm(altitude)) {
+				t.Fatalf("orthocenter %v not on altitude from vertex %d", h, k)
+			}
+		}
+		c, r = tri.Incircle()
+		for _, side := range tri.Sides() {
+			if d := side.DistanceInfinite(c); math.Abs(d-r) > tol*(1+r) {
+				t.Fatalf("incircle not tangent to side: distance %g, radius %g", d, r)
+			}
+		}
+
+		// Overlap tests agree with a sampled overlap check.
+		other := Triangle{randVec(), randVec(), randVec()}
+		box := Box{Min: randVec()}
+		box.Max = Add(box.Min, Vec{X: float64(rng.Float64() * 4), Y: float64(rng.Float64() * 4)})
+		var sampledTri, sampledBox bool
+		const n = 40
+		for iy := 0; iy <= n; iy++ {
+			for ix := 0; ix <= n-iy; ix++ {
+				q := other.FromBarycentric([3]float64{float64(ix) / n, float64(iy) / n, float64(n-ix-iy) / n})
+				sampledTri = sampledTri || tri.Contains(q)
+				q = tri.FromBarycentric([3]float64{float64(ix) / n, float64(iy) / n, float64(n-ix-iy) / n})
+				sampledBox = sampledBox || box.Contains(q)
+			}
+		}
+		if sampledTri && !tri.OverlapsTriangle(other) {
+			t.Fatalf("triangles %v and %v overlap but OverlapsTriangle returned false", tri, other)
+		} else if tri.OverlapsTriangle(other) != other.OverlapsTriangle(tri) {
+			t.Fatal("OverlapsTriangle not symmetric")
+		}
+		if sampledBox && !tri.OverlapsBox(box) {
+			t.Fatalf("triangle %v and box %v overlap but OverlapsBox returned false", tri, box)
+		}
+	}
+	if tri.OverlapsTriangle(Triangle{{X: 3, Y: 3}, {X: 5, Y: 3}, {X: 3, Y: 5}}) {
+		t.Error("separated by hypotenuse triangles should not overlap")
+	}
+	if tri.OverlapsBox(Box{Min: Vec{X: 2.5, Y: 2}, Max: Vec{X: 4, Y: 3}}) || !tri.OverlapsBox(Box{Min: Vec{X: 1, Y: 1}, Max: Vec{X: 9, Y: 9}}) {
+		t.Error("bad triangle-box overlap")
+	}
+}
+
+func TestBoxQueries(t *testing.T) {
+	const tol = 1e-5
+	box := Box{Min: Vec{X: -1, Y: -2}, Max: Vec{X: 1, Y: 2}}
+	tmin, tmax, normal, hit := box.IntersectRay(Vec{X: 0.5, Y: 10}, Vec{Y: -2})
+	if !hit || tmin != 4 || tmax != 6 || normal != (Vec{Y: 1}) {
+		t.Errorf("ray along y: got %g %g %v %v", tmin, tmax, normal, hit)
+	}
+	if _, _, _, hit = box.IntersectRay(Vec{X: -5, Y: 3}, Vec{X: 1, Y: 0.1}); hit {
+		t.Error("ray passing above box should not hit")
+	}
+	c0, c1, ok := box.ClipSegment(Vec{X: -2, Y: -4}, Vec{X: 2, Y: 4})
+	if !ok || !EqualElem(c0, Vec{X: -1, Y: -2}, tol) || !EqualElem(c1, Vec{X: 1, Y: 2}, tol) {
+		t.Errorf("clip diagonal segment: got %v %v %v", c0, c1, ok)
+	}
+	if d := box.Distance(Vec{X: 4, Y: 6}); math.Abs(d-5) > tol {
+		t.Errorf("distance: want 5, got %g", d)
+	}
+	if d := box.DistanceBox(box.Add(Vec{X: -6})); math.Abs(d-4) > tol {
+		t.Errorf("box distance: want 4, got %g", d)
+	}
+	lo, hi := box.Split(1, 0)
+	if lo.Area() != 4 || hi.Area() != 4 || lo.Max.Y != 0 || hi.Min.Y != 0 {
+		t.Errorf("split: got %v %v", lo, hi)
+	}
+	verts := box.Vertices()
+	for i, quadrant := range box.Quadtree() {
+		if quadrant.Area() != 2 || !quadrant.Contains(verts[i]) {
+			t.Errorf("quadrant %d %v does not match vertex %v", i, quadrant, verts[i])
+		}
+	}
+}
+
+func TestPolyline(t *testing.T) {
+	const tol = 1e-5
+	// L shaped path of length 7.
+	pl := Polyline{{X: 0, Y: 0}, {X: 3, Y: 0}, {X: 3, Y: 4}}
+	if l := pl.Length(); l != 7 {
+		t.Fatalf("want length 7, got %g", l)
+	}
+	if s := pl.AppendArcLengths(nil); len(s) != 3 || s[1] != 3 || s[2] != 7 {
+		t.Errorf("bad arc lengths %v", s)
+	}
+	for _, test := range []struct {
+		s    float64
+		want Vec
+	}{
+		{s: -1, want: pl[0]}, {s: 1.5, want: Vec{X: 1.5}}, {s: 3, want: pl[1]}, {s: 5, want: Vec{X: 3, Y: 2}}, {s: 10, want: pl[2]},
+	} {
+		if got := pl.PointAt(test.s); !EqualElem(got, test.want, tol) {
+			t.Errorf("PointAt(%g): want %v, got %v", test.s, test.want, got)
+		}
+	}
+	resampled := pl.AppendResampled(nil, 8)
+	if len(resampled) != 8 || resampled[0] != pl[0] || resampled[7] != pl[2] {
+		t.Fatalf("bad resampling %v", resampled)
+	}
+	for i := 1; i < len(resampled); i++ {
+		// Points are 1 unit apart along the path; straight line distance is shorter only around the corner.
+		if d := Norm(Sub(resampled[i], resampled[i-1])); d > 1+tol || d < 0.7 {
+			t.Errorf("resampled points %d and %d %g apart", i-1, i, d)
+		}
+	}
+	if tg := pl.Tangent(0); tg != (Vec{X: 1}) {
+		t.Errorf("start tangent %v", tg)
+	}
+	if tg := pl.Tangent(1); !EqualElem(tg, Vec{X: math.Sqrt2 / 2, Y: math.Sqrt2 / 2}, tol) {
+		t.Errorf("corner tangent %v", tg)
+	}
+	if n := pl.Normal(2); !EqualElem(n, Vec{X: -1}, tol) {
+		t.Errorf("end normal should point left of travel, got %v", n)
+	}
+	closest, s, dist := pl.Project(Vec{X: 5, Y: 1})
+	if closest != (Vec{X: 3, Y: 1}) || s != 4 || dist != 2 {
+		t.Errorf("project: got %v %g %g", closest, s, dist)
+	}
+	sub := pl.AppendBetween(nil, 2, 5)
+	if len(sub) != 3 || !EqualElem(sub[0], Vec{X: 2}, tol) || sub[1] != pl[1] || !EqualElem(sub[2], Vec{X: 3, Y: 2}, tol) {
+		t.Errorf("sub polyline: got %v", sub)
+	}
+	if l := sub.Length(); math.Abs(l-3) > tol {
+		t.Errorf("sub polyline length %g, want 3", l)
+	}
+	rev := pl.AppendBetween(nil, 5, 2)
+	if len(rev) != 3 || rev[1] != pl[1] || !EqualElem(rev[0], sub[2], tol) {
+		t.Errorf("reversed sub polyline: got %v", rev)
+	}
+	rev = append(Polyline(nil), pl...)
+	rev.Reverse()
+	if rev[0] != pl[2] || rev[2] != pl[0] || rev.Length() != pl.Length() {
+		t.Errorf("bad reversal %v", rev)
+	}
+}
+
+func TestPredicates(t *testing.T) {
+	if Orient2D(Vec{0, 0}, Vec{1, 0}, Vec{0, 1}) != 1 || Orient2D(Vec{0, 0}, Vec{0, 1}, Vec{1, 0}) != -1 {
+		t.Error("bad Orient2D sign")
+	}
+	if Orient2D(Vec{1, 1}, Vec{2, 2}, Vec{3, 3}) != 0 {
+		t.Error("Orient2D of collinear points should be zero")
+	}
+	// Integer points on a circle of radius 5, counter-clockwise.
+	a, b, c := Vec{5, 0}, Vec{0, 5}, Vec{-5, 0}
+	for _, test := range []struct {
+		d    Vec
+		want int
+	}{
+		{d: Vec{3, 4}, want: 0},
+		{d: Vec{-4, -3}, want: 0},
+		{d: Vec{0, 0}, want: 1},
+		{d: Vec{4, 3.5}, want: -1},
+	} {
+		if got := InCircle(a, b, c, test.d); got != test.want {
+			t.Errorf("InCircle(%v)=%d, want %d", test.d, got, test.want)
+		}
+		if got := InCircle(c, b, a, test.d); got != -test.want {
+			t.Errorf("clockwise InCircle(%v)=%d, want %d", test.d, got, -test.want)
+		}
+	}
+
+	// Sweep a grid of neighbouring floats around a point that is nearly collinear with b and c,
+	// where naive evaluation gives inconsistent signs, and compare against rational arithmetic.
+	b, c = Vec{12, 12}, Vec{24, 24}
+	p0 := Vec{0.5, 0.5}
+	py := p0.Y
+	for i := 0; i < 32; i++ {
+		px := p0.X
+		for j := 0; j < 32; j++ {
+			p := Vec{px, py}
+			want := ratOrient2(p, b, c)
+			if got := Orient2D(p, b, c); got != want {
+				t.Fatalf("Orient2D(%v,%v,%v)=%d, want %d", p, b, c, got, want)
+			}
+			if got := Collinear(p, b, c, 0); got != (want == 0) {
+				t.Fatalf("Collinear(%v,%v,%v,0)=%v, want %v", p, b, c, got, want == 0)
+			}
+			if got := CopyOrientation(2, p, b, c); got != 2*float64(want) {
+				t.Fatalf("CopyOrientation(%v,%v,%v)=%v, want %d", p, b, c, got, 2*want)
+			}
+			px = math.Nextafter(px, 1)
+		}
+		py = math.Nextafter(py, 1)
+	}
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 1000; i++ {
+		// Nearly cocircular points: perturb a point on the circle by a few ulps.
+		angle := 2 * math.Pi * float64(rng.Float64())
+		d := Vec{X: 5 * math.Cos(angle), Y: 5 * math.Sin(angle)}
+		want := ratInCircle(a, b, Vec{-5, 0}, d)
+		if got := InCircle(a, b, Vec{-5, 0}, d); got != want {
+			t.Fatalf("InCircle(%v)=%d, want %d", d, got, want)
+		}
+	}
+
+	// Points on the shared edge of adjacent triangles are contained by both.
+	t1 := Triangle{{0, 0}, {1, 0}, {0, 1}}
+	t2 := Triangle{{1, 0}, {1, 1}, {0, 1}}
+	for _, p := range []Vec{{0.5, 0.5}, {0.25, 0.75}, {0.125, 0.875}} {
+		if !t1.Contains(p) || !t2.Contains(p) {
+			t.Errorf("point %v on shared edge should be contained by both triangles", p)
+		}
+	}
+	if t1.Contains(Vec{0.5, math.Nextafter(0.5, 1)}) {
+		t.Error("point just outside triangle edge should not be contained")
+	}
+}
+
+func ratVec(v Vec) (x, y *big.Rat) {
+	return new(big.Rat).SetFloat64(float64(v.X)), new(big.Rat).SetFloat64(float64(v.Y))
+}
+
+func ratOrient2(a, b, c Vec) int {
+	ax, ay := ratVec(a)
+	bx, by := ratVec(b)
+	cx, cy := ratVec(c)
+	acx, acy := new(big.Rat).Sub(ax, cx), new(big.Rat).Sub(ay, cy)
+	bcx, bcy := new(big.Rat).Sub(bx, cx), new(big.Rat).Sub(by, cy)
+	left := new(big.Rat).Mul(acx, bcy)
+	right := new(big.Rat).Mul(acy, bcx)
+	return left.Sub(left, right).Sign()
+}
+
+func ratInCircle(a, b, c, d Vec) int {
+	dx, dy := ratVec(d)
+	var rows [3][3]*big.Rat
+	for i, v := range [3]Vec{a, b, c} {
+		x, y := ratVec(v)
+		x.Sub(x, dx)
+		y.Sub(y, dy)
+		lift := new(big.Rat).Mul(x, x)
+		lift.Add(lift, new(big.Rat).Mul(y, y))
+		rows[i] = [3]*big.Rat{x, y, lift}
+	}
+	return ratDet3(rows).Sign()
+}
+
+func ratDet3(m [3][3]*big.Rat) *big.Rat {
+	minor := func(i, j, k, l int) *big.Rat {
+		p := new(big.Rat).Mul(m[i][k], m[j][l])
+		return p.Sub(p, new(big.Rat).Mul(m[i][l], m[j][k]))
+	}
+	det := new(big.Rat).Mul(m[0][0], minor(1, 2, 1, 2))
+	det.Sub(det, new(big.Rat).Mul(m[0][1], minor(1, 2, 0, 2)))
+	return det.Add(det, new(big.Rat).Mul(m[0][2], minor(1, 2, 0, 1)))
+}
@@ -5,7 +5,11 @@
 package md2
 
 import (
+	"encoding/json"
+	"image"
+	"math/rand"
 	"strconv"
+	"strings"
 	"testing"
 
 	math "math"
@@ -231,3 +235,663 @@ func TestArcUniformity(t *testing.T) {
 		}
 	}
 }
+
+func TestShape(t *testing.T) {
+	square := func(x0, y0, size float64, ccw bool) []Vec {
+		ring := []Vec{{X: x0, Y: y0}, {X: x0 + size, Y: y0}, {X: x0 + size, Y: y0 + size}, {X: x0, Y: y0 + size}}
+		if !ccw {
+			ring[1], ring[3] = ring[3], ring[1]
+		}
+		return ring
+	}
+	// Letter "B"-like shape: outer square, two holes and an island inside the first hole.
+	// All rings oriented counter-clockwise to exercise normalization.
+	shape := Shape{Rings: [][]Vec{
+		square(0, 0, 10, true),
+		square(1, 1, 3, true),
+		square(1, 6, 3, true),
+		square(2, 2, 1, true),
+	}}
+	parents := shape.AppendRingParents(nil)
+	wantParents := []int{-1, 0, 0, 1}
+	for i := range parents {
+		if parents[i] != wantParents[i] {
+			t.Fatalf("want parents %v, got %v", wantParents, parents)
+		}
+	}
+	inHole, inIsland, inOuter := Vec{X: 1.5, Y: 1.5}, Vec{X: 2.5, Y: 2.5}, Vec{X: 6, Y: 6}
+	if !shape.Contains(inHole, FillNonZero) || shape.Contains(inHole, FillEvenOdd) {
+		t.Error("same orientation rings: hole filled under nonzero rule, empty under even-odd")
+	}
+	if shape.Winding(inIsland) != 3 {
+		t.Errorf("want winding 3 in island, got %d", shape.Winding(inIsland))
+	}
+	shape.Normalize()
+	if got := shape.SignedArea(); got != 100-9-9+1 {
+		t.Errorf("normalized signed area: got %g", got)
+	}
+	for _, rule := range []FillRule{FillNonZero, FillEvenOdd} {
+		if !shape.Contains(inOuter, rule) || shape.Contains(inHole, rule) || !shape.Contains(inIsland, rule) || shape.Contains(Vec{X: 11}, rule) {
+			t.Errorf("normalized shape containment incorrect under %s rule", rule)
+		}
+	}
+	for i, ring := range shape.Rings {
+		if isHole := i == 1 || i == 2; isHole != (RingSignedArea(ring) < 0) {
+			t.Errorf("ring %d has wrong orientation", i)
+		}
+	}
+	if !shape.Bounds().Equal(Box{Max: Vec{X: 10, Y: 10}}, 0) {
+		t.Errorf("bad bounds %v", shape.Bounds())
+	}
+	// IsClockwise assumes the Y axis points down: rings with positive signed area, counter-clockwise
+	// with the Y axis up, are clockwise to it.
+	for _, ccw := range []bool{false, true} {
+		var pb PolygonBuilder
+		ring := square(0, 0, 1, ccw)
+		for _, v := range ring {
+			pb.Add(v)
+		}
+		if area := RingSignedArea(ring); pb.IsClockwise() != (area > 0) {
+			t.Errorf("IsClockwise=%v for ring of signed area %g, want IsClockwise true exactly for positive signed area", pb.IsClockwise(), area)
+		}
+	}
+}
+
+func TestRasterizer(t *testing.T) {
+	const tol = 1e-4
+	var r Rasterizer
+	// 1 world unit per pixel, world Y up means row 0 spans y in [3,4].
+	r.Reset(4, 4, Box{Max: Vec{X: 4, Y: 4}})
+	r.AddRing([]Vec{{X: 0.5, Y: 0.5}, {X: 2.5, Y: 0.5}, {X: 2.5, Y: 2.5}, {X: 0.5, Y: 2.5}})
+	want := [4][4]float64{
+		{0, 0, 0, 0},
+		{0.25, 0.5, 0.25, 0},
+		{0.5, 1, 0.5, 0},
+		{0.25, 0.5, 0.25, 0},
+	}
+	for y := range want {
+		for x, w := range want[y] {
+			if got := r.Coverage(x, y, FillNonZero); math.Abs(got-w) > tol {
+				t.Errorf("pixel (%d,%d): want coverage %g, got %g", x, y, w, got)
+			}
+		}
+	}
+	img := image.NewAlpha(r.Bounds())
+	r.DrawAlpha(img, FillNonZero)
+	if img.AlphaAt(1, 2).A != 255 || img.AlphaAt(1, 1).A != 128 || img.AlphaAt(3, 0).A != 0 {
+		t.Error("unexpected alpha image values")
+	}
+
+	// Total coverage equals area for arbitrary, partially clipped geometry.
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 50; i++ {
+		tri := Triangle{}
+		for k := range tri {
+			tri[k] = Vec{X: float64(rng.Float64()*12 - 2), Y: float64(rng.Float64()*12 - 2)}
+		}
+		world := Box{Max: Vec{X: 8, Y: 8}}
+		r.Reset(16, 16, world)
+		r.AddRing(tri[:])
+		var sum float64
+		for y := 0; y < 16; y++ {
+			for x := 0; x < 16; x++ {
+				sum += r.Coverage(x, y, FillEvenOdd)
+			}
+		}
+		// Clip triangle to world box to compute expected area in pixels.
+		shape := Shape{Rings: [][]Vec{tri[:]}}
+		var inside float64
+		const n = 200
+		for iy := 0; iy < n; iy++ {
+			for ix := 0; ix < n; ix++ {
+				p := Vec{X: 8 * (float64(ix) + 0.5) / n, Y: 8 * (float64(iy) + 0.5) / n}
+				if shape.Contains(p, FillNonZero) {
+					inside++
+				}
+			}
+		}
+		wantSum := inside * 256 / (n * n)
+		if math.Abs(sum-wantSum) > 0.02*256 {
+			t.Errorf("triangle %v: want total coverage %g, got %g", tri, wantSum, sum)
+		}
+	}
+
+	// Overlapping rings with the same orientation: filled under nonzero, empty under even-odd.
+	r.Reset(2, 2, Box{Max: Vec{X: 2, Y: 2}})
+	ring := []Vec{{X: 0, Y: 0}, {X: 2, Y: 0}, {X: 2, Y: 2}, {X: 0, Y: 2}}
+	r.AddShape(Shape{Rings: [][]Vec{ring, ring}})
+	gray := image.NewGray(image.Rect(0, 0, 2, 2))
+	r.DrawGray(gray, FillNonZero)
+	if gray.GrayAt(0, 0).Y != 255 {
+		t.Error("nonzero rule should fill doubly wound ring")
+	}
+	r.DrawGray(gray, FillEvenOdd)
+	if gray.GrayAt(1, 1).Y != 0 {
+		t.Error("even-odd rule should not fill doubly wound ring")
+	}
+}
+
+func TestPolygonBuilderSplineEdges(t *testing.T) {
+	var poly PolygonBuilder
+	poly.SplineTolerance = 1e-4
+	// Parabolic segment: the edge reaching the first vertex is a quadratic Bézier.
+	poly.AddXY(0, 0).BezierQuadratic(Vec{X: 1, Y: 2})
+	poly.AddXY(2, 0)
+	vecs, err := poly.AppendVecs(nil)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(vecs) < 8 {
+		t.Fatalf("expected quadratic edge to be discretized, got %d vertices", len(vecs))
+	}
+	// Area between chord and parabola is 2/3 of the enclosing triangle.
+	if area := RingSignedArea(vecs); math.Abs(math.Abs(area)-4./3) > 1e-3 {
+		t.Errorf("parabolic segment area: want 4/3, got %g", area)
+	}
+
+	// Cubic Bézier edge approximating a quarter circle of radius 1.
+	const k = 0.5522847
+	poly.Reset()
+	poly.SplineTolerance = 0
+	poly.AddXY(0, 0)
+	poly.AddXY(1, 0)
+	poly.AddXY(0, 1).BezierCubic(Vec{X: 1, Y: k}, Vec{X: k, Y: 1})
+	vecs, err = poly.AppendVecs(vecs[:0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range vecs[2:] {
+		if r := Norm(v); math.Abs(r-1) > 2*defaultSplineTol {
+			t.Errorf("quarter circle vertex %v at radius %g", v, r)
+		}
+	}
+	if vecs[len(vecs)-1] != (Vec{X: 0, Y: 1}) || len(vecs) < 5 {
+		t.Errorf("bad cubic edge discretization %v", vecs)
+	}
+
+	// Closed Catmull-Rom run through a square's corners passes through them and bulges outward.
+	poly.Reset()
+	corners := []Vec{{X: 1, Y: 1}, {X: -1, Y: 1}, {X: -1, Y: -1}, {X: 1, Y: -1}}
+	for _, c := range corners {
+		poly.Add(c).CatmullRom()
+	}
+	vecs, err = poly.AppendVecs(vecs[:0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range corners {
+		found := false
+		for _, v := range vecs {
+			found = found || v == c
+		}
+		if !found {
+			t.Errorf("Catmull-Rom curve does not pass through control point %v", c)
+		}
+	}
+	if area := RingSignedArea(vecs); area <= 4 {
+		t.Errorf("Catmull-Rom curve through square should enclose more than the square, got area %g", area)
+	}
+	// Arcs replace spline edges and vice versa.
+	cp := poly.Last()
+	cp.Arc(2, 4)
+	if cp.edge != edgeLine {
+		t.Error("arc did not replace spline edge")
+	}
+	cp.CatmullRom()
+	if cp.isArc() {
+		t.Error("spline edge did not replace arc")
+	}
+	// Smoothing and spline edges replace each other too.
+	cp.Chamfer(0.1)
+	if cp.edge != edgeLine || !cp.isSmoothed() {
+		t.Error("chamfer did not replace spline edge")
+	}
+	cp.BezierQuadratic(Vec{X: 1, Y: 1})
+	if cp.isSmoothed() || cp.edge != edgeBezierQuadratic {
+		t.Error("spline edge did not replace smoothing")
+	}
+}
+
+func TestPolygonBuilderSerialization(t *testing.T) {
+	const program = `# Rounded plate with a spline bottom.
+tol 0.001
+xy 0 0; smooth 1 6
+rel 10 0; chamfer 1
+rel 0 5; arc -6 8
+polar 3 2.5 # Comment after statement.
+rel -2 -1; cubic 0 5 1 2
+rel -1 -2; quad -3 0
+xy 1 -1; catmullrom
+`
+	var poly PolygonBuilder
+	err := poly.UnmarshalText([]byte(program))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := poly.AppendVecs(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	text, err := poly.MarshalText()
+	if err != nil {
+		t.Fatal(err)
+	}
+	jsonData, err := json.Marshal(poly)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(text), "; chamfer 1\n") || !strings.Contains(string(jsonData), `"chamfer":1`) {
+		t.Errorf("chamfer not preserved:\n%s\n%s", text, jsonData)
+	}
+	for _, decode := range []func(*PolygonBuilder) error{
+		func(p *PolygonBuilder) error { return p.UnmarshalText(text) },
+		func(p *PolygonBuilder) error { return json.Unmarshal(jsonData, p) },
+	} {
+		var decoded PolygonBuilder
+		decoded.AddXY(100, 100) // Decoding replaces existing control points.
+		if err := decode(&decoded); err != nil {
+			t.Fatal(err)
+		}
+		if decoded.SplineTolerance != poly.SplineTolerance || len(decoded.verts) != len(poly.verts) {
+			t.Fatalf("decoded builder mismatch:\n%s\n%s", text, jsonData)
+		}
+		for i := range poly.verts {
+			if decoded.verts[i] != poly.verts[i] {
+				t.Errorf("control point %d: want %+v, got %+v", i, poly.verts[i], decoded.verts[i])
+			}
+		}
+		got, err := decoded.AppendVecs(nil)
+		if err != nil || len(got) != len(want) {
+			t.Fatalf("decoded polygon discretizes to %d vertices, want %d: %v", len(got), len(want), err)
+		}
+	}
+
+	for _, test := range []struct {
+		text string
+		line string
+	}{
+		{text: "xy 0 0\nxy 1\n", line: "line 2:"},
+		{text: "smooth 1 2", line: "line 1:"},
+		{text: "xy 0 0\n\n# Comment.\nxy 1 1; frobnicate 2", line: "line 4:"},
+		{text: "xy 0 0; arc 1 3\nxy 1 1\nrel 1 0; smooth 1 a", line: "line 3:"},
+		{text: "xy 0 0; arc 1 3; smooth 1 2", line: "line 1:"},
+		{text: "xy 0 0\nxy 1 1; quad 2 0; smooth 1 2", line: "line 2:"},
+		{text: "xy 0 0\nxy 1 1; catmullrom; chamfer 1", line: "line 2:"},
+		{text: "xy 0 0\nxy 1 1; smooth 1 2; quad 2 0", line: "line 2:"},
+		{text: "xy 0 0\nxy 1 1; arc 1 3; cubic 0 1 1 0", line: "line 2:"},
+		{text: "xy 0 0\nxy 1 1; quad 2 0\ncubic 0 1 1 0", line: "line 3:"},
+		{text: "xy 0 0\nxy 1 1; catmullrom; arc 1 3", line: "line 2:"},
+	} {
+		err := poly.UnmarshalText([]byte(test.text))
+		if err == nil || !strings.Contains(err.Error(), test.line) {
+			t.Errorf("%q: expected error at %s got %v", test.text, test.line, err)
+		}
+	}
+	err = json.Unmarshal([]byte(`{"points":[{"x":0,"y":0},{"x":1,"y":0,"arc":{"radius":1,"facets":3},"catmull_rom":true}]}`), &poly)
+	if err == nil || !strings.Contains(err.Error(), "control_point[1]") {
+		t.Errorf("expected control point error, got %v", err)
+	}
+}
+
+func TestStraightSkeleton(t *testing.T) {
+	const tol = 1e-4
+	rect := Shape{Rings: [][]Vec{{{X: 0, Y: 0}, {X: 4, Y: 0}, {X: 4, Y: 2}, {X: 0, Y: 2}}}}
+	sk, err := NewStraightSkeleton(rect)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Rectangle roof: 4 eaves, 2 ridge ends and a ridge.
+	if len(sk.Nodes) != 6 || len(sk.Arcs) != 5 {
+		t.Fatalf("rectangle skeleton: want 6 nodes and 5 arcs, got %v", sk)
+	}
+	for _, nd := range sk.Nodes[4:] {
+		if nd.Time != 1 || nd.Pos.Y != 1 || (nd.Pos.X != 1 && nd.Pos.X != 3) {
+			t.Errorf("bad rectangle ridge node %+v", nd)
+		}
+	}
+
+	// Irregular shapes with reflex vertices and a hole have a generic skeleton:
+	// n+2h-2 interior nodes and 2n+3h-3 arcs for n vertices and h holes.
+	outer := []Vec{{X: 0, Y: 0}, {X: 10, Y: 0.5}, {X: 11, Y: 7}, {X: 6, Y: 4.3}, {X: 2.1, Y: 8}, {X: -0.5, Y: 5.2}}
+	hole := []Vec{{X: 3, Y: 2}, {X: 4.2, Y: 1.5}, {X: 5.1, Y: 3.1}, {X: 3.3, Y: 3.6}}
+	for _, test := range []struct {
+		shape Shape
+		holes int
+	}{
+		{shape: Shape{Rings: [][]Vec{outer}}},
+		{shape: Shape{Rings: [][]Vec{outer, hole}}, holes: 1},
+	} {
+		shape := test.shape
+		n := 0
+		for _, ring := range shape.Rings {
+			n += len(ring)
+		}
+		sk, err := NewStraightSkeleton(shape)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(sk.Nodes)-n != n+2*test.holes-2 || len(sk.Arcs) != 2*n+3*test.holes-3 {
+			t.Errorf("want %d interior nodes and %d arcs, got %d and %d", n+2*test.holes-2, 2*n+3*test.holes-3, len(sk.Nodes)-n, len(sk.Arcs))
+		}
+		degree := make([]int, len(sk.Nodes))
+		for _, arc := range sk.Arcs {
+			degree[arc[0]]++
+			degree[arc[1]]++
+			// Roof height increases monotonically away from boundary.
+			if sk.Nodes[arc[0]].Time == sk.Nodes[arc[1]].Time && sk.Nodes[arc[0]].Time == 0 {
+				t.Errorf("arc %v joins two boundary nodes", arc)
+			}
+		}
+		for i, nd := range sk.Nodes {
+			if i < n {
+				if nd.Time != 0 || degree[i] != 1 {
+					t.Errorf("boundary node %d: %+v with degree %d", i, nd, degree[i])
+				}
+				continue
+			}
+			if !shape.Contains(nd.Pos, FillEvenOdd) || nd.Time <= 0 || degree[i] != 3 {
+				t.Errorf("interior node %d: %+v with degree %d", i, nd, degree[i])
+			}
+			// Offset time is the distance to the supporting lines of at least 3 edges, and at most the boundary distance.
+			var nearLines int
+			minDist := float64(math.MaxFloat32)
+			for _, ring := range shape.Rings {
+				for k := range ring {
+					edge := Line{ring[k], ring[(k+1)%len(ring)]}
+					if math.Abs(edge.DistanceInfinite(nd.Pos)-nd.Time) < tol*10 {
+						nearLines++
+					}
+					closest, _ := edge.Closest(nd.Pos)
+					minDist = math.Min(minDist, Norm(Sub(closest, nd.Pos)))
+				}
+			}
+			if nearLines < 3 || nd.Time > minDist+tol {
+				t.Errorf("node %d %+v: %d supporting lines at offset distance, boundary distance %g", i, nd, nearLines, minDist)
+			}
+		}
+	}
+
+	// Simultaneous events at a point. Plus shaped cross: arm ends collapse at the same time the 4 reflex vertices
+	// meet at the center. T shape: the 2 reflex vertices meet on the opposing edge of the bar.
+	plus := []Vec{
+		{X: -1, Y: -3}, {X: 1, Y: -3}, {X: 1, Y: -1}, {X: 3, Y: -1}, {X: 3, Y: 1}, {X: 1, Y: 1},
+		{X: 1, Y: 3}, {X: -1, Y: 3}, {X: -1, Y: 1}, {X: -3, Y: 1}, {X: -3, Y: -1}, {X: -1, Y: -1},
+	}
+	rotated := make([]Vec, len(plus))
+	for i, v := range plus {
+		rotated[i] = MulMatVec(RotationMat2(0.3), v)
+	}
+	tee := []Vec{{X: 0, Y: 0}, {X: 2, Y: 0}, {X: 2, Y: 4}, {X: 4, Y: 4}, {X: 4, Y: 6}, {X: -2, Y: 6}, {X: -2, Y: 4}, {X: 0, Y: 4}}
+	for _, test := range []struct {
+		ring           []Vec
+		interior, arcs int
+	}{
+		{ring: plus, interior: 5, arcs: 16},
+		{ring: rotated, interior: 5, arcs: 16},
+		{ring: tee, interior: 4, arcs: 11},
+	} {
+		shape := Shape{Rings: [][]Vec{test.ring}}
+		sk, err := NewStraightSkeleton(shape)
+		if err != nil {
+			t.Fatal(err)
+		}
+		n := len(test.ring)
+		if len(sk.Nodes)-n != test.interior || len(sk.Arcs) != test.arcs {
+			t.Errorf("want %d interior nodes and %d arcs, got %d and %d", test.interior, test.arcs, len(sk.Nodes)-n, len(sk.Arcs))
+		}
+		for _, nd := range sk.Nodes[n:] {
+			if !shape.Contains(nd.Pos, FillEvenOdd) || math.Abs(nd.Time-1) > tol {
+				t.Errorf("bad interior node %+v", nd)
+			}
+		}
+	}
+
+	if _, err := NewStraightSkeleton(Shape{Rings: [][]Vec{{{}, {X: 1}}}}); err == nil {
+		t.Error("expected error for degenerate ring")
+	}
+}
+
+func TestMedialAxis(t *testing.T) {
+	rect := Shape{Rings: [][]Vec{{{X: 0, Y: 0}, {X: 4, Y: 0}, {X: 4, Y: 2}, {X: 0, Y: 2}}}}
+	const spacing = 0.05
+	ma, err := NewMedialAxis(rect, spacing)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ma.Nodes) == 0 || len(ma.Arcs) == 0 {
+		t.Fatal("empty medial axis")
+	}
+	var ridgeNodes int
+	for _, nd := range ma.Nodes {
+		boundaryDist := math.Min(math.Min(nd.Pos.X, 4-nd.Pos.X), math.Min(nd.Pos.Y, 2-nd.Pos.Y))
+		if !rect.Contains(nd.Pos, FillNonZero) || math.Abs(nd.Time-boundaryDist) > spacing {
+			t.Errorf("node %+v: radius does not match boundary distance %g", nd, boundaryDist)
+		}
+		if math.Abs(nd.Pos.Y-1) < spacing && nd.Pos.X > 1 && nd.Pos.X < 3 {
+			ridgeNodes++
+		}
+	}
+	if ridgeNodes < int(2/spacing)/2 {
+		t.Errorf("expected medial axis along rectangle's center line, found %d nodes", ridgeNodes)
+	}
+	for _, arc := range ma.Arcs {
+		if d := Norm(Sub(ma.Nodes[arc[0]].Pos, ma.Nodes[arc[1]].Pos)); d > 1 {
+			t.Errorf("spurious long arc %v of length %g", arc, d)
+		}
+	}
+	if _, err := NewMedialAxis(rect, 0); err == nil {
+		t.Error("expected error for zero spacing")
+	}
+}
+
+func TestAreaMoments(t *testing.T) {
+	const tol = 1e-3
+	const w, h = 6, 4
+	// Hollow rectangular section: outer w x h and centered hole 2 x 1, offset from the origin.
+	offset := Vec{X: 10, Y: -3}
+	var pb PolygonBuilder
+	pb.AddXY(0, 0)
+	pb.AddXY(w, 0)
+	pb.AddXY(w, h)
+	pb.AddXY(0, h)
+	outer, err := pb.AppendVecs(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hole := []Vec{{X: 2, Y: 1.5}, {X: 2, Y: 2.5}, {X: 4, Y: 2.5}, {X: 4, Y: 1.5}}
+	for i := range outer {
+		outer[i] = Add(outer[i], offset)
+	}
+	for i := range hole {
+		hole[i] = Add(hole[i], offset)
+	}
+	shape := Shape{Rings: [][]Vec{hole, outer}}
+	shape.Normalize()
+	m := shape.AreaMoments()
+	wantArea := float64(w*h - 2*1)
+	if math.Abs(m.Area-wantArea) > tol {
+		t.Errorf("area=%g, want %g", m.Area, wantArea)
+	}
+	wantCentroid := Add(offset, Vec{X: w / 2, Y: h / 2})
+	if !EqualElem(m.Centroid(), wantCentroid, tol) {
+		t.Errorf("centroid=%v, want %v", m.Centroid(), wantCentroid)
+	}
+	c := m.Centroidal()
+	wantIx := float64(w*h*h*h)/12 - float64(2*1*1*1)/12
+	wantIy := float64(h*w*w*w)/12 - float64(1*2*2*2)/12
+	if math.Abs(c.Ix-wantIx) > tol || math.Abs(c.Iy-wantIy) > tol || math.Abs(c.Ixy) > tol {
+		t.Errorf("centroidal Ix,Iy,Ixy=%g,%g,%g, want %g,%g,0", c.Ix, c.Iy, c.Ixy, wantIx, wantIy)
+	}
+	if got := c.Polar(); math.Abs(got-(wantIx+wantIy)) > tol {
+		t.Errorf("polar=%g, want %g", got, wantIx+wantIy)
+	}
+	// Moments about the origin follow the parallel axis theorem.
+	if want := wantIx + wantArea*wantCentroid.Y*wantCentroid.Y; math.Abs(m.Ix-want) > tol*math.Abs(want) {
+		t.Errorf("Ix=%g, want %g", m.Ix, want)
+	}
+	if want := wantArea * wantCentroid.X * wantCentroid.Y; math.Abs(m.Ixy-want) > tol*math.Abs(want) {
+		t.Errorf("Ixy=%g, want %g", m.Ixy, want)
+	}
+	// Clockwise rings negate the moments.
+	reversed := RingAreaMoments([]Vec{outer[3], outer[2], outer[1], outer[0]})
+	if math.Abs(reversed.Area+w*h) > tol {
+		t.Errorf("clockwise ring area=%g, want %g", reversed.Area, -float64(w*h))
+	}
+
+	// Principal axes of a rotated rectangle recover its rotation.
+	for _, angle := range []float64{0, 0.3, 1, -1.2} {
+		rot := RotationMat2(angle)
+		ring := make([]Vec, len(outer))
+		for i, v := range outer {
+			ring[i] = MulMatVec(rot, Sub(v, wantCentroid))
+		}
+		pm := RingAreaMoments(ring)
+		moments, axes := pm.PrincipalAxes()
+		// The largest moment is about the long side's perpendicular, the y axis of the unrotated rectangle.
+		wantMoments := Vec{X: float64(h*w*w*w) / 12, Y: float64(w*h*h*h) / 12}
+		if !EqualElem(moments, wantMoments, tol) {
+			t.Errorf("angle %g: principal moments=%v, want %v", angle, moments, wantMoments)
+		}
+		wantAxis := MulMatVec(rot, Vec{Y: 1})
+		if gotAxis := axes.VecCol(0); math.Abs(Cross(gotAxis, wantAxis)) > tol {
+			t.Errorf("angle %g: principal axis=%v, want %v", angle, gotAxis, wantAxis)
+		}
+		gotAngle := pm.PrincipalAngle()
+		if math.Abs(Cross(Vec{X: math.Cos(gotAngle), Y: math.Sin(gotAngle)}, wantAxis)) > tol {
+			t.Errorf("angle %g: principal angle=%g, want axis %v", angle, gotAngle, wantAxis)
+		}
+		rotated := MulMat2(MulMat2(axes.Transpose(), pm.InertiaTensor()), axes)
+		if !EqualMat2(rotated, Diagonal2(moments.X, moments.Y), tol) {
+			t.Errorf("angle %g: inertia tensor not diagonal in principal axes: %v", angle, rotated)
+		}
+	}
+}
+
+func TestInfill(t *testing.T) {
+	const spacing = 1
+	const tol = 1e-3
+	// 20x20 square with a centered 4x4 square hole.
+	shape := Shape{Rings: [][]Vec{
+		{{X: 0, Y: 0}, {X: 20, Y: 0}, {X: 20, Y: 20}, {X: 0, Y: 20}},
+		{{X: 8, Y: 8}, {X: 12, Y: 8}, {X: 12, Y: 12}, {X: 8, Y: 12}},
+	}}
+	shape.Normalize()
+	const area = 20*20 - 4*4
+	inf := DefaultInfill(spacing)
+	inside := func(name string, paths []Polyline) {
+		t.Helper()
+		if len(paths) == 0 {
+			t.Errorf("%s: no paths generated", name)
+		}
+		for _, pl := range paths {
+			for i := 0; i+1 < len(pl); i++ {
+				if !segmentInside(shape, inf.Rule, pl[i], pl[i+1]) {
+					t.Fatalf("%s: segment %v-%v not inside shape", name, pl[i], pl[i+1])
+				}
+			}
+		}
+	}
+	totalLength := func(paths []Polyline) (length float64) {
+		for _, pl := range paths {
+			length += pl.Length()
+		}
+		return length
+	}
+	travel := func(paths []Polyline) (dist float64) {
+		for i := 1; i < len(paths); i++ {
+			dist += Norm(Sub(paths[i][0], paths[i-1][len(paths[i-1])-1]))
+		}
+		return dist
+	}
+
+	hatch := inf.AppendHatch(nil, shape)
+	inside("hatch", hatch)
+	if got := totalLength(hatch); math.Abs(got-area/spacing) > tol {
+		t.Errorf("hatch length=%g, want %g", got, float64(area/spacing))
+	}
+	if len(hatch) != 20+4 {
+		t.Errorf("hatch got %d lines, want %d", len(hatch), 24)
+	}
+	unordered := inf.appendHatch(nil, shape, inf.Angle)
+	if got, unorderedTravel := travel(hatch), travel(unordered); got >= unorderedTravel {
+		t.Errorf("ordered travel %g not less than unordered travel %g", got, unorderedTravel)
+	}
+	// Appending continues from the end of the last path in dst.
+	more := inf.AppendHatch(hatch[:1:1], shape)
+	if d := Norm(Sub(more[1][0], hatch[0][len(hatch[0])-1])); d > 1.5*spacing {
+		t.Errorf("appended hatch starts %g away from previous path end", d)
+	}
+
+	inf.Angle = math.Pi / 6
+	rotated := inf.AppendHatch(nil, shape)
+	inside("rotated hatch", rotated)
+	if got := totalLength(rotated); math.Abs(got-area/spacing) > 0.05*area {
+		t.Errorf("rotated hatch length=%g, want about %g", got, float64(area/spacing))
+	}
+	for _, pl := range rotated {
+		if dir := Unit(Sub(pl[1], pl[0])); math.Abs(Cross(dir, Vec{X: math.Cos(inf.Angle), Y: math.Sin(inf.Angle)})) > tol {
+			t.Fatalf("rotated hatch line direction %v not at infill angle", dir)
+		}
+	}
+	inf.Angle = 0
+
+	cross := inf.AppendCrosshatch(nil, shape)
+	inside("crosshatch", cross)
+	if got := totalLength(cross); math.Abs(got-2*area/spacing) > tol {
+		t.Errorf("crosshatch length=%g, want %g", got, float64(2*area/spacing))
+	}
+
+	zigzag := inf.AppendZigzag(nil, shape)
+	inside("zigzag", zigzag)
+	if len(zigzag) > 4 {
+		t.Errorf("zigzag got %d polylines, want at most 4", len(zigzag))
+	}
+	if got := totalLength(zigzag); got < area/spacing {
+		t.Errorf("zigzag length=%g shorter than hatch length %g", got, float64(area/spacing))
+	}
+
+	concentric := inf.AppendConcentric(nil, shape)
+	inside("concentric", concentric)
+	for _, pl := range concentric {
+		if !isClosedPolyline(pl) {
+			t.Fatalf("concentric contour not closed: %v", pl)
+		}
+		for _, v := range pl {
+			// Distance to the boundary is an odd multiple of half the spacing.
+			d := -shapeDistance(shape, inf.Rule, v) / spacing
+			if math.Abs(d-math.Floor(d)-0.5) > inf.Resolution {
+				t.Fatalf("concentric vertex %v at distance %g from boundary", v, d*spacing)
+			}
+		}
+	}
+	if len(concentric) < 8 {
+		t.Errorf("got %d concentric contours, want at least 8", len(concentric))
+	}
+	// Distance field agrees with the brute force distance at every node.
+	const nx, ny = 49, 37
+	domain := shape.Bounds().expand(1.3)
+	field := appendDistanceField(nil, shape, inf.Rule, domain, nx, ny)
+	d := DivElem(domain.Size(), Vec{X: nx - 1, Y: ny - 1})
+	for k, got := range field {
+		p := Add(domain.Min, MulElem(d, Vec{X: float64(k % nx), Y: float64(k / nx)}))
+		if want := shapeDistance(shape, inf.Rule, p); math.Abs(got-want) > tol {
+			t.Fatalf("distance field at %v is %g, want %g", p, got, want)
+		}
+	}
+
+	honeycomb := inf.AppendHoneycomb(nil, shape)
+	inside("honeycomb", honeycomb)
+	side := spacing / math.Sqrt(3)
+	for _, pl := range honeycomb {
+		for i := 0; i+1 < len(pl); i++ {
+			if l := Norm(Sub(pl[i+1], pl[i])); l > side+tol {
+				t.Fatalf("honeycomb segment length %g longer than cell side %g", l, side)
+			}
+		}
+	}
+
+	gyroid := inf.AppendGyroid(nil, shape, 0.3)
+	inside("gyroid", gyroid)
+	if got := totalLength(gyroid); got < 0.5*area/spacing {
+		t.Errorf("gyroid length=%g, too short for area %g", got, float64(area))
+	}
+}
@@ -0,0 +1,247 @@
+// DO NOT EDIT.
+// This file was generated automatically
+// from gen.go. Please do not edit this file.
+
+package md2
+
+import (
+	"image"
+
+	math "math"
+)
+
+// Rasterizer renders closed rings such as polygons and [Shape]s into anti-aliased images with
+// exact area coverage: the value of each pixel is the fraction of its area covered by the filled region.
+//
+// Geometry is given in world coordinates and mapped to pixels with the world box passed to [Rasterizer.Reset].
+// The world box's minimum X maps to the left edge of the image and its maximum Y to the top edge, so the
+// Y axis of world coordinates points up. Geometry outside the world box is clipped.
+//
+// Coverage is accumulated with signed areas per pixel and the fill rule is applied to the accumulated winding.
+// Results are exact for edges that do not cross within a pixel.
+type Rasterizer struct {
+	acc    []float64
+	width  int
+	height int
+	world  Box
+	// scale converts world lengths to pixels in x and y.
+	scale Vec
+}
+
+// Reset clears the accumulated geometry and sets the image size in pixels and the region of world coordinates it covers.
+// Reset panics if the size is not positive or the world box is empty.
+func (r *Rasterizer) Reset(width, height int, world Box) {
+	if width <= 0 || height <= 0 {
+		panic("rasterizer size must be positive")
+	} else if world.Empty() {
+		panic("rasterizer world box must not be empty")
+	}
+	n := (width + 2) * height
+	if cap(r.acc) < n {
+		r.acc = make([]float64, n)
+	} else {
+		r.acc = r.acc[:n]
+		for i := range r.acc {
+			r.acc[i] = 0
+		}
+	}
+	r.width, r.height, r.world = width, height, world
+	size := world.Size()
+	r.scale = Vec{X: float64(width) / size.X, Y: float64(height) / size.Y}
+}
+
+// Bounds returns the rectangle of pixels rendered by the rasterizer, with origin at zero.
+func (r *Rasterizer) Bounds() image.Rectangle {
+	return image.Rect(0, 0, r.width, r.height)
+}
+
+// AddShape adds all rings of the shape to the rasterizer.
+func (r *Rasterizer) AddShape(s Shape) {
+	for _, ring := range s.Rings {
+		r.AddRing(ring)
+	}
+}
+
+// AddRing adds a closed ring of vertices in world coordinates to the rasterizer. The last vertex connects to the first.
+func (r *Rasterizer) AddRing(ring []Vec) {
+	if len(ring) < 2 {
+		return
+	}
+	prev := ring[len(ring)-1]
+	for _, v := range ring {
+		r.AddLine(prev, v)
+		prev = v
+	}
+}
+
+// AddLine adds a single directed edge in world coordinates to the rasterizer. Edges
+// must form closed loops once all geometry is added for the result to be meaningful.
+func (r *Rasterizer) AddLine(a, b Vec) {
+	p0, p1 := r.toPixel(a), r.toPixel(b)
+	// Split the edge where it crosses the left and right image edges.
+	// Portions left of the image accumulate at column 0 and portions right of the image do not affect any pixel.
+	w := float64(r.width)
+	var ts [4]float64
+	ts[0] = 0
+	n := 1
+	for _, x := range [2]float64{0, w} {
+		if (p0.X < x) != (p1.X < x) {
+			ts[n] = (x - p0.X) / (p1.X - p0.X)
+			n++
+		}
+	}
+	if n == 3 && ts[1] > ts[2] {
+		ts[1], ts[2] = ts[2], ts[1]
+	}
+	ts[n] = 1
+	n++
+	d := Sub(p1, p0)
+	for i := 0; i < n-1; i++ {
+		t0, t1 := ts[i], ts[i+1]
+		q0, q1 := Add(p0, Scale(t0, d)), Add(p0, Scale(t1, d))
+		if i == 0 {
+			q0 = p0
+		}
+		if i == n-2 {
+			q1 = p1
+		}
+		midX := (q0.X + q1.X) / 2
+		switch {
+		case midX >= w:
+			continue
+		case midX <= 0:
+			q0.X, q1.X = 0, 0
+		default:
+			q0.X = clampf(q0.X, 0, w)
+			q1.X = clampf(q1.X, 0, w)
+		}
+		r.accumulate(q0, q1)
+	}
+}
+
+// DrawAlpha writes the coverage of the accumulated geometry under the fill rule into dst.
+// Pixel (0,0) of the rasterizer is written at dst.Rect.Min and pixels outside dst are ignored.
+func (r *Rasterizer) DrawAlpha(dst *image.Alpha, rule FillRule) {
+	r.draw(dst.Pix, dst.Stride, dst.Rect, rule)
+}
+
+// DrawGray writes the coverage of the accumulated geometry under the fill rule into dst with
+// covered pixels white. Pixel (0,0) of the rasterizer is written at dst.Rect.Min and pixels outside dst are ignored.
+func (r *Rasterizer) DrawGray(dst *image.Gray, rule FillRule) {
+	r.draw(dst.Pix, dst.Stride, dst.Rect, rule)
+}
+
+func (r *Rasterizer) draw(pix []uint8, stride int, rect image.Rectangle, rule FillRule) {
+	width := min(r.width, rect.Dx())
+	height := min(r.height, rect.Dy())
+	for y := 0; y < height; y++ {
+		row := r.acc[y*(r.width+2):]
+		dst := pix[y*stride:]
+		var winding float64
+		for x := 0; x < width; x++ {
+			winding += row[x]
+			dst[x] = uint8(coverage(winding, rule)*255 + 0.5)
+		}
+	}
+}
+
+// Coverage returns the fraction of the area of pixel (x,y) covered by the accumulated geometry under the fill rule.
+func (r *Rasterizer) Coverage(x, y int, rule FillRule) float64 {
+	if x < 0 || y < 0 || x >= r.width || y >= r.height {
+		return 0
+	}
+	row := r.acc[y*(r.width+2):]
+	var winding float64
+	for i := 0; i <= x; i++ {
+		winding += row[i]
+	}
+	return coverage(winding, rule)
+}
+
+func (r *Rasterizer) toPixel(p Vec) Vec {
+	return Vec{
+		X: (p.X - r.world.Min.X) * r.scale.X,
+		Y: (r.world.Max.Y - p.Y) * r.scale.Y,
+	}
+}
+
+// accumulate adds the signed area contributions of an edge in pixel coordinates with
+// x within [0, width] to the accumulation buffer, clipping it to the image rows.
+func (r *Rasterizer) accumulate(p0, p1 Vec) {
+	if p0.Y == p1.Y {
+		return
+	}
+	dir := float64(1)
+	if p0.Y > p1.Y {
+		dir = -1
+		p0, p1 = p1, p0
+	}
+	dxdy := (p1.X - p0.X) / (p1.Y - p0.Y)
+	x := p0.X
+	y0 := 0
+	if p0.Y > 0 {
+		y0 = int(p0.Y)
+	} else {
+		x -= p0.Y * dxdy
+	}
+	yEnd := min(r.height, int(math.Ceil(p1.Y)))
+	stride := r.width + 2
+	w := float64(r.width)
+	x = clampf(x, 0, w) // Guard against rounding placing x outside the image.
+	for y := y0; y < yEnd; y++ {
+		row := r.acc[y*stride : (y+1)*stride]
+		dy := math.Min(float64(y+1), p1.Y) - math.Max(float64(y), p0.Y)
+		xnext := clampf(x+dxdy*dy, 0, w)
+		d := dy * dir
+		x0, x1 := x, xnext
+		if x0 > x1 {
+			x0, x1 = x1, x0
+		}
+		x0floor := math.Floor(x0)
+		x0i := int(x0floor)
+		x1ceil := math.Ceil(x1)
+		x1i := int(x1ceil)
+		if x1i <= x0i+1 {
+			// Edge within a single pixel column in this row.
+			xmf := 0.5*(x+xnext) - x0floor
+			row[x0i] += d - d*xmf
+			row[x0i+1] += d * xmf
+		} else {
+			s := 1 / (x1 - x0)
+			x0f := x0 - x0floor
+			a0 := 0.5 * s * (1 - x0f) * (1 - x0f)
+			x1f := x1 - x1ceil + 1
+			am := 0.5 * s * x1f * x1f
+			row[x0i] += d * a0
+			if x1i == x0i+2 {
+				row[x0i+1] += d * (1 - a0 - am)
+			} else {
+				a1 := s * (1.5 - x0f)
+				row[x0i+1] += d * (a1 - a0)
+				for xi := x0i + 2; xi < x1i-1; xi++ {
+					row[xi] += d * s
+				}
+				a2 := a1 + float64(x1i-x0i-3)*s
+				row[x1i-1] += d * (1 - a2 - am)
+			}
+			row[x1i] += d * am
+		}
+		x = xnext
+	}
+}
+
+// coverage applies the fill rule to an accumulated fractional winding number.
+func coverage(winding float64, rule FillRule) float64 {
+	w := math.Abs(winding)
+	if rule == FillEvenOdd {
+		w = math.Mod(w, 2)
+		if w > 1 {
+			w = 2 - w
+		}
+	}
+	return math.Min(w, 1)
+}
+
+func clampf(v, lo, hi float64) float64 {
+	return math.Max(lo, math.Min(v, hi))
+}
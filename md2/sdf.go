@@ -0,0 +1,404 @@
+// DO NOT EDIT.
+// This file was generated automatically
+// from gen.go. Please do not edit this file.
+
+package md2
+
+import (
+	math "math"
+)
+
+// SDF is a 2D signed distance function. Evaluate returns the distance from p to the shape's boundary,
+// negative inside the shape and positive outside. Bounds returns a box containing the shape, that is, the region where Evaluate is negative.
+//
+// Primitive SDFs return exact distances. Combinators such as [SDFUnion] return exact distances outside the shape
+// and a lower bound of the distance inside it, which is sufficient for sphere tracing and contouring with [AppendContourFunc].
+type SDF interface {
+	Evaluate(p Vec) float64
+	Bounds() Box
+}
+
+// SDFCircle returns the SDF of a circle.
+func SDFCircle(c Circle) SDF { return sdfCircle(c) }
+
+type sdfCircle Circle
+
+func (c sdfCircle) Evaluate(p Vec) float64 { return Norm(Sub(p, c.Center)) - c.Radius }
+func (c sdfCircle) Bounds() Box {
+	return NewCenteredBox(c.Center, Vec{X: 2 * c.Radius, Y: 2 * c.Radius})
+}
+
+// SDFBox returns the SDF of a box with corners rounded by radius. A zero radius yields the SDF of the box.
+// SDFBox panics if radius is negative or larger than half the box's smallest side.
+func SDFBox(b Box, radius float64) SDF {
+	b = b.Canon()
+	half := Scale(0.5, b.Size())
+	if radius < 0 || radius > half.Min() {
+		panic("invalid SDFBox radius")
+	}
+	return sdfBox{center: b.Center(), half: half, radius: radius}
+}
+
+type sdfBox struct {
+	center, half Vec
+	radius       float64
+}
+
+func (b sdfBox) Evaluate(p Vec) float64 {
+	q := Sub(AbsElem(Sub(p, b.center)), AddScalar(-b.radius, b.half))
+	return Norm(MaxElem(q, Vec{})) + math.Min(q.Max(), 0) - b.radius
+}
+
+func (b sdfBox) Bounds() Box { return NewCenteredBox(b.center, Scale(2, b.half)) }
+
+// SDFSegment returns the SDF of a line segment thickened by radius, also known as a capsule or stadium.
+// A zero radius yields the unsigned distance to the segment.
+func SDFSegment(l Line, radius float64) SDF {
+	if radius < 0 {
+		panic("negative SDFSegment radius")
+	}
+	return sdfSegment{l: l, radius: radius}
+}
+
+type sdfSegment struct {
+	l      Line
+	radius float64
+}
+
+func (s sdfSegment) Evaluate(p Vec) float64 { return segmentDistance(s.l[0], s.l[1], p) - s.radius }
+
+func (s sdfSegment) Bounds() Box {
+	return Box{Min: MinElem(s.l[0], s.l[1]), Max: MaxElem(s.l[0], s.l[1])}.expand(s.radius)
+}
+
+// SDFTriangle returns the SDF of a triangle of any orientation.
+func SDFTriangle(t Triangle) SDF { return sdfPolygon(t[:]) }
+
+// SDFPolygon returns the SDF of a simple polygon given by its vertices. The polygon is implicitly closed.
+// Self-intersecting polygons are filled with the even-odd rule. SDFPolygon panics if given less than 3 vertices.
+// The vertices are not copied.
+func SDFPolygon(vertices []Vec) SDF {
+	if len(vertices) < 3 {
+		panic("SDFPolygon needs at least 3 vertices")
+	}
+	return sdfPolygon(vertices)
+}
+
+type sdfPolygon []Vec
+
+func (poly sdfPolygon) Evaluate(p Vec) float64 {
+	d := math.Inf(1)
+	inside := false
+	j := len(poly) - 1
+	for i, vi := range poly {
+		vj := poly[j]
+		d = math.Min(d, segmentDistance(vi, vj, p))
+		// Even-odd crossing test of horizontal ray from p.
+		if (vi.Y > p.Y) != (vj.Y > p.Y) && p.X < vi.X+(p.Y-vi.Y)*(vj.X-vi.X)/(vj.Y-vi.Y) {
+			inside = !inside
+		}
+		j = i
+	}
+	if inside {
+		return -d
+	}
+	return d
+}
+
+func (poly sdfPolygon) Bounds() Box {
+	b := Box{Min: poly[0], Max: poly[0]}
+	for _, v := range poly[1:] {
+		b = b.IncludePoint(v)
+	}
+	return b
+}
+
+// SDFNagon returns the SDF of an n sided regular polygon centered at the origin with vertices at centerDistance from the center,
+// matching the polygon generated by [PolygonBuilder.Nagon]. SDFNagon panics if n<3.
+func SDFNagon(n int, centerDistance float64) SDF {
+	if n < 3 {
+		panic("SDFNagon needs at least 3 sides")
+	}
+	alpha := 2 * math.Pi / float64(n)
+	s, c := math.Sincos(alpha)
+	return sdfNagon{
+		alpha: alpha,
+		v0:    Vec{X: centerDistance},
+		v1:    Vec{X: centerDistance * c, Y: centerDistance * s},
+		r:     centerDistance,
+	}
+}
+
+type sdfNagon struct {
+	alpha  float64
+	v0, v1 Vec
+	r      float64
+}
+
+func (ng sdfNagon) Evaluate(p Vec) float64 {
+	// Fold p into the half sector between the first vertex and the first edge's midpoint.
+	theta := math.Mod(math.Atan2(p.Y, p.X), ng.alpha)
+	if theta < 0 {
+		theta += ng.alpha
+	}
+	if theta > ng.alpha/2 {
+		theta = ng.alpha - theta
+	}
+	s, c := math.Sincos(theta)
+	r := Norm(p)
+	q := Vec{X: r * c, Y: r * s}
+	d := segmentDistance(ng.v0, ng.v1, q)
+	if Cross(Sub(ng.v1, ng.v0), Sub(q, ng.v0)) > 0 {
+		return -d // Origin side of the edge.
+	}
+	return d
+}
+
+func (ng sdfNagon) Bounds() Box {
+	return Box{Min: Vec{X: -ng.r, Y: -ng.r}, Max: Vec{X: ng.r, Y: ng.r}}
+}
+
+// SDFArc returns the SDF of the arc of circle c spanning counter-clockwise from startAngle to endAngle
+// thickened by radius, with rounded ends. Angles are in radians. A span of 2π or more results in a ring.
+func SDFArc(c Circle, startAngle, endAngle, radius float64) SDF {
+	if radius < 0 {
+		panic("negative SDFArc radius")
+	}
+	span := endAngle - startAngle
+	if span < 2*math.Pi {
+		span = math.Mod(span, 2*math.Pi)
+		if span < 0 {
+			span += 2 * math.Pi
+		}
+	}
+	ss, cs := math.Sincos(startAngle)
+	se, ce := math.Sincos(startAngle + span)
+	return sdfArc{
+		c:      c,
+		start:  startAngle,
+		span:   span,
+		p0:     Add(c.Center, Vec{X: c.Radius * cs, Y: c.Radius * ss}),
+		p1:     Add(c.Center, Vec{X: c.Radius * ce, Y: c.Radius * se}),
+		radius: radius,
+	}
+}
+
+type sdfArc struct {
+	c      Circle
+	start  float64
+	span   float64
+	p0, p1 Vec
+	radius float64
+}
+
+func (a sdfArc) Evaluate(p Vec) float64 {
+	rel := Sub(p, a.c.Center)
+	phi := math.Mod(math.Atan2(rel.Y, rel.X)-a.start, 2*math.Pi)
+	if phi < 0 {
+		phi += 2 * math.Pi
+	}
+	if phi <= a.span {
+		return math.Abs(Norm(rel)-a.c.Radius) - a.radius
+	}
+	return math.Min(Norm(Sub(p, a.p0)), Norm(Sub(p, a.p1))) - a.radius
+}
+
+func (a sdfArc) Bounds() Box {
+	return sdfCircle(a.c).Bounds().expand(a.radius)
+}
+
+// SDFUnion returns the union of the argument SDFs. SDFUnion panics if no SDFs are passed.
+func SDFUnion(sdfs ...SDF) SDF {
+	if len(sdfs) == 0 {
+		panic("SDFUnion needs at least one SDF")
+	}
+	return sdfUnion(sdfs)
+}
+
+type sdfUnion []SDF
+
+func (u sdfUnion) Evaluate(p Vec) float64 {
+	d := u[0].Evaluate(p)
+	for _, s := range u[1:] {
+		d = math.Min(d, s.Evaluate(p))
+	}
+	return d
+}
+
+func (u sdfUnion) Bounds() Box {
+	b := u[0].Bounds()
+	for _, s := range u[1:] {
+		b = b.Union(s.Bounds())
+	}
+	return b
+}
+
+// SDFIntersection returns the intersection of the argument SDFs. SDFIntersection panics if no SDFs are passed.
+func SDFIntersection(sdfs ...SDF) SDF {
+	if len(sdfs) == 0 {
+		panic("SDFIntersection needs at least one SDF")
+	}
+	return sdfIntersection(sdfs)
+}
+
+type sdfIntersection []SDF
+
+func (in sdfIntersection) Evaluate(p Vec) float64 {
+	d := in[0].Evaluate(p)
+	for _, s := range in[1:] {
+		d = math.Max(d, s.Evaluate(p))
+	}
+	return d
+}
+
+func (in sdfIntersection) Bounds() Box {
+	b := in[0].Bounds()
+	for _, s := range in[1:] {
+		b = b.Intersect(s.Bounds())
+	}
+	return b
+}
+
+// SDFDifference returns the SDF of a with b subtracted from it.
+func SDFDifference(a, b SDF) SDF { return sdfDifference{a: a, b: b} }
+
+type sdfDifference struct{ a, b SDF }
+
+func (df sdfDifference) Evaluate(p Vec) float64 {
+	return math.Max(df.a.Evaluate(p), -df.b.Evaluate(p))
+}
+
+func (df sdfDifference) Bounds() Box { return df.a.Bounds() }
+
+// SDFSmoothUnion returns the union of a and b blended by a fillet of size k using a quadratic smooth minimum.
+// A k of zero yields [SDFUnion].
+func SDFSmoothUnion(k float64, a, b SDF) SDF {
+	if k < 0 {
+		panic("negative SDFSmoothUnion k")
+	}
+	return sdfSmooth{a: a, b: b, k: k}
+}
+
+// SDFSmoothIntersection returns the intersection of a and b blended by a fillet of size k using a quadratic smooth maximum.
+// A k of zero yields [SDFIntersection].
+func SDFSmoothIntersection(k float64, a, b SDF) SDF {
+	if k < 0 {
+		panic("negative SDFSmoothIntersection k")
+	}
+	return sdfSmooth{a: a, b: b, k: k, max: true}
+}
+
+type sdfSmooth struct {
+	a, b SDF
+	k    float64
+	max  bool
+}
+
+func (s sdfSmooth) Evaluate(p Vec) float64 {
+	da, db := s.a.Evaluate(p), s.b.Evaluate(p)
+	if s.max {
+		return -SmoothMin(s.k, -da, -db)
+	}
+	return SmoothMin(s.k, da, db)
+}
+
+func (s sdfSmooth) Bounds() Box {
+	if s.max {
+		return s.a.Bounds().Intersect(s.b.Bounds())
+	}
+	// Smooth minimum is at most k/4 below the minimum.
+	return s.a.Bounds().Union(s.b.Bounds()).expand(s.k / 4)
+}
+
+// SmoothMin returns the quadratic polynomial smooth minimum of a and b with smoothing width k:
+// the result equals math.Min(a,b) when a and b differ by more than k and is at most k/4 below it otherwise.
+func SmoothMin(k, a, b float64) float64 {
+	if k == 0 {
+		return math.Min(a, b)
+	}
+	h := math.Max(k-math.Abs(a-b), 0) / k
+	return math.Min(a, b) - h*h*k/4
+}
+
+// SDFOffset returns s grown outwards by distance d. A negative distance shrinks the shape.
+func SDFOffset(s SDF, d float64) SDF { return sdfOffset{s: s, d: d} }
+
+type sdfOffset struct {
+	s SDF
+	d float64
+}
+
+func (o sdfOffset) Evaluate(p Vec) float64 { return o.s.Evaluate(p) - o.d }
+func (o sdfOffset) Bounds() Box            { return o.s.Bounds().expand(math.Max(o.d, 0)) }
+
+// SDFShell returns a shell of the argument thickness centered on the boundary of s.
+func SDFShell(s SDF, thickness float64) SDF {
+	if thickness < 0 {
+		panic("negative SDFShell thickness")
+	}
+	return sdfShell{s: s, halfThickness: thickness / 2}
+}
+
+type sdfShell struct {
+	s             SDF
+	halfThickness float64
+}
+
+func (sh sdfShell) Evaluate(p Vec) float64 { return math.Abs(sh.s.Evaluate(p)) - sh.halfThickness }
+func (sh sdfShell) Bounds() Box            { return sh.s.Bounds().expand(sh.halfThickness) }
+
+// SDFTransform returns s transformed by the affine transformation a. The transformed SDF is exact for
+// rigid transformations and uniform scaling. Otherwise distances are scaled by the smallest singular value
+// of the transformation so that the result remains a lower bound of the true distance.
+// SDFTransform panics if a is singular.
+func SDFTransform(s SDF, a Affine) SDF {
+	det := a.Mat.Determinant()
+	if det == 0 {
+		panic("singular SDFTransform affine")
+	}
+	// Singular values of M are the square roots of the eigenvalues of MᵀM.
+	mtm := MulMat2(a.Mat.Transpose(), a.Mat)
+	tr := mtm.x00 + mtm.x11
+	disc := math.Sqrt(math.Max(0, tr*tr/4-mtm.Determinant()))
+	sigmaMin := math.Sqrt(math.Max(0, tr/2-disc))
+	return sdfTransform{s: s, a: a, inv: a.Inverse(), scale: sigmaMin}
+}
+
+type sdfTransform struct {
+	s     SDF
+	a     Affine
+	inv   Affine
+	scale float64
+}
+
+func (t sdfTransform) Evaluate(p Vec) float64 { return t.scale * t.s.Evaluate(t.inv.Apply(p)) }
+func (t sdfTransform) Bounds() Box            { return t.a.ApplyBox(t.s.Bounds()) }
+
+// segmentDistance returns the distance from p to the segment with ends a and b.
+func segmentDistance(a, b, p Vec) float64 {
+	e := Sub(b, a)
+	w := Sub(p, a)
+	e2 := Norm2(e)
+	if e2 == 0 {
+		return Norm(w)
+	}
+	t := math.Max(0, math.Min(1, Dot(w, e)/e2))
+	return Norm(Sub(w, Scale(t, e)))
+}
+
+// segmentDistance2 returns the squared distance from p to the segment a-b.
+func segmentDistance2(a, b, p Vec) float64 {
+	e := Sub(b, a)
+	w := Sub(p, a)
+	e2 := Norm2(e)
+	if e2 == 0 {
+		return Norm2(w)
+	}
+	t := math.Max(0, math.Min(1, Dot(w, e)/e2))
+	return Norm2(Sub(w, Scale(t, e)))
+}
+
+// expand returns the box grown by d in all directions.
+func (a Box) expand(d float64) Box {
+	return Box{Min: AddScalar(-d, a.Min), Max: AddScalar(d, a.Max)}
+}
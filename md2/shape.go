@@ -0,0 +1,165 @@
+// DO NOT EDIT.
+// This file was generated automatically
+// from gen.go. Please do not edit this file.
+
+package md2
+
+import (
+	math "math"
+)
+
+// FillRule determines which regions enclosed by the rings of a [Shape] are considered inside it.
+type FillRule uint8
+
+const (
+	// FillNonZero considers points with a non-zero winding number inside the shape.
+	FillNonZero FillRule = iota
+	// FillEvenOdd considers points inside the shape if a ray from the point crosses the rings an odd amount of times.
+	FillEvenOdd
+)
+
+// String returns the name of the fill rule.
+func (fr FillRule) String() string {
+	switch fr {
+	case FillNonZero:
+		return "nonzero"
+	case FillEvenOdd:
+		return "evenodd"
+	}
+	return "FillRule(invalid)"
+}
+
+// Shape is a planar region bounded by one or more closed rings, such as a polygon with holes or a glyph with counters.
+// Rings are implicitly closed: the last vertex connects to the first. Which regions are inside the shape
+// is decided by a [FillRule]. Orientations are given with the Y axis pointing up: counter-clockwise rings have positive signed area.
+//
+// A normalized shape, see [Shape.Normalize], has outer rings oriented counter-clockwise and holes clockwise,
+// in which case both fill rules agree and [Shape.SignedArea] is the area of the filled region.
+type Shape struct {
+	Rings [][]Vec
+}
+
+// RingSignedArea returns the signed area of a closed ring of vertices. It is positive for counter-clockwise rings
+// with the Y axis pointing up. [PolygonBuilder.IsClockwise] assumes the Y axis points down, as in screen
+// coordinates, so it reports rings with positive signed area as clockwise.
+func RingSignedArea(ring []Vec) float64 {
+	if len(ring) < 3 {
+		return 0
+	}
+	var sum float64
+	prev := ring[len(ring)-1]
+	for _, v := range ring {
+		sum += windingTerm(prev, v)
+		prev = v
+	}
+	return -sum / 2
+}
+
+// SignedArea returns the sum of the signed areas of the shape's rings.
+func (s Shape) SignedArea() float64 {
+	var area float64
+	for _, ring := range s.Rings {
+		area += RingSignedArea(ring)
+	}
+	return area
+}
+
+// Winding returns the winding number of the shape's rings around p: the amount of times the rings
+// wind counter-clockwise around p minus the amount of times they wind clockwise.
+func (s Shape) Winding(p Vec) int {
+	var wn int
+	for _, ring := range s.Rings {
+		wn += ringWinding(ring, p)
+	}
+	return wn
+}
+
+// Contains reports whether p is inside the shape according to the fill rule.
+func (s Shape) Contains(p Vec, rule FillRule) bool {
+	wn := s.Winding(p)
+	if rule == FillEvenOdd {
+		return wn%2 != 0
+	}
+	return wn != 0
+}
+
+// Bounds returns the bounding box of all ring vertices. It returns the zero Box for a shape with no vertices.
+func (s Shape) Bounds() Box {
+	var b Box
+	first := true
+	for _, ring := range s.Rings {
+		for _, v := range ring {
+			if first {
+				b = Box{Min: v, Max: v}
+				first = false
+			} else {
+				b = b.IncludePoint(v)
+			}
+		}
+	}
+	return b
+}
+
+// AppendRingParents discovers the nesting of the shape's rings and appends the index of each ring's parent to dst.
+// The parent of a ring is the smallest ring containing it, or -1 if no ring contains it.
+// Rings with no parent are outer rings, rings whose parent is an outer ring are holes, rings inside holes are
+// outer rings again and so on. Rings must not intersect each other.
+func (s Shape) AppendRingParents(dst []int) []int {
+	for i, ring := range s.Rings {
+		parent := -1
+		var parentArea float64
+		if len(ring) > 0 {
+			for j, other := range s.Rings {
+				if i == j || len(other) < 3 || ringWinding(other, ring[0]) == 0 {
+					continue
+				}
+				area := math.Abs(RingSignedArea(other))
+				if parent < 0 || area < parentArea {
+					parent, parentArea = j, area
+				}
+			}
+		}
+		dst = append(dst, parent)
+	}
+	return dst
+}
+
+// Normalize reverses rings in place so that outer rings are counter-clockwise and holes are clockwise
+// according to the nesting found by [Shape.AppendRingParents]. This makes the filled region of the shape
+// the same for both fill rules, given by the even-odd rule. Orientations are those of [RingSignedArea] with
+// the Y axis pointing up: outer rings of a normalized shape are reported clockwise by [PolygonBuilder.IsClockwise].
+func (s *Shape) Normalize() {
+	parents := s.AppendRingParents(nil)
+	for i, ring := range s.Rings {
+		depth := 0
+		for p := parents[i]; p >= 0 && depth <= len(parents); p = parents[p] {
+			depth++
+		}
+		isHole := depth%2 == 1
+		if isHole == (RingSignedArea(ring) > 0) {
+			for a, b := 0, len(ring)-1; a < b; a, b = a+1, b-1 {
+				ring[a], ring[b] = ring[b], ring[a]
+			}
+		}
+	}
+}
+
+// ringWinding returns the winding number of a closed ring around p.
+func ringWinding(ring []Vec, p Vec) int {
+	if len(ring) < 3 {
+		return 0
+	}
+	var wn int
+	a := ring[len(ring)-1]
+	for _, b := range ring {
+		if a.Y <= p.Y {
+			if b.Y > p.Y && Cross(Sub(b, a), Sub(p, a)) > 0 {
+				wn++ // Upward crossing with p left of edge.
+			}
+		} else if b.Y <= p.Y && Cross(Sub(b, a), Sub(p, a)) < 0 {
+			wn-- // Downward crossing with p right of edge.
+		}
+		a = b
+	}
+	return wn
+}
@@ -0,0 +1,133 @@
+// DO NOT EDIT.
+// This file was generated automatically
+// from gen.go. Please do not edit this file.
+
+package md2
+
+import (
+	math "math"
+)
+
+// SplineChain is a chain of uniform cubic spline segments defined by a single slice of control points.
+// It takes care of iterating over the control points with the [Spline3.Stride] of the spline kind:
+//   - Cubic Bézier: P0, CP0, CP1, P1, CP2, CP3, P2... Segments share end points.
+//   - Hermite: P0, V0, P1, V1, P2, V2... Segments share end point and velocity.
+//   - Catmull-Rom, Cardinal and B-Spline: P0, P1, P2, P3... Each point starts a new segment.
+//   - Quadratic Bézier: P0, CP0, P1, CP1, P2... Segments share end points.
+//
+// The chain is parametrized with a global parameter t in [0, NumSegments]: the integer part
+// of t selects the segment and the fractional part is the segment's local parameter.
+type SplineChain struct {
+	// Spline is the kind of spline the chain is composed of.
+	Spline Spline3
+	// Points are the control points of the chain interpreted according to the kind of spline.
+	Points []Vec
+	// Closed makes the chain periodic by wrapping control point indices around so that
+	// the chain's end joins its start. Closed chains with a stride greater than 1 do not repeat the first point at the end
+	// and should have a number of points multiple of the spline's stride.
+	Closed bool
+}
+
+// NumSegments returns the number of spline segments in the chain.
+func (sc SplineChain) NumSegments() int {
+	n := len(sc.Points)
+	stride := sc.Spline.Stride()
+	if sc.Closed {
+		return n / stride
+	}
+	used := sc.Spline.pointsUsed()
+	if n < used {
+		return 0
+	}
+	return (n-used)/stride + 1
+}
+
+// Segment returns the 4 points defining the ith segment of the chain, ready to be passed to [Spline3.Evaluate]
+// or [Spline3Sampler.SetSplinePoints]. Segment panics if i is out of range.
+func (sc SplineChain) Segment(i int) (v0, v1, v2, v3 Vec) {
+	if i < 0 || i >= sc.NumSegments() {
+		panic("spline chain segment out of range")
+	}
+	start := i * sc.Spline.Stride()
+	n := len(sc.Points)
+	if sc.Closed {
+		return sc.Points[start%n], sc.Points[(start+1)%n], sc.Points[(start+2)%n], sc.Points[(start+3)%n]
+	}
+	if sc.Spline.pointsUsed() == 3 {
+		// Fourth point has no effect. Avoid reading past the end of the chain.
+		return sc.Points[start], sc.Points[start+1], sc.Points[start+2], Vec{}
+	}
+	return sc.Points[start], sc.Points[start+1], sc.Points[start+2], sc.Points[start+3]
+}
+
+// Evaluate evaluates the chain at global parameter t in [0, NumSegments]. t is clamped to this range.
+// Evaluate panics if the chain has no segments.
+func (sc SplineChain) Evaluate(t float64) Vec {
+	i, tl := sc.local(t)
+	v0, v1, v2, v3 := sc.Segment(i)
+	return sc.Spline.Evaluate(tl, v0, v1, v2, v3)
+}
+
+// EvaluateDiff evaluates the first derivative of the chain with respect to the global parameter t.
+func (sc SplineChain) EvaluateDiff(t float64) Vec {
+	return sc.evalBasis(t, sc.Spline.BasisFuncDiff())
+}
+
+// EvaluateDiff2 evaluates the second derivative of the chain with respect to the global parameter t.
+func (sc SplineChain) EvaluateDiff2(t float64) Vec {
+	return sc.evalBasis(t, sc.Spline.BasisFuncDiff2())
+}
+
+// AppendSamples samples every segment of the chain with the bisection method of [Spline3Sampler.SampleBisect]
+// and appends the resulting points to dst. Points shared by consecutive segments are appended once.
+// Open chains include both chain extremes; closed chains do not repeat the starting point at the end.
+func (sc SplineChain) AppendSamples(dst []Vec, tolerance float64, maxDepth int) []Vec {
+	return appendChainSamples(dst, sc.Spline, sc.NumSegments(), sc.Closed, sc.Segment, tolerance, maxDepth)
+}
+
+// appendChainSamples samples nseg consecutive segments of spline given by segment with [Spline3Sampler.SampleBisect]
+// and appends the points to dst. Points shared by consecutive segments are appended once and
+// the starting point is not repeated at the end of closed chains.
+func appendChainSamples(dst []Vec, spline Spline3, nseg int, closed bool, segment func(i int) (v0, v1, v2, v3 Vec), tolerance float64, maxDepth int) []Vec {
+	if nseg == 0 {
+		return dst
+	}
+	sampler := Spline3Sampler{Spline: spline, Tolerance: tolerance}
+	for i := 0; i < nseg; i++ {
+		sampler.SetSplinePoints(segment(i))
+		if i == 0 {
+			dst = append(dst, sampler.Evaluate(0))
+		}
+		dst = sampler.SampleBisect(dst, maxDepth)
+		if !closed || i != nseg-1 {
+			dst = append(dst, sampler.Evaluate(1))
+		}
+	}
+	return dst
+}
+
+// local returns the segment index and local parameter of global parameter t.
+func (sc SplineChain) local(t float64) (int, float64) {
+	return chainLocal(sc.NumSegments(), t)
+}
+
+// chainLocal returns the segment index and local parameter of global parameter t
+// for a chain of nseg segments parametrized over [0, nseg].
+func chainLocal(nseg int, t float64) (int, float64) {
+	if nseg == 0 {
+		panic("spline chain has no segments")
+	}
+	t = math.Max(0, math.Min(t, float64(nseg)))
+	i := int(t)
+	if i == nseg {
+		i--
+	}
+	return i, t - float64(i)
+}
+
+func (sc SplineChain) evalBasis(t float64, basis func(float64) [4]float64) Vec {
+	i, tl := sc.local(t)
+	v0, v1, v2, v3 := sc.Segment(i)
+	b := basis(tl)
+	return Add(Add(Scale(b[0], v0), Scale(b[1], v1)), Add(Scale(b[2], v2), Scale(b[3], v3)))
+}
@@ -6,16 +6,17 @@ package md2
 
 // Spline3 implements uniform cubic spline logic (degree 3).
 // Keep in mind the iteration over the spline points and how the points are interpreted
-// depend on the type of spline being worked with.
+// depend on the type of spline being worked with. [Spline3.Stride] returns the amount of points
+// to advance between consecutive spline segments. [SplineChain] takes care of the iteration.
 //
 // Bézier example:
 //
-//	const Nsamples = 64 // Number of times to sample each set of two Bézier points.
+//	const Nsamples = 64 // Number of times to sample each Bézier segment.
 //	var spline []ms2.Vec = makeBezierSpline()
-//	bz := ms2.SplineBezier()
+//	bz := ms2.SplineBezierCubic()
 //	var curve []ms2.Vec
-//	for i := 0; i < len(spline); i += 4 {
-//		p0, cp0, cp1, p1 := spline[4*i], spline[4*i+1], spline[4*i+2], spline[4*i+3]
+//	for i := 0; i+3 < len(spline); i += bz.Stride() {
+//		p0, cp0, cp1, p1 := spline[i], spline[i+1], spline[i+2], spline[i+3]
 //		for t := float64(0.0); t<1; t+=1./Nsamples {
 //			xy := bz.Evaluate(t, p0, cp0, cp1, p1)
 //			curve = append(curve, xy)
@@ -23,10 +24,11 @@ package md2
 //	}
 //	plot(curve)
 type Spline3 struct {
-	m mat4
+	m      mat4
+	stride int
 }
 
-// NewSpline3 returns a [Spline3] ready for use.
+// NewSpline3 returns a [Spline3] ready for use. The returned spline has a [Spline3.Stride] of 1.
 // See [Freya Holmér's video] on splines for more information on how a matrix represents a uniform cubic spline.
 //
 // [Freya Holmér's video]: https://youtu.be/jvPPXbo87ds?si=Sn08aUjSKSXeRZ6D&t=419
@@ -34,7 +36,27 @@ func NewSpline3(matrix4x4 []float64) Spline3 {
 	if len(matrix4x4) < 16 {
 		panic("input matrix too short (need to be 4x4, row major)")
 	}
-	return Spline3{m: newMat4(matrix4x4)}
+	return Spline3{m: newMat4(matrix4x4), stride: 1}
+}
+
+// Stride returns the amount of points between the first points of two consecutive segments
+// of a chain of splines. For example, cubic Bézier segments share their end points so the stride is 3:
+//
+//	P0, CP0, CP1, P1, CP2, CP3, P2, ...
+func (s Spline3) Stride() int {
+	if s.stride <= 0 {
+		return 1
+	}
+	return s.stride
+}
+
+// pointsUsed returns the amount of points used to evaluate the spline.
+// Splines whose fourth point has no effect such as the quadratic Bézier return 3.
+func (s Spline3) pointsUsed() int {
+	if s.m.x03 == 0 && s.m.x13 == 0 && s.m.x23 == 0 && s.m.x33 == 0 {
+		return 3
+	}
+	return 4
 }
 
 // Mat4Array returns a row-major ordered copy of the values of the cubic spline 4x4 matrix.
@@ -155,7 +177,7 @@ var (
 //   - Uses in shapes and vector graphics.
 //
 // Iterate every 3 points. Point0, ControlPoint0, ControlPoint1, Point1.
-func SplineBezierCubic() Spline3 { return Spline3{m: _beziermat} }
+func SplineBezierCubic() Spline3 { return Spline3{m: _beziermat, stride: 3} }
 
 // SplineHermite returns a Hermite cubic spline interpreter. Result splines have the following characteristics:
 //   - C¹/C⁰ continuous.
@@ -164,24 +186,24 @@ func SplineBezierCubic() Spline3 { return Spline3{m: _beziermat} }
 //   - Uses in animation, physics simulations and interpolation.
 //
 // Iterate every 2 points, Point0, Velocity0, Point1, Velocity1.
-func SplineHermite() Spline3 { return Spline3{m: _hermiteMat} }
+func SplineHermite() Spline3 { return Spline3{m: _hermiteMat, stride: 2} }
 
 // SplineCatmullRom returns a Catmull-Rom cubic spline interpreter, a special case of Cardinal spline when scale=0.5. Result splines have the following characteristics:
 //   - C¹ continuous.
 //   - Interpolates all points.
 //   - Automatic tangents.
 //   - Used for animation and path smoothing.
-func SplineCatmullRom() Spline3 { return Spline3{m: _catmullromMat} }
+func SplineCatmullRom() Spline3 { return Spline3{m: _catmullromMat, stride: 1} }
 
 // SplineCardinal returns a cardinal cubic spline interpreter.
-func SplineCardinal(scale float64) Spline3 { return Spline3{m: _cardinalMat(scale)} }
+func SplineCardinal(scale float64) Spline3 { return Spline3{m: _cardinalMat(scale), stride: 1} }
 
 // SplineBasis returns a B-Spline interpreter. Result splines have the following characteristics:
 //   - C² continuous.
 //   - No point interpolation.
 //   - Automatic tangents.
 //   - Ideal for curvature-sensitive shapes and animations such as camera paths. Used in industrial design.
-func SplineBasis() Spline3 { return Spline3{m: _basisMat} }
+func SplineBasis() Spline3 { return Spline3{m: _basisMat, stride: 1} }
 
 // SplineBezierQuadratic returns a quadratic spline interpreter (fourth point is inneffective).
 //   - C¹ continuous.
@@ -190,7 +212,7 @@ func SplineBasis() Spline3 { return Spline3{m: _basisMat} }
 //   - Used in fonts. Cubic beziers are superior.
 //
 // Iterate every 2 points. Point0, ControlPoint, Point1. Keep in mind this is an innefficient implementation of a quadratic bezier. Is here for convenience.
-func SplineBezierQuadratic() Spline3 { return Spline3{m: _quadraticBezierMat} }
+func SplineBezierQuadratic() Spline3 { return Spline3{m: _quadraticBezierMat, stride: 2} }
 
 // Spline3Sampler implements algorithms for sampling points of a cubic spline [Spline3].
 type Spline3Sampler struct {
@@ -249,6 +271,13 @@ func (s *Spline3Sampler) SampleBisectWithExtremes(dst []Vec, maxDepth int) []Vec
 }
 
 func (s *Spline3Sampler) sampleBisect(dst []Vec, lvl, idx int, xstart Vec, tstart, baseRes float64) []Vec {
+	return appendBisect(dst, s.Evaluate, s.Tolerance, lvl, idx, xstart, tstart, baseRes)
+}
+
+// appendBisect implements the bisection sampling algorithm of [Spline3Sampler.SampleBisect]
+// for an arbitrary parametric curve f. t=tstart+baseRes*idx is the start of the section being
+// sampled and baseRes*2**lvl its length in parameter space.
+func appendBisect(dst []Vec, f func(t float64) Vec, tol float64, lvl, idx int, xstart Vec, tstart, baseRes float64) []Vec {
 	if lvl == 0 {
 		if idx != 0 {
 			dst = append(dst, xstart)
@@ -262,14 +291,14 @@ func (s *Spline3Sampler) sampleBisect(dst []Vec, lvl, idx int, xstart Vec, tstar
 
 	tend := baseRes * float64(endIdx)
 	tmid := baseRes * float64(midIdx)
-	xend := s.Evaluate(tend)
-	xmid := s.Evaluate(tmid)
-	if Collinear(xstart, xmid, xend, s.Tolerance) {
+	xend := f(tend)
+	xmid := f(tmid)
+	if Collinear(xstart, xmid, xend, tol) {
 		// Check offset- curve may be undersampled.
 		var k float64 = 0.45
 		tmid2 := tstart + k*(tend-tstart)
-		xmid2 := s.Evaluate(tmid2)
-		if Collinear(xstart, xmid2, xend, s.Tolerance) {
+		xmid2 := f(tmid2)
+		if Collinear(xstart, xmid2, xend, tol) {
 			if idx != 0 {
 				dst = append(dst, xstart)
 			}
@@ -277,8 +306,8 @@ func (s *Spline3Sampler) sampleBisect(dst []Vec, lvl, idx int, xstart Vec, tstar
 		}
 	}
 
-	dst = s.sampleBisect(dst, slvl, idx, xstart, tstart, baseRes)
-	dst = s.sampleBisect(dst, slvl, midIdx, xmid, tmid, baseRes)
+	dst = appendBisect(dst, f, tol, slvl, idx, xstart, tstart, baseRes)
+	dst = appendBisect(dst, f, tol, slvl, midIdx, xmid, tmid, baseRes)
 	return dst
 }
 
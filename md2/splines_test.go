@@ -0,0 +1,349 @@
+// DO NOT EDIT.
+// This file was generated automatically
+// from gen.go. Please do not edit this file.
+
+package md2
+
+import (
+	"testing"
+
+	math "math"
+)
+
+func TestBezierFitter(t *testing.T) {
+	const maxErr = 1e-2
+	bz := SplineBezierCubic()
+	// Sample an S shaped curve composed of two Bézier segments.
+	chain := []Vec{{0, 0}, {1, 2}, {3, 2}, {4, 0}, {5, -2}, {7, -2}, {8, 0}}
+	var samples []Vec
+	for i := 0; i+3 < len(chain); i += 3 {
+		for j := 0; j < 50; j++ {
+			samples = append(samples, bz.Evaluate(float64(j)/50, chain[i], chain[i+1], chain[i+2], chain[i+3]))
+		}
+	}
+	samples = append(samples, chain[len(chain)-1])
+
+	fitter := BezierFitter{MaxError: maxErr}
+	fit := fitter.AppendFit(nil, samples)
+	if len(fit)%3 != 1 {
+		t.Fatalf("expected 3n+1 points, got %d", len(fit))
+	}
+	if fit[0] != samples[0] || fit[len(fit)-1] != samples[len(samples)-1] {
+		t.Error("fit does not interpolate curve extremes")
+	}
+	nseg := len(fit) / 3
+	if nseg > 8 {
+		t.Errorf("too many segments for a smooth curve: %d", nseg)
+	}
+	checkFitError(t, fit, samples, maxErr)
+}
+
+func TestBezierFitterCorner(t *testing.T) {
+	const maxErr = 1e-3
+	// L shaped path with a sharp 90 degree corner at (1,0).
+	var samples []Vec
+	for i := 0; i <= 20; i++ {
+		samples = append(samples, Vec{X: float64(i) / 20})
+	}
+	for i := 1; i <= 20; i++ {
+		samples = append(samples, Vec{X: 1, Y: float64(i) / 20})
+	}
+	fitter := BezierFitter{MaxError: maxErr, CornerAngle: math.Pi / 4}
+	fit := fitter.AppendFit(nil, samples)
+	if len(fit) != 7 {
+		t.Fatalf("expected two segments split at corner, got %d points", len(fit))
+	}
+	if fit[3] != (Vec{X: 1}) {
+		t.Errorf("expected joint at corner, got %v", fit[3])
+	}
+	checkFitError(t, fit, samples, maxErr)
+}
+
+func checkFitError(t *testing.T, fit, samples []Vec, maxErr float64) {
+	t.Helper()
+	bz := SplineBezierCubic()
+	var dense []Vec
+	for i := 0; i+3 < len(fit); i += 3 {
+		for j := 0; j <= 400; j++ {
+			dense = append(dense, bz.Evaluate(float64(j)/400, fit[i], fit[i+1], fit[i+2], fit[i+3]))
+		}
+	}
+	for _, p := range samples {
+		minDist := float64(math.MaxFloat32)
+		for _, q := range dense {
+			minDist = math.Min(minDist, Norm(Sub(p, q)))
+		}
+		// Allow slack for the dense sampling resolution.
+		if minDist > 1.5*maxErr {
+			t.Errorf("sample %v is %g away from fitted curve, max error %g", p, minDist, maxErr)
+		}
+	}
+}
+
+func TestNURBSBezierEquivalence(t *testing.T) {
+	const tol = 1e-5
+	pts := []Vec{{0, 0}, {1, 2}, {3, 2}, {4, 0}}
+	nurbs, err := NewNURBS(3, pts, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bz := SplineBezierCubic()
+	for i := 0; i <= 10; i++ {
+		tp := float64(i) / 10
+		got := nurbs.Evaluate(tp)
+		want := bz.Evaluate(tp, pts[0], pts[1], pts[2], pts[3])
+		if !EqualElem(got, want, tol) {
+			t.Errorf("t=%g: got %v, want %v", tp, got, want)
+		}
+	}
+}
+
+func TestNURBSCircle(t *testing.T) {
+	const tol = 1e-5
+	// Full unit circle as a rational quadratic NURBS.
+	w := math.Sqrt2 / 2
+	pts := []Vec{{1, 0}, {1, 1}, {0, 1}, {-1, 1}, {-1, 0}, {-1, -1}, {0, -1}, {1, -1}, {1, 0}}
+	weights := []float64{1, w, 1, w, 1, w, 1, w, 1}
+	knots := []float64{0, 0, 0, 0.25, 0.25, 0.5, 0.5, 0.75, 0.75, 1, 1, 1}
+	circle, err := NewNURBS(2, pts, weights, knots)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkRadius := func(c NURBS) {
+		t.Helper()
+		for i := 0; i <= 100; i++ {
+			p := c.Evaluate(float64(i) / 100)
+			if r := Norm(p); math.Abs(r-1) > tol {
+				t.Fatalf("point %v at radius %g", p, r)
+			}
+		}
+	}
+	checkRadius(circle)
+
+	// Knot insertion must not change the shape.
+	err = circle.InsertKnot(0.1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(circle.ControlPoints()) != len(pts)+1 {
+		t.Fatal("expected one more control point after knot insertion")
+	}
+	checkRadius(circle)
+	err = circle.InsertKnot(0.25)
+	if err == nil {
+		t.Error("expected multiplicity error inserting knot over degree")
+	}
+
+	// Tangent is perpendicular to radius.
+	derivs := circle.AppendDerivs(nil, 0.3, 2)
+	if len(derivs) != 3 {
+		t.Fatalf("expected 3 derivatives, got %d", len(derivs))
+	}
+	if c := Cos(derivs[0], derivs[1]); math.Abs(c) > 1e-4 {
+		t.Errorf("tangent not perpendicular to radius: cos=%g", c)
+	}
+	// Chords between sampled points stay close to the circle.
+	const sampleTol = 1e-3
+	samples := circle.SampleBisect(nil, sampleTol, 6)
+	if samples[0] != circle.Evaluate(0) || samples[len(samples)-1] != circle.Evaluate(1) {
+		t.Error("sampling does not include extremes")
+	}
+	for i := 1; i < len(samples); i++ {
+		mid := Scale(0.5, Add(samples[i-1], samples[i]))
+		if r := Norm(mid); 1-r > 0.05 {
+			t.Errorf("sampled circle chord too far from curve: %g", 1-r)
+		}
+	}
+}
+
+func TestNURBSDerivs(t *testing.T) {
+	const h = 1e-3
+	pts := []Vec{{0, 0}, {1, 3}, {2, -1}, {4, 2}, {5, 0}, {7, 1}}
+	weights := []float64{1, 2, 0.5, 1, 3, 1}
+	c, err := NewNURBS(3, pts, weights, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tp := range []float64{0.1, 0.35, 0.5, 0.8} {
+		d := c.AppendDerivs(nil, tp, 1)
+		if !EqualElem(d[0], c.Evaluate(tp), 1e-5) {
+			t.Errorf("zeroth derivative %v != evaluated %v", d[0], c.Evaluate(tp))
+		}
+		fd := Scale(1/(2*h), Sub(c.Evaluate(tp+h), c.Evaluate(tp-h)))
+		if !EqualElem(d[1], fd, 1e-2*Norm(fd)) {
+			t.Errorf("t=%g: derivative %v, finite difference %v", tp, d[1], fd)
+		}
+	}
+}
+
+func TestNURBSBezierDecompose(t *testing.T) {
+	const tol = 1e-4
+	pts := []Vec{{0, 0}, {1, 3}, {2, -1}, {4, 2}, {5, 0}, {7, 1}, {8, 3}}
+	c, err := NewNURBS(3, pts, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bzc, err := c.BezierDecompose()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctl := bzc.ControlPoints()
+	knots := c.Knots()
+	nseg := len(pts) - 3 // Number of non-empty knot spans.
+	if len(ctl) != 3*nseg+1 {
+		t.Fatalf("expected %d Bézier control points, got %d", 3*nseg+1, len(ctl))
+	}
+	bz := SplineBezierCubic()
+	for i := 0; i < nseg; i++ {
+		t0, t1 := knots[3+i], knots[4+i]
+		for j := 0; j <= 8; j++ {
+			u := float64(j) / 8
+			got := bz.Evaluate(u, ctl[3*i], ctl[3*i+1], ctl[3*i+2], ctl[3*i+3])
+			want := c.Evaluate(t0 + u*(t1-t0))
+			if !EqualElem(got, want, tol) {
+				t.Errorf("segment %d u=%g: got %v, want %v", i, u, got, want)
+			}
+		}
+	}
+}
+
+func TestSplineChain(t *testing.T) {
+	const tol = 1e-5
+	pts := []Vec{{0, 0}, {1, 2}, {3, 2}, {4, 0}, {5, -2}, {7, -2}, {8, 0}}
+	cases := []struct {
+		spline  Spline3
+		closed  bool
+		npts    int
+		wantSeg int
+	}{
+		{spline: SplineBezierCubic(), wantSeg: 2},
+		{spline: SplineBezierQuadratic(), wantSeg: 3},
+		{spline: SplineHermite(), wantSeg: 2},
+		{spline: SplineCatmullRom(), wantSeg: 4},
+		{spline: SplineBasis(), wantSeg: 4},
+		{spline: SplineCatmullRom(), closed: true, wantSeg: 7},
+		{spline: SplineBasis(), closed: true, wantSeg: 7},
+		{spline: SplineBezierQuadratic(), closed: true, npts: 6, wantSeg: 3},
+		{spline: SplineBezierCubic(), closed: true, npts: 6, wantSeg: 2},
+	}
+	for _, tc := range cases {
+		chainPts := pts
+		if tc.npts > 0 {
+			chainPts = pts[:tc.npts]
+		}
+		chain := SplineChain{Spline: tc.spline, Points: chainPts, Closed: tc.closed}
+		nseg := chain.NumSegments()
+		if nseg != tc.wantSeg {
+			t.Errorf("stride=%d closed=%v: want %d segments, got %d", tc.spline.Stride(), tc.closed, tc.wantSeg, nseg)
+			continue
+		}
+		// C⁰ continuity between segments.
+		for i := 1; i < nseg; i++ {
+			const eps = 1e-4
+			before := chain.Evaluate(float64(i) - eps)
+			after := chain.Evaluate(float64(i) + eps)
+			if !EqualElem(before, after, 1e-2) {
+				t.Errorf("stride=%d: discontinuity at segment %d: %v != %v", tc.spline.Stride(), i, before, after)
+			}
+		}
+		if tc.closed {
+			if !EqualElem(chain.Evaluate(0), chain.Evaluate(float64(nseg)), tol) {
+				t.Errorf("closed chain does not join start: %v != %v", chain.Evaluate(0), chain.Evaluate(float64(nseg)))
+			}
+		}
+		samples := chain.AppendSamples(nil, 1e-3, 6)
+		if samples[0] != chain.Evaluate(0) {
+			t.Error("samples do not start at chain start")
+		}
+		if !tc.closed && !EqualElem(samples[len(samples)-1], chain.Evaluate(float64(nseg)), tol) {
+			t.Error("samples do not end at chain end")
+		}
+	}
+
+	// Catmull-Rom interpolates interior points at integer parameters.
+	cr := SplineChain{Spline: SplineCatmullRom(), Points: pts}
+	for i := 0; i <= cr.NumSegments(); i++ {
+		if got := cr.Evaluate(float64(i)); !EqualElem(got, pts[i+1], tol) {
+			t.Errorf("Catmull-Rom at t=%d: got %v, want %v", i, got, pts[i+1])
+		}
+	}
+	// Derivative matches finite differences.
+	const h = 1e-2
+	for _, tp := range []float64{0.3, 1.5, 2.7} {
+		fd := Scale(1/(2*h), Sub(cr.Evaluate(tp+h), cr.Evaluate(tp-h)))
+		if d := cr.EvaluateDiff(tp); !EqualElem(d, fd, 1e-2) {
+			t.Errorf("t=%g: derivative %v, finite difference %v", tp, d, fd)
+		}
+	}
+}
+
+func TestCatmullRomChain(t *testing.T) {
+	const tol = 1e-4
+	pts := []Vec{{0, 0}, {1, 2}, {1.1, 2}, {4, 0}, {5, -2}, {9, -2}, {9, 0}}
+	uniform := CatmullRomChain{Points: pts, Alpha: CatmullRomUniform}
+	ref := SplineChain{Spline: SplineCatmullRom(), Points: pts}
+	if uniform.NumSegments() != ref.NumSegments() {
+		t.Fatalf("segment count mismatch %d != %d", uniform.NumSegments(), ref.NumSegments())
+	}
+	for i := 0; i <= 40; i++ {
+		tp := float64(i) / 10
+		if got, want := uniform.Evaluate(tp), ref.Evaluate(tp); !EqualElem(got, want, tol) {
+			t.Errorf("uniform t=%g: got %v, want %v", tp, got, want)
+		}
+	}
+	for _, alpha := range []float64{CatmullRomCentripetal, CatmullRomChordal} {
+		for _, closed := range []bool{false, true} {
+			cr := CatmullRomChain{Points: pts, Alpha: alpha, Closed: closed}
+			off := 1
+			if closed {
+				off = 0
+			}
+			for i := 0; i < cr.NumSegments(); i++ {
+				if got := cr.Evaluate(float64(i)); !EqualElem(got, pts[i+off], tol) {
+					t.Errorf("alpha=%g closed=%v: t=%d got %v, want %v", alpha, closed, i, got, pts[i+off])
+				}
+			}
+			samples := cr.AppendSamples(nil, 1e-3, 6)
+			if len(samples) < len(pts)-2 {
+				t.Errorf("too few samples: %d", len(samples))
+			}
+		}
+	}
+}
+
+func TestKochanekBartelsChain(t *testing.T) {
+	const tol = 1e-4
+	pts := []Vec{{0, 0}, {1, 2}, {3, 2}, {4, 0}, {5, -2}}
+	keys := make([]KochanekBartelsKey, len(pts))
+	for i := range pts {
+		keys[i].Point = pts[i]
+	}
+	kb := KochanekBartelsChain{Keys: keys}
+	if kb.NumSegments() != len(pts)-1 {
+		t.Fatalf("want %d segments, got %d", len(pts)-1, kb.NumSegments())
+	}
+	// Interpolates all keys.
+	for i := range pts {
+		if got := kb.Evaluate(float64(i)); !EqualElem(got, pts[i], tol) {
+			t.Errorf("t=%d: got %v, want %v", i, got, pts[i])
+		}
+	}
+	// Zero TCB parameters result in a uniform Catmull-Rom spline in the interior.
+	cr := SplineChain{Spline: SplineCatmullRom(), Points: pts}
+	for i := 0; i <= 20; i++ {
+		tp := float64(i) / 10
+		if got, want := kb.Evaluate(1+tp), cr.Evaluate(tp); !EqualElem(got, want, tol) {
+			t.Errorf("t=%g: got %v, want Catmull-Rom %v", 1+tp, got, want)
+		}
+	}
+	// Full tension produces zero velocity at keys.
+	for i := range keys {
+		keys[i].Tension = 1
+	}
+	for i := 0; i < kb.NumSegments(); i++ {
+		_, v0, _, v1 := kb.Segment(i)
+		if Norm(v0) != 0 || Norm(v1) != 0 {
+			t.Errorf("segment %d: nonzero tangents %v %v with full tension", i, v0, v1)
+		}
+	}
+}
@@ -116,19 +116,15 @@ func (t Triangle) edges() (Vec, Vec, Vec) {
 	return Sub(t[1], t[0]), Sub(t[2], t[1]), Sub(t[0], t[2])
 }
 
-// Contains returns true if point is contained within the triangle's surface.
+// Contains returns true if point is contained within the triangle's surface, including its edges.
+// The test uses the exact [Orient2D] predicate so points on shared edges of adjacent triangles are handled consistently.
 func (t Triangle) Contains(point Vec) bool {
-	d1 := d2Sign(point, t[0], t[1])
-	d2 := d2Sign(point, t[1], t[2])
-	d3 := d2Sign(point, t[2], t[0])
-	has_neg := (d1 < 0) || (d2 < 0) || (d3 < 0)
-	has_pos := (d1 > 0) || (d2 > 0) || (d3 > 0)
-	return !(has_neg && has_pos)
-}
-
-func d2Sign(p1, p2, p3 Vec) float64 {
-	// TODO: replace with CopyOrientation?
-	return (p1.X-p3.X)*(p2.Y-p3.Y) - (p2.X-p3.X)*(p1.Y-p3.Y)
+	d1 := Orient2D(point, t[0], t[1])
+	d2 := Orient2D(point, t[1], t[2])
+	d3 := Orient2D(point, t[2], t[0])
+	hasNeg := (d1 < 0) || (d2 < 0) || (d3 < 0)
+	hasPos := (d1 > 0) || (d2 > 0) || (d3 > 0)
+	return !(hasNeg && hasPos)
 }
 
 // Closest returns the point on the triangle closest to the argument point p.
@@ -161,3 +157,125 @@ func (t Triangle) Closest(p Vec) (closest Vec, side int8, vertex int8) {
 	}
 	return closest, side, vertex
 }
+
+// SignedArea returns the signed area of the triangle. It is positive if the vertices are in counter-clockwise order.
+func (t Triangle) SignedArea() float64 {
+	return Cross(Sub(t[1], t[0]), Sub(t[2], t[0])) / 2
+}
+
+// Barycentric returns the barycentric coordinates of p with respect to the triangle's vertices.
+// The coordinates sum to 1 and are all non-negative if p lies within the triangle.
+// The result is not finite for degenerate triangles.
+func (t Triangle) Barycentric(p Vec) [3]float64 {
+	e0, e1 := Sub(t[1], t[0]), Sub(t[2], t[0])
+	d := Sub(p, t[0])
+	inv := 1 / Cross(e0, e1)
+	b1 := Cross(d, e1) * inv
+	b2 := Cross(e0, d) * inv
+	return [3]float64{1 - b1 - b2, b1, b2}
+}
+
+// FromBarycentric returns the point with barycentric coordinates b with respect to the triangle's vertices.
+func (t Triangle) FromBarycentric(b [3]float64) Vec {
+	return Add(Add(Scale(b[0], t[0]), Scale(b[1], t[1])), Scale(b[2], t[2]))
+}
+
+// InterpolateBarycentric linearly interpolates per-vertex attributes using barycentric coordinates b,
+// as returned by [Triangle.Barycentric].
+func InterpolateBarycentric(b, attrs [3]float64) float64 {
+	return b[0]*attrs[0] + b[1]*attrs[1] + b[2]*attrs[2]
+}
+
+// Circumcircle returns the center and radius of the circle passing through the triangle's three vertices.
+// The result is not finite for degenerate triangles.
+func (t Triangle) Circumcircle() (center Vec, radius float64) {
+	b, c := Sub(t[1], t[0]), Sub(t[2], t[0])
+	b2, c2 := Norm2(b), Norm2(c)
+	inv := 1 / (2 * Cross(b, c))
+	u := Vec{
+		X: (c.Y*b2 - b.Y*c2) * inv,
+		Y: (b.X*c2 - c.X*b2) * inv,
+	}
+	return Add(t[0], u), Norm(u)
+}
+
+// Incircle returns the center and radius of the largest circle contained in the triangle,
+// which is tangent to all three sides.
+func (t Triangle) Incircle() (center Vec, radius float64) {
+	// Side lengths opposite to each vertex weigh the vertices.
+	l0, l1, l2 := Norm(Sub(t[2], t[1])), Norm(Sub(t[0], t[2])), Norm(Sub(t[1], t[0]))
+	perimeter := l0 + l1 + l2
+	if perimeter == 0 {
+		return t[0], 0
+	}
+	center = Scale(1/perimeter, Add(Add(Scale(l0, t[0]), Scale(l1, t[1])), Scale(l2, t[2])))
+	return center, 2 * math.Abs(t.SignedArea()) / perimeter
+}
+
+// Orthocenter returns the intersection of the triangle's three altitudes.
+// The result is not finite for degenerate triangles.
+func (t Triangle) Orthocenter() Vec {
+	// Euler line relation: H = A + B + C - 2*O where O is the circumcenter.
+	circumcenter, _ := t.Circumcircle()
+	return Sub(Add(Add(t[0], t[1]), t[2]), Scale(2, circumcenter))
+}
+
+// Angles returns the interior angles in radians at each of the triangle's vertices. They sum to π.
+func (t Triangle) Angles() [3]float64 {
+	var angles [3]float64
+	for i := range t {
+		e1, e2 := Sub(t[(i+1)%3], t[i]), Sub(t[(i+2)%3], t[i])
+		angles[i] = math.Atan2(math.Abs(Cross(e1, e2)), Dot(e1, e2))
+	}
+	return angles
+}
+
+// OverlapsTriangle reports whether triangles t and u intersect using the separating axis theorem.
+// Triangles that only touch are considered overlapping.
+func (t Triangle) OverlapsTriangle(u Triangle) bool {
+	for _, tri := range [2]*Triangle{&t, &u} {
+		for i := range tri {
+			axis := perp(Sub(tri[(i+1)%3], tri[i]))
+			tmin, tmax := projectPoints(t[:], axis)
+			umin, umax := projectPoints(u[:], axis)
+			if tmax < umin || umax < tmin {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// OverlapsBox reports whether triangle t and box b intersect using the separating axis theorem.
+// Shapes that only touch are considered overlapping.
+func (t Triangle) OverlapsBox(b Box) bool {
+	tb := Box{Min: MinElem(MinElem(t[0], t[1]), t[2]), Max: MaxElem(MaxElem(t[0], t[1]), t[2])}
+	if tb.Max.X < b.Min.X || b.Max.X < tb.Min.X || tb.Max.Y < b.Min.Y || b.Max.Y < tb.Min.Y {
+		return false
+	}
+	corners := b.Vertices()
+	for i := range t {
+		axis := perp(Sub(t[(i+1)%3], t[i]))
+		tmin, tmax := projectPoints(t[:], axis)
+		bmin, bmax := projectPoints(corners[:], axis)
+		if tmax < bmin || bmax < tmin {
+			return false
+		}
+	}
+	return true
+}
+
+// perp returns v rotated 90 degrees counter-clockwise.
+func perp(v Vec) Vec { return Vec{X: -v.Y, Y: v.X} }
+
+// projectPoints returns the extent of the projection of pts onto axis.
+func projectPoints(pts []Vec, axis Vec) (lo, hi float64) {
+	lo = Dot(pts[0], axis)
+	hi = lo
+	for _, p := range pts[1:] {
+		d := Dot(p, axis)
+		lo = math.Min(lo, d)
+		hi = math.Max(hi, d)
+	}
+	return lo, hi
+}
@@ -237,25 +237,29 @@ func SmoothStepElem(e0, e1, x Vec) Vec {
 	return Vec{X: ms1.SmoothStep(e0.X, e1.X, x.X), Y: ms1.SmoothStep(e0.Y, e1.Y, x.Y)}
 }
 
-// Orientation calculates the orientation in the plane of 3 points and applies it to f.
+// CopyOrientation calculates the orientation in the plane of 3 points and applies it to f.
 //   - f returned for counter-clockwise orientation
 //   - -f returned for clockwise orientation
 //   - 0 returned for 3 colinear points
+//
+// The orientation is computed exactly with [Orient2D].
 func CopyOrientation(f float64, p1, p2, p3 Vec) float64 {
-	// See C++ version: https://www.geeksforgeeks.org/orientation-3-ordered-points/
-	slope1 := (p2.Y - p1.Y) * (p3.X - p2.X)
-	slope2 := (p3.Y - p2.Y) * (p2.X - p1.X)
-	if slope1 == slope2 {
-		return 0
+	switch Orient2D(p1, p2, p3) {
+	case 1:
+		return math.Abs(f)
+	case -1:
+		return -math.Abs(f)
 	}
-
-	return math.Copysign(f, slope2-slope1)
+	return 0
 }
 
 // Collinear returns true if 3 points lie on a single line to within tol.
 // tol is interpreted as the sine of the maximum permissible angle subtended
-// at c by the points a and b.
+// at c by the points a and b. If tol is zero the exact [Orient2D] predicate is used instead.
 func Collinear(a, b, c Vec, tol float64) bool {
+	if tol == 0 {
+		return Orient2D(a, b, c) == 0
+	}
 	// Equivalent to |sin(θ)| < tol with θ the angle at c, but avoids the two
 	// square roots and divisions of normalizing pa and pb. Since both sides are
 	// non-negative the inequality is preserved when squared:
@@ -169,3 +169,113 @@ func (a Box) Diagonal() float64 {
 	sz := a.Size()
 	return math.Hypot(math.Hypot(sz.X, sz.Y), sz.Z)
 }
+
+// IntersectRay intersects the ray origin + t*dir with the box using the slab method. tmin and tmax are the
+// ray parameters at which the ray's line enters and exits the box and normal is the outward unit normal
+// of the face through which it enters. hit is false if the line misses the box or the box lies behind the
+// ray origin, i.e: tmax < 0. If the origin is inside the box tmin is negative.
+// normal is the zero vector if the line runs along a face, so that no face is entered.
+func (a Box) IntersectRay(origin, dir Vec) (tmin, tmax float64, normal Vec, hit bool) {
+	tmin, tmax, axis, ok := a.clipRay(origin, dir, math.Inf(-1), math.Inf(1))
+	if !ok || tmax < 0 {
+		return tmin, tmax, Vec{}, false
+	}
+	if axis >= 0 {
+		var n [3]float64
+		n[axis] = -math.Copysign(1, dir.Array()[axis])
+		normal = Vec{X: n[0], Y: n[1], Z: n[2]}
+	}
+	return tmin, tmax, normal, true
+}
+
+// ClipSegment clips the segment between p0 and p1 to the box. ok is false if the segment lies outside the box.
+func (a Box) ClipSegment(p0, p1 Vec) (c0, c1 Vec, ok bool) {
+	d := Sub(p1, p0)
+	t0, t1, _, ok := a.clipRay(p0, d, 0, 1)
+	if !ok {
+		return p0, p1, false
+	}
+	c0, c1 = p0, p1
+	if t0 > 0 {
+		c0 = Add(p0, Scale(t0, d))
+	}
+	if t1 < 1 {
+		c1 = Add(p0, Scale(t1, d))
+	}
+	return c0, c1, true
+}
+
+// clipRay clips the parameter range [t0, t1] of the ray origin + t*dir to the box's slabs. axis is the
+// axis of the slab that last raised t0, or -1 if none did. ok is false if the clipped range is empty.
+func (a Box) clipRay(origin, dir Vec, t0, t1 float64) (tEnter, tExit float64, axis int, ok bool) {
+	o, d := origin.Array(), dir.Array()
+	lo, hi := a.Min.Array(), a.Max.Array()
+	axis = -1
+	for i := range o {
+		if d[i] == 0 {
+			if o[i] < lo[i] || o[i] > hi[i] {
+				return t0, t1, -1, false // Parallel to and outside of slab.
+			}
+			continue
+		}
+		ta := (lo[i] - o[i]) / d[i]
+		tb := (hi[i] - o[i]) / d[i]
+		if ta > tb {
+			ta, tb = tb, ta
+		}
+		if ta > t0 {
+			t0 = ta
+			axis = i
+		}
+		t1 = math.Min(t1, tb)
+	}
+	return t0, t1, axis, t0 <= t1
+}
+
+// Closest returns the point in the box closest to p. It returns p if p is contained in the box.
+func (a Box) Closest(p Vec) Vec {
+	return MinElem(MaxElem(p, a.Min), a.Max)
+}
+
+// Distance returns the distance from p to the closest point in the box. It is zero for points contained in the box.
+func (a Box) Distance(p Vec) float64 {
+	return Norm(Sub(p, a.Closest(p)))
+}
+
+// DistanceBox returns the distance between the closest points of boxes a and b. It is zero for intersecting boxes.
+func (a Box) DistanceBox(b Box) float64 {
+	gap := MaxElem(MaxElem(Sub(b.Min, a.Max), Sub(a.Min, b.Max)), Vec{})
+	return Norm(gap)
+}
+
+// Split splits the box in two along the plane normal to axis (0 for x, 1 for y, 2 for z) at value. lo contains
+// the part of the box below value and hi the part above. value is clamped to the box's extent along the axis.
+// Split panics if axis is not 0, 1 or 2.
+func (a Box) Split(axis int, value float64) (lo, hi Box) {
+	lo, hi = a, a
+	switch axis {
+	case 0:
+		value = math.Max(a.Min.X, math.Min(value, a.Max.X))
+		lo.Max.X, hi.Min.X = value, value
+	case 1:
+		value = math.Max(a.Min.Y, math.Min(value, a.Max.Y))
+		lo.Max.Y, hi.Min.Y = value, value
+	case 2:
+		value = math.Max(a.Min.Z, math.Min(value, a.Max.Z))
+		lo.Max.Z, hi.Min.Z = value, value
+	default:
+		panic("invalid axis")
+	}
+	return lo, hi
+}
+
+// Octree subdivides the box into 8 octants of equal size split at the box center.
+// The ith octant contains the ith vertex returned by [Box.Vertices].
+func (a Box) Octree() [8]Box {
+	c := a.Center()
+	var octants [8]Box
+	for i, v := range a.Vertices() {
+		octants[i] = Box{Min: MinElem(v, c), Max: MaxElem(v, c)}
+	}
+	return octants
+}
@@ -0,0 +1,189 @@
+// DO NOT EDIT.
+// This file was generated automatically
+// from gen.go. Please do not edit this file.
+
+package md3
+
+import (
+	math "math"
+)
+
+// Polyline is an open path of line segments joining consecutive vertices, such as a sampled curve or toolpath.
+// Positions along the polyline are given as arc length: the distance traveled along the path from its first vertex.
+type Polyline []Vec
+
+// Length returns the total arc length of the polyline.
+func (pl Polyline) Length() float64 {
+	var length float64
+	for i := 1; i < len(pl); i++ {
+		length += Norm(Sub(pl[i], pl[i-1]))
+	}
+	return length
+}
+
+// AppendArcLengths appends the arc length at each vertex of the polyline to dst.
+func (pl Polyline) AppendArcLengths(dst []float64) []float64 {
+	var length float64
+	for i := range pl {
+		if i > 0 {
+			length += Norm(Sub(pl[i], pl[i-1]))
+		}
+		dst = append(dst, length)
+	}
+	return dst
+}
+
+// PointAt returns the point at arc length s along the polyline. s is clamped to the polyline's extent.
+// PointAt panics if the polyline has no vertices.
+func (pl Polyline) PointAt(s float64) Vec {
+	p, _ := pl.locate(s)
+	return p
+}
+
+// AppendResampled appends n points uniformly spaced by arc length along the polyline to dst, including
+// the first and last vertices. AppendResampled panics if n < 2 or the polyline has no vertices.
+func (pl Polyline) AppendResampled(dst Polyline, n int) Polyline {
+	if n < 2 {
+		panic("resampling needs at least 2 points")
+	} else if len(pl) == 0 {
+		panic("empty polyline")
+	}
+	if len(pl) == 1 {
+		for k := 0; k < n; k++ {
+			dst = append(dst, pl[0])
+		}
+		return dst
+	}
+	step := pl.Length() / float64(n-1)
+	seg := 1
+	var segStart float64 // Arc length at pl[seg-1].
+	segLen := Norm(Sub(pl[1], pl[0]))
+	dst = append(dst, pl[0])
+	for k := 1; k < n-1; k++ {
+		s := float64(k) * step
+		for seg < len(pl)-1 && segStart+segLen < s {
+			segStart += segLen
+			seg++
+			segLen = Norm(Sub(pl[seg], pl[seg-1]))
+		}
+		t := float64(1)
+		if segLen > 0 {
+			t = clampf((s-segStart)/segLen, 0, 1)
+		}
+		dst = append(dst, Add(pl[seg-1], Scale(t, Sub(pl[seg], pl[seg-1]))))
+	}
+	return append(dst, pl[len(pl)-1])
+}
+
+// Tangent returns the unit tangent of the polyline at its ith vertex, the normalized average of the directions of the
+// segments joined at the vertex. Tangent returns the zero vector if the segments are degenerate or double back on each other.
+func (pl Polyline) Tangent(i int) Vec {
+	var t Vec
+	if i > 0 {
+		t = unitOrZero(Sub(pl[i], pl[i-1]))
+	}
+	if i < len(pl)-1 {
+		t = Add(t, unitOrZero(Sub(pl[i+1], pl[i])))
+	}
+	return unitOrZero(t)
+}
+
+// Normal returns the unit normal of the polyline at its ith vertex, pointing towards the center of curvature
+// of the path at the vertex. Normal returns the zero vector at the end vertices and where the path is straight.
+func (pl Polyline) Normal(i int) Vec {
+	if i <= 0 || i >= len(pl)-1 {
+		return Vec{}
+	}
+	t := pl.Tangent(i)
+	// Change in direction between the segments joined at the vertex, without its tangential component.
+	bend := Sub(unitOrZero(Sub(pl[i+1], pl[i])), unitOrZero(Sub(pl[i], pl[i-1])))
+	return unitOrZero(Sub(bend, Scale(Dot(bend, t), t)))
+}
+
+// Project returns the point of the polyline closest to p, its arc length along the polyline and its distance to p.
+// Project panics if the polyline has no vertices.
+func (pl Polyline) Project(p Vec) (closest Vec, s, distance float64) {
+	closest = pl[0]
+	best := Norm2(Sub(p, closest))
+	var segStart float64
+	for i := 1; i < len(pl); i++ {
+		a, b := pl[i-1], pl[i]
+		ab := Sub(b, a)
+		segLen2 := Norm2(ab)
+		var t float64
+		if segLen2 > 0 {
+			t = Dot(Sub(p, a), ab) / segLen2
+			t = clampf(t, 0, 1)
+		}
+		c := Add(a, Scale(t, ab))
+		segLen := Norm(ab)
+		if d2 := Norm2(Sub(p, c)); d2 < best {
+			best, closest, s = d2, c, segStart+t*segLen
+		}
+		segStart += segLen
+	}
+	return closest, s, Norm(Sub(p, closest))
+}
+
+// AppendBetween appends the part of the polyline between arc lengths s0 and s1 to dst, starting with the point at s0
+// and ending with the point at s1. Arc lengths are clamped to the polyline's extent. If s0 > s1 the extracted part is reversed.
+// AppendBetween panics if the polyline has no vertices.
+func (pl Polyline) AppendBetween(dst Polyline, s0, s1 float64) Polyline {
+	reverse := s0 > s1
+	if reverse {
+		s0, s1 = s1, s0
+	}
+	start := len(dst)
+	p0, i0 := pl.locate(s0)
+	p1, i1 := pl.locate(s1)
+	dst = append(dst, p0)
+	for i := i0; i < i1; i++ {
+		if pl[i] != dst[len(dst)-1] {
+			dst = append(dst, pl[i])
+		}
+	}
+	if p1 != dst[len(dst)-1] || len(dst)-start == 1 {
+		dst = append(dst, p1)
+	}
+	if reverse {
+		dst[start:].Reverse()
+	}
+	return dst
+}
+
+// Reverse reverses the order of the polyline's vertices in place.
+func (pl Polyline) Reverse() {
+	for i, j := 0, len(pl)-1; i < j; i, j = i+1, j-1 {
+		pl[i], pl[j] = pl[j], pl[i]
+	}
+}
+
+// locate returns the point at arc length s and the index of the first vertex past it.
+func (pl Polyline) locate(s float64) (Vec, int) {
+	if s <= 0 {
+		return pl[0], 1
+	}
+	var segStart float64
+	for i := 1; i < len(pl); i++ {
+		segLen := Norm(Sub(pl[i], pl[i-1]))
+		if segStart+segLen >= s && segLen > 0 {
+			t := (s - segStart) / segLen
+			return Add(pl[i-1], Scale(t, Sub(pl[i], pl[i-1]))), i
+		}
+		segStart += segLen
+	}
+	return pl[len(pl)-1], len(pl)
+}
+
+// unitOrZero returns the unit vector colinear to v or the zero vector if v is zero.
+func unitOrZero(v Vec) Vec {
+	n := Norm(v)
+	if n == 0 {
+		return Vec{}
+	}
+	return Scale(1/n, v)
+}
+
+func clampf(v, lo, hi float64) float64 {
+	return math.Max(lo, math.Min(v, hi))
+}
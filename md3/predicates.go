@@ -0,0 +1,53 @@
+// DO NOT EDIT.
+// This file was generated automatically
+// from gen.go. Please do not edit this file.
+
+package md3
+
+import "github.com/soypat/geometry/internal"
+
+// Orient3D returns the exact orientation of the point d relative to the plane passing through a, b and c:
+//   - 1 if d lies on the side of the plane that Cross(b-a, c-a) points towards
+//   - -1 if d lies on the opposite side
+//   - 0 if the four points are coplanar
+//
+// Equivalently the result is the sign of the volume of the tetrahedron abcd. Unlike floating point
+// triple products the result is exact for finite single precision inputs and for double precision inputs
+// whose products of up to three coordinates neither overflow nor underflow. Otherwise, e.g. for coordinates
+// around 1e100 in magnitude, the result may be wrong or 0.
+func Orient3D(a, b, c, d Vec) int {
+	return -sign(internal.Orient3(
+		float64(a.X), float64(a.Y), float64(a.Z),
+		float64(b.X), float64(b.Y), float64(b.Z),
+		float64(c.X), float64(c.Y), float64(c.Z),
+		float64(d.X), float64(d.Y), float64(d.Z),
+	))
+}
+
+// InSphere returns exactly where the point e lies relative to the sphere passing through a, b, c and d:
+//   - 1 if e lies inside the sphere
+//   - -1 if e lies outside the sphere
+//   - 0 if the five points are cospherical
+//
+// a, b, c and d must be positively oriented, i.e: [Orient3D](a,b,c,d) is 1. If they are negatively oriented the sign is reversed.
+// The result is exact for finite single precision inputs and for double precision inputs whose products
+// of up to five coordinates neither overflow nor underflow.
+func InSphere(a, b, c, d, e Vec) int {
+	return -sign(internal.InSphere(
+		float64(a.X), float64(a.Y), float64(a.Z),
+		float64(b.X), float64(b.Y), float64(b.Z),
+		float64(c.X), float64(c.Y), float64(c.Z),
+		float64(d.X), float64(d.Y), float64(d.Z),
+		float64(e.X), float64(e.Y), float64(e.Z),
+	))
+}
+
+func sign(v float64) int {
+	switch {
+	case v > 0:
+		return 1
+	case v < 0:
+		return -1
+	}
+	return 0
+}
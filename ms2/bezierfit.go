@@ -0,0 +1,233 @@
+package ms2
+
+import (
+	math "github.com/chewxy/math32"
+	"github.com/soypat/geometry/ms1"
+)
+
+// BezierFitter fits a chain of cubic Bézier segments to a sequence of sampled points
+// using Philip J. Schneider's algorithm from Graphics Gems (1990) "An Algorithm for Automatically Fitting Digitized Curves".
+// It is the inverse operation of sampling a curve with [Spline3Sampler].
+//
+// The fitted chain is appended to a buffer as 3*n+1 points for n segments:
+//
+//	p0, cp0, cp1, p1, cp2, cp3, p2, ...
+//
+// Consecutive segments share their end points so the chain can be evaluated by iterating every 3 points with [SplineBezierCubic].
+// Joints between segments are G¹ continuous unless they lie on a detected corner.
+type BezierFitter struct {
+	// MaxError is the maximum permissible distance between an input point and the fitted curve. Must be positive.
+	MaxError float32
+	// CornerAngle is the minimum turning angle in radians between the incoming and outgoing
+	// directions at an input point for it to be considered a corner. The curve is split at corners
+	// and tangent continuity is not enforced there. If zero no corner detection is performed.
+	CornerAngle float32
+	// MaxIterations is the maximum amount of Newton-Raphson reparametrization steps
+	// performed before splitting a segment. If zero a default of 4 is used.
+	MaxIterations int
+}
+
+// AppendFit fits cubic Bézier segments to points and appends the control points of the
+// resulting chain to dst. See [BezierFitter] for the layout of the appended points.
+// Consecutive duplicate points are ignored. If less than 2 distinct points are provided
+// the distinct points are appended as is.
+func (bf BezierFitter) AppendFit(dst, points []Vec) []Vec {
+	if bf.MaxError <= 0 {
+		panic("BezierFitter MaxError must be positive")
+	} else if bf.CornerAngle < 0 {
+		panic("negative BezierFitter CornerAngle")
+	}
+	maxIter := bf.MaxIterations
+	if maxIter <= 0 {
+		maxIter = 4
+	}
+	// Work on a copy without consecutive duplicates, which break tangent estimation.
+	pts := make([]Vec, 0, len(points))
+	for i := range points {
+		if len(pts) == 0 || points[i] != pts[len(pts)-1] {
+			pts = append(pts, points[i])
+		}
+	}
+	if len(pts) < 2 {
+		return append(dst, pts...)
+	}
+	f := bezierFit{
+		err2:    bf.MaxError * bf.MaxError,
+		maxIter: maxIter,
+	}
+	dst = append(dst, pts[0])
+	start := 0
+	for i := 1; bf.CornerAngle > 0 && i < len(pts)-1; i++ {
+		in := Sub(pts[i], pts[i-1])
+		out := Sub(pts[i+1], pts[i])
+		if math.Acos(ms1.Clamp(Cos(in, out), -1, 1)) >= bf.CornerAngle {
+			dst = f.fitRun(dst, pts[start:i+1])
+			start = i
+		}
+	}
+	return f.fitRun(dst, pts[start:])
+}
+
+// bezierFit holds the state of a Schneider fit.
+type bezierFit struct {
+	err2    float32
+	maxIter int
+	u       []float32 // Parameter buffer reused across fits.
+}
+
+// fitRun fits a run of points with no corners and appends all points of the fit except the first.
+func (f *bezierFit) fitRun(dst, pts []Vec) []Vec {
+	tHat1 := Unit(Sub(pts[1], pts[0]))
+	tHat2 := Unit(Sub(pts[len(pts)-2], pts[len(pts)-1]))
+	return f.fitCubic(dst, pts, tHat1, tHat2)
+}
+
+// fitCubic fits a single Bézier segment to pts with end tangents tHat1 and tHat2 (pointing inwards)
+// splitting the points recursively if the error is too large.
+func (f *bezierFit) fitCubic(dst, pts []Vec, tHat1, tHat2 Vec) []Vec {
+	if len(pts) == 2 {
+		dist := Norm(Sub(pts[1], pts[0])) / 3
+		return append(dst,
+			Add(pts[0], Scale(dist, tHat1)),
+			Add(pts[1], Scale(dist, tHat2)),
+			pts[1],
+		)
+	}
+	f.u = chordLengthParametrize(f.u[:0], pts)
+	u := f.u
+	bz := generateBezier(pts, u, tHat1, tHat2)
+	maxErr, split := bezierMaxError(pts, bz, u)
+	if maxErr < f.err2 {
+		return append(dst, bz[1], bz[2], bz[3])
+	}
+	// If the error is within 4 times MaxError try reparametrizing and iterating.
+	if maxErr < 16*f.err2 {
+		for i := 0; i < f.maxIter; i++ {
+			reparametrize(u, pts, bz)
+			bz = generateBezier(pts, u, tHat1, tHat2)
+			maxErr, split = bezierMaxError(pts, bz, u)
+			if maxErr < f.err2 {
+				return append(dst, bz[1], bz[2], bz[3])
+			}
+		}
+	}
+	// Fitting failed: split at the point of max error and fit recursively
+	// sharing the center tangent on both sides so the joint is G¹ continuous.
+	tCenter := Unit(Sub(pts[split-1], pts[split+1]))
+	if math.IsNaN(tCenter.X) { // Points before and after split are equal.
+		tCenter = Unit(Sub(pts[split-1], pts[split]))
+	}
+	dst = f.fitCubic(dst, pts[:split+1], tHat1, tCenter)
+	return f.fitCubic(dst, pts[split:], Scale(-1, tCenter), tHat2)
+}
+
+// generateBezier uses least-squares to find the Bézier control points for a region
+// given the end tangents and the point parametrization.
+func generateBezier(pts []Vec, u []float32, tHat1, tHat2 Vec) [4]Vec {
+	first, last := pts[0], pts[len(pts)-1]
+	var c00, c01, c11, x0, x1 float32
+	for i, p := range pts {
+		t := u[i]
+		mt := 1 - t
+		b0 := mt * mt * mt
+		b1 := 3 * t * mt * mt
+		b2 := 3 * t * t * mt
+		b3 := t * t * t
+		a1 := Scale(b1, tHat1)
+		a2 := Scale(b2, tHat2)
+		c00 += Dot(a1, a1)
+		c01 += Dot(a1, a2)
+		c11 += Dot(a2, a2)
+		tmp := Sub(p, Add(Scale(b0+b1, first), Scale(b2+b3, last)))
+		x0 += Dot(a1, tmp)
+		x1 += Dot(a2, tmp)
+	}
+	// Solve the 2x2 system with Cramer's rule.
+	det := c00*c11 - c01*c01
+	var alpha1, alpha2 float32
+	if det != 0 {
+		alpha1 = (x0*c11 - c01*x1) / det
+		alpha2 = (c00*x1 - c01*x0) / det
+	}
+	segLen := Norm(Sub(last, first))
+	eps := 1e-6 * segLen
+	if alpha1 < eps || alpha2 < eps {
+		// Fall back on Wu/Barsky heuristic if alpha is negative or degenerate.
+		alpha1 = segLen / 3
+		alpha2 = alpha1
+	}
+	return [4]Vec{
+		first,
+		Add(first, Scale(alpha1, tHat1)),
+		Add(last, Scale(alpha2, tHat2)),
+		last,
+	}
+}
+
+// reparametrize improves the parametrization u of pts over the curve bz using a Newton-Raphson step.
+func reparametrize(u []float32, pts []Vec, bz [4]Vec) {
+	for i := range u {
+		u[i] = newtonRaphsonRootFind(bz, pts[i], u[i])
+	}
+}
+
+func newtonRaphsonRootFind(bz [4]Vec, p Vec, u float32) float32 {
+	q := bezierEval(bz, u)
+	d1, d2 := bezierDiffs(bz, u)
+	diff := Sub(q, p)
+	num := Dot(diff, d1)
+	den := Dot(d1, d1) + Dot(diff, d2)
+	if den == 0 {
+		return u
+	}
+	return u - num/den
+}
+
+// bezierMaxError returns the maximum squared distance between pts and their
+// parametrized points on the curve, along with the index of the farthest point.
+func bezierMaxError(pts []Vec, bz [4]Vec, u []float32) (maxDist2 float32, splitIdx int) {
+	splitIdx = len(pts) / 2
+	for i := 1; i < len(pts)-1; i++ {
+		d2 := Norm2(Sub(bezierEval(bz, u[i]), pts[i]))
+		if d2 >= maxDist2 {
+			maxDist2 = d2
+			splitIdx = i
+		}
+	}
+	return maxDist2, splitIdx
+}
+
+// chordLengthParametrize appends the normalized cumulative chord length of pts to dst.
+func chordLengthParametrize(dst []float32, pts []Vec) []float32 {
+	start := len(dst)
+	dst = append(dst, 0)
+	for i := 1; i < len(pts); i++ {
+		dst = append(dst, dst[len(dst)-1]+Norm(Sub(pts[i], pts[i-1])))
+	}
+	total := dst[len(dst)-1]
+	for i := start + 1; i < len(dst); i++ {
+		dst[i] /= total
+	}
+	return dst
+}
+
+// bezierEval evaluates a cubic Bézier curve at t.
+func bezierEval(bz [4]Vec, t float32) Vec {
+	mt := 1 - t
+	b0 := mt * mt * mt
+	b1 := 3 * t * mt * mt
+	b2 := 3 * t * t * mt
+	b3 := t * t * t
+	return Add(Add(Scale(b0, bz[0]), Scale(b1, bz[1])), Add(Scale(b2, bz[2]), Scale(b3, bz[3])))
+}
+
+// bezierDiffs returns the first and second derivatives of a cubic Bézier curve at t.
+func bezierDiffs(bz [4]Vec, t float32) (d1, d2 Vec) {
+	mt := 1 - t
+	q0 := Scale(3, Sub(bz[1], bz[0]))
+	q1 := Scale(3, Sub(bz[2], bz[1]))
+	q2 := Scale(3, Sub(bz[3], bz[2]))
+	d1 = Add(Add(Scale(mt*mt, q0), Scale(2*t*mt, q1)), Scale(t*t, q2))
+	d2 = Add(Scale(2*mt, Sub(q1, q0)), Scale(2*t, Sub(q2, q1)))
+	return d1, d2
+}
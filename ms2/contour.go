@@ -0,0 +1,146 @@
+package ms2
+
+// AppendContour extracts the contour lines of a scalar field at the argument level with the marching squares algorithm
+// and appends the resulting segments to dst. values contains the field sampled on the nx*ny grid vertices of domain
+// in the same x-major order as the points returned by [AppendGrid].
+//
+// Edge crossings are found by linear interpolation. Segments are oriented so that the region where the field is
+// below level lies on their left, so contours enclosing a minimum (i.e: the inside of a signed distance field) are counter-clockwise.
+// Saddle cells are resolved with the average of the cell's corner values. Crossings are computed identically for cells sharing an edge,
+// so segments can be joined exactly with [AppendPolylines].
+func AppendContour(dst []Line, domain Box, nx, ny int, values []float32, level float32) []Line {
+	if nx <= 1 || ny <= 1 {
+		panic("AppendContour needs more grid subdivisions")
+	} else if len(values) != nx*ny {
+		panic("AppendContour values length must be nx*ny")
+	}
+	d := DivElem(domain.Size(), Vec{X: float32(nx - 1), Y: float32(ny - 1)})
+	for j := 0; j < ny-1; j++ {
+		dst = appendContourRow(dst, domain.Min, d, j, values[j*nx:(j+1)*nx], values[(j+1)*nx:(j+2)*nx], level)
+	}
+	return dst
+}
+
+// AppendContourFunc is like [AppendContour] but samples the scalar field f on the nx*ny grid vertices of domain.
+// Only two rows of samples are kept in memory at a time.
+func AppendContourFunc(dst []Line, domain Box, nx, ny int, f func(Vec) float32, level float32) []Line {
+	if nx <= 1 || ny <= 1 {
+		panic("AppendContourFunc needs more grid subdivisions")
+	}
+	d := DivElem(domain.Size(), Vec{X: float32(nx - 1), Y: float32(ny - 1)})
+	buf := make([]float32, 2*nx)
+	lo, hi := buf[:nx], buf[nx:]
+	sampleRow := func(row []float32, j int) {
+		y := domain.Min.Y + d.Y*float32(j)
+		for i := range row {
+			row[i] = f(Vec{X: domain.Min.X + d.X*float32(i), Y: y})
+		}
+	}
+	sampleRow(lo, 0)
+	for j := 0; j < ny-1; j++ {
+		sampleRow(hi, j+1)
+		dst = appendContourRow(dst, domain.Min, d, j, lo, hi, level)
+		lo, hi = hi, lo
+	}
+	return dst
+}
+
+// appendContourRow appends the contour segments of the cells between grid rows j and j+1 with values lo and hi.
+func appendContourRow(dst []Line, origin, d Vec, j int, lo, hi []float32, level float32) []Line {
+	y0 := origin.Y + d.Y*float32(j)
+	y1 := origin.Y + d.Y*float32(j+1)
+	for i := 0; i < len(lo)-1; i++ {
+		x0 := origin.X + d.X*float32(i)
+		x1 := origin.X + d.X*float32(i+1)
+		// Cell corners in counter-clockwise order.
+		pos := [4]Vec{{X: x0, Y: y0}, {X: x1, Y: y0}, {X: x1, Y: y1}, {X: x0, Y: y1}}
+		val := [4]float32{lo[i], lo[i+1], hi[i+1], hi[i]}
+		dst = appendContourCell(dst, pos, val, level)
+	}
+	return dst
+}
+
+// appendContourCell appends the contour segments of a grid cell with corners pos in counter-clockwise order
+// starting at the lowest corner and their values val.
+func appendContourCell(dst []Line, pos [4]Vec, val [4]float32, level float32) []Line {
+	var inside [4]bool
+	var nInside int
+	for k, v := range val {
+		inside[k] = v < level
+		if inside[k] {
+			nInside++
+		}
+	}
+	if nInside == 0 || nInside == 4 {
+		return dst // Cell not crossed by contour.
+	}
+	// Gather edge crossings in counter-clockwise order.
+	var crossings [4]Vec
+	var leaving [4]bool // Crossing leaves the inside region when walking counter-clockwise.
+	var n int
+	for k := 0; k < 4; k++ {
+		k1 := (k + 1) % 4
+		if inside[k] == inside[k1] {
+			continue
+		}
+		// Always interpolate from lowest to highest grid vertex so neighboring cells compute identical crossings.
+		a, b := k, k1
+		if k >= 2 {
+			a, b = k1, k
+		}
+		t := (level - val[a]) / (val[b] - val[a])
+		crossings[n] = Add(pos[a], Scale(t, Sub(pos[b], pos[a])))
+		leaving[n] = inside[k]
+		n++
+	}
+	// Pair each leaving crossing with the next crossing if the inside region is connected through
+	// the cell center, or the previous crossing otherwise. Both are the same for non-saddle cells.
+	centerInside := (val[0]+val[1]+val[2]+val[3])/4 < level
+	for k := 0; k < n; k++ {
+		if !leaving[k] {
+			continue
+		}
+		next := (k + n - 1) % n
+		if centerInside {
+			next = (k + 1) % n
+		}
+		if crossings[k] != crossings[next] {
+			dst = append(dst, Line{crossings[k], crossings[next]})
+		}
+	}
+	return dst
+}
+
+// AppendPolylines joins consecutive segments sharing end and start points into polylines and appends them to dst.
+// Closed polylines repeat their first point at the end. Segments must be consistently oriented, such as the
+// segments returned by [AppendContour], and end points must match exactly to be joined.
+func AppendPolylines(dst [][]Vec, segments []Line) [][]Vec {
+	byStart := make(map[Vec]int, len(segments))
+	byEnd := make(map[Vec]int, len(segments))
+	for i, seg := range segments {
+		byStart[seg[0]] = i
+		byEnd[seg[1]] = i
+	}
+	used := make([]bool, len(segments))
+	for i := range segments {
+		if used[i] {
+			continue
+		}
+		// Walk backwards to find the start of an open polyline.
+		first := i
+		for steps := 0; steps < len(segments); steps++ {
+			prev, ok := byEnd[segments[first][0]]
+			if !ok || used[prev] || prev == i {
+				break
+			}
+			first = prev
+		}
+		line := []Vec{segments[first][0]}
+		for cur, ok := first, true; ok && !used[cur]; cur, ok = byStart[segments[cur][1]] {
+			used[cur] = true
+			line = append(line, segments[cur][1])
+		}
+		dst = append(dst, line)
+	}
+	return dst
+}
@@ -0,0 +1,377 @@
+package ms2
+
+import (
+	math "github.com/chewxy/math32"
+)
+
+// Supporter is implemented by convex shapes which provide a support mapping for use with [GJKSolver].
+// Support returns the point of the shape furthest along dir, that is, the point p of the shape that maximizes Dot(p, dir).
+// dir is not required to be normalized.
+type Supporter interface {
+	Support(dir Vec) Vec
+}
+
+var (
+	_ Supporter = Triangle{}
+	_ Supporter = Box{}
+	_ Supporter = Line{}
+	_ Supporter = Circle{}
+	_ Supporter = ConvexPoints{}
+)
+
+// Circle is a solid disk defined by its center and radius.
+type Circle struct {
+	Center Vec
+	Radius float32
+}
+
+// Support returns the point of the circle furthest along dir. See [Supporter].
+func (c Circle) Support(dir Vec) Vec {
+	n := Norm(dir)
+	if n == 0 {
+		return Add(c.Center, Vec{X: c.Radius})
+	}
+	return Add(c.Center, Scale(c.Radius/n, dir))
+}
+
+// ConvexPoints is the convex hull of a set of points. It is used to build a [Supporter] from
+// the vertices of an arbitrary convex polygon.
+type ConvexPoints []Vec
+
+// Support returns the point of the set furthest along dir. See [Supporter].
+func (c ConvexPoints) Support(dir Vec) Vec { return supportPoints(c, dir) }
+
+// Support returns the vertex of the triangle furthest along dir. See [Supporter].
+func (t Triangle) Support(dir Vec) Vec { return supportPoints(t[:], dir) }
+
+// Support returns the end point of the line segment furthest along dir. See [Supporter].
+func (l Line) Support(dir Vec) Vec { return supportPoints(l[:], dir) }
+
+// Support returns the vertex of the box furthest along dir. See [Supporter].
+func (a Box) Support(dir Vec) Vec {
+	v := a.Min
+	if dir.X > 0 {
+		v.X = a.Max.X
+	}
+	if dir.Y > 0 {
+		v.Y = a.Max.Y
+	}
+	return v
+}
+
+func supportPoints(pts []Vec, dir Vec) Vec {
+	best := pts[0]
+	bestDot := Dot(best, dir)
+	for _, p := range pts[1:] {
+		if d := Dot(p, dir); d > bestDot {
+			best, bestDot = p, d
+		}
+	}
+	return best
+}
+
+// DefaultGJKSolver returns a [GJKSolver] with recommended parameters.
+func DefaultGJKSolver() GJKSolver {
+	return GJKSolver{
+		MaxIterations: 64,
+		Tolerance:     1e-5,
+	}
+}
+
+// GJKSolver implements convex shape queries on [Supporter] shapes: the Gilbert-Johnson-Keerthi (GJK) algorithm
+// for overlap tests and separation distance and the Expanding Polytope Algorithm (EPA) for penetration depth.
+// Both operate on the Minkowski difference A-B of the shapes, which contains the origin if and only if the shapes overlap.
+type GJKSolver struct {
+	// MaxIterations bounds the amount of support mapping evaluations of each algorithm. Parameter is required.
+	MaxIterations int
+	// Tolerance is the relative tolerance with which the distance or penetration depth is computed. Parameter is required.
+	Tolerance float32
+}
+
+// gjkVertex is a vertex of the Minkowski difference A-B and the shape points which generate it.
+type gjkVertex struct {
+	w, a, b Vec
+}
+
+// Overlap reports whether shapes a and b intersect. Touching shapes are considered overlapping.
+func (s GJKSolver) Overlap(a, b Supporter) bool {
+	_, _, _, overlap := s.gjk(a, b)
+	return overlap
+}
+
+// Distance returns the separation distance between shapes a and b and the closest points
+// on each shape, pa on a and pb on b. If the shapes overlap dist is zero and pa and pb are not meaningful.
+func (s GJKSolver) Distance(a, b Supporter) (dist float32, pa, pb Vec) {
+	simplex, lambda, n, overlap := s.gjk(a, b)
+	for i := 0; i < n; i++ {
+		pa = Add(pa, Scale(lambda[i], simplex[i].a))
+		pb = Add(pb, Scale(lambda[i], simplex[i].b))
+	}
+	if overlap {
+		return 0, pa, pb
+	}
+	return Norm(Sub(pa, pb)), pa, pb
+}
+
+// Penetration returns the penetration depth and unit normal of overlapping shapes a and b using EPA.
+// Translating b by depth along normal separates the shapes, leaving them touching.
+// ok is false if the shapes do not overlap.
+func (s GJKSolver) Penetration(a, b Supporter) (depth float32, normal Vec, ok bool) {
+	simplex, _, n, overlap := s.gjk(a, b)
+	if !overlap {
+		return 0, Vec{}, false
+	}
+	n = gjkExpand(a, b, &simplex, n, s.Tolerance)
+	if n < 3 {
+		// Minkowski difference is flat: shapes are touching.
+		if n == 2 {
+			normal = Unit(Vec{X: simplex[1].w.Y - simplex[0].w.Y, Y: simplex[0].w.X - simplex[1].w.X})
+		}
+		return 0, normal, true
+	}
+	return s.epa(a, b, simplex)
+}
+
+// gjkExpand adds support points to the simplex until it is a triangle, needed when GJK terminates
+// with a lower dimensional simplex due to touching shapes. It returns the new amount of simplex vertices,
+// which is less than 3 if the Minkowski difference is flat.
+func gjkExpand(a, b Supporter, simplex *[3]gjkVertex, n int, tol float32) int {
+	for n < 3 {
+		var dirs []Vec
+		switch n {
+		case 1:
+			dirs = []Vec{{X: 1}, {X: -1}, {Y: 1}, {Y: -1}}
+		case 2:
+			e := Sub(simplex[1].w, simplex[0].w)
+			perp := Vec{X: -e.Y, Y: e.X}
+			dirs = []Vec{perp, Scale(-1, perp)}
+		}
+		added := false
+		for _, d := range dirs {
+			v := gjkSupport(a, b, d)
+			if gjkAffinelyIndependent(simplex[:n], v.w, tol) {
+				simplex[n] = v
+				n++
+				added = true
+				break
+			}
+		}
+		if !added {
+			break
+		}
+	}
+	return n
+}
+
+// gjk runs the GJK distance algorithm and returns the final simplex and the barycentric
+// coordinates of the point of the Minkowski difference closest to the origin.
+func (s GJKSolver) gjk(a, b Supporter) (simplex [3]gjkVertex, lambda [3]float32, n int, overlap bool) {
+	if s.MaxIterations <= 0 {
+		panic("invalid MaxIterations")
+	} else if s.Tolerance <= 0 || math.IsNaN(s.Tolerance) {
+		panic("invalid Tolerance")
+	}
+	simplex[0] = gjkSupport(a, b, Vec{X: 1})
+	lambda[0] = 1
+	n = 1
+	v := simplex[0].w
+	tol2 := s.Tolerance * s.Tolerance
+	for i := 0; i < s.MaxIterations; i++ {
+		vv := Norm2(v)
+		var maxW2 float32
+		for k := 0; k < n; k++ {
+			maxW2 = math.Max(maxW2, Norm2(simplex[k].w))
+		}
+		if vv <= tol2*maxW2 {
+			return simplex, lambda, n, true // Origin on simplex: shapes touching.
+		}
+		w := gjkSupport(a, b, Scale(-1, v))
+		if vv-Dot(v, w.w) <= s.Tolerance*vv {
+			break // No significant progress towards origin.
+		}
+		duplicate := false
+		for k := 0; k < n; k++ {
+			duplicate = duplicate || simplex[k].w == w.w
+		}
+		if duplicate {
+			break
+		}
+		simplex[n] = w
+		n++
+		v, lambda, n = gjkClosest(&simplex, n)
+		if n == 3 {
+			return simplex, lambda, n, true // Origin enclosed by triangle.
+		}
+	}
+	return simplex, lambda, n, false
+}
+
+func gjkSupport(a, b Supporter, dir Vec) gjkVertex {
+	pa := a.Support(dir)
+	pb := b.Support(Scale(-1, dir))
+	return gjkVertex{w: Sub(pa, pb), a: pa, b: pb}
+}
+
+// gjkClosest finds the point of the simplex closest to the origin, reduces the simplex to the
+// smallest sub-simplex containing it and returns the point and its barycentric coordinates.
+func gjkClosest(simplex *[3]gjkVertex, n int) (v Vec, lambda [3]float32, m int) {
+	bestDist := math.Inf(1)
+	var bestMask int
+	var bestLambda [3]float32
+	for mask := 1; mask < 1<<n; mask++ {
+		var idx [3]int
+		k := 0
+		for i := 0; i < n; i++ {
+			if mask&(1<<i) != 0 {
+				idx[k] = i
+				k++
+			}
+		}
+		l, ok := gjkProject(simplex, idx[:k])
+		if !ok {
+			continue
+		}
+		var p Vec
+		for j := 0; j < k; j++ {
+			p = Add(p, Scale(l[j], simplex[idx[j]].w))
+		}
+		if d := Norm2(p); d < bestDist {
+			bestDist, bestMask, bestLambda, v = d, mask, l, p
+		}
+	}
+	// Keep vertices of the sub-simplex.
+	var reduced [3]gjkVertex
+	for i := 0; i < n; i++ {
+		if bestMask&(1<<i) != 0 {
+			reduced[m] = simplex[i]
+			m++
+		}
+	}
+	*simplex = reduced
+	return v, bestLambda, m
+}
+
+// gjkProject projects the origin onto the affine hull of the simplex vertices given by idx and returns
+// the barycentric coordinates of the projection. ok is false if the projection lies outside the sub-simplex
+// or the sub-simplex is degenerate.
+func gjkProject(simplex *[3]gjkVertex, idx []int) (lambda [3]float32, ok bool) {
+	k := len(idx)
+	if k == 1 {
+		return [3]float32{1}, true
+	}
+	// Minimize |w0 + sum(mu_i*e_i)|² with e_i = w_i - w0 by solving the normal equations G*mu = -E'*w0.
+	w0 := simplex[idx[0]].w
+	var e [2]Vec
+	for i := 1; i < k; i++ {
+		e[i-1] = Sub(simplex[idx[i]].w, w0)
+	}
+	var sys [2][3]float32
+	for i := 0; i < k-1; i++ {
+		for j := 0; j < k-1; j++ {
+			sys[i][j] = Dot(e[i], e[j])
+		}
+		sys[i][k-1] = -Dot(e[i], w0)
+	}
+	mu, ok := solveSmall(&sys, k-1)
+	if !ok {
+		return lambda, false
+	}
+	lambda[0] = 1
+	for i := 0; i < k-1; i++ {
+		if mu[i] <= 0 {
+			return lambda, false
+		}
+		lambda[i+1] = mu[i]
+		lambda[0] -= mu[i]
+	}
+	return lambda, lambda[0] > 0
+}
+
+// solveSmall solves the n×n linear system with augmented matrix sys using Gaussian elimination with partial pivoting.
+func solveSmall(sys *[2][3]float32, n int) (x [2]float32, ok bool) {
+	var scale float32
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			scale = math.Max(scale, math.Abs(sys[i][j]))
+		}
+	}
+	for col := 0; col < n; col++ {
+		pivot := col
+		for r := col + 1; r < n; r++ {
+			if math.Abs(sys[r][col]) > math.Abs(sys[pivot][col]) {
+				pivot = r
+			}
+		}
+		if math.Abs(sys[pivot][col]) <= 1e-6*scale {
+			return x, false // Singular system: degenerate simplex.
+		}
+		sys[col], sys[pivot] = sys[pivot], sys[col]
+		for r := col + 1; r < n; r++ {
+			f := sys[r][col] / sys[col][col]
+			for c := col; c <= n; c++ {
+				sys[r][c] -= f * sys[col][c]
+			}
+		}
+	}
+	for r := n - 1; r >= 0; r-- {
+		sum := sys[r][n]
+		for c := r + 1; c < n; c++ {
+			sum -= sys[r][c] * x[c]
+		}
+		x[r] = sum / sys[r][r]
+	}
+	return x, true
+}
+
+// gjkAffinelyIndependent reports whether w is affinely independent of the simplex vertices.
+func gjkAffinelyIndependent(simplex []gjkVertex, w Vec, tol float32) bool {
+	w0 := simplex[0].w
+	d := Sub(w, w0)
+	scale := Norm(d)
+	if scale == 0 {
+		return false
+	}
+	if len(simplex) == 1 {
+		return true
+	}
+	e := Sub(simplex[1].w, w0)
+	return math.Abs(Cross(e, d)) > tol*Norm(e)*scale
+}
+
+// epa runs the expanding polytope algorithm starting from a triangle enclosing the origin.
+func (s GJKSolver) epa(a, b Supporter, tri [3]gjkVertex) (depth float32, normal Vec, ok bool) {
+	poly := make([]gjkVertex, 0, 3+s.MaxIterations)
+	poly = append(poly, tri[:]...)
+	if Cross(Sub(tri[1].w, tri[0].w), Sub(tri[2].w, tri[0].w)) < 0 {
+		poly[1], poly[2] = poly[2], poly[1] // Counter-clockwise order.
+	}
+	for i := 0; i < s.MaxIterations; i++ {
+		// Find polygon edge closest to origin.
+		closest := -1
+		depth = math.Inf(1)
+		for k := range poly {
+			e := Sub(poly[(k+1)%len(poly)].w, poly[k].w)
+			n := Vec{X: e.Y, Y: -e.X} // Outward normal of counter-clockwise polygon.
+			norm := Norm(n)
+			if norm == 0 {
+				continue
+			}
+			n = Scale(1/norm, n)
+			if d := math.Abs(Dot(n, poly[k].w)); d < depth {
+				closest, depth, normal = k, d, n
+			}
+		}
+		if closest < 0 {
+			break
+		}
+		w := gjkSupport(a, b, normal)
+		if Dot(w.w, normal)-depth <= s.Tolerance*math.Max(depth, 1e-3) {
+			break // Reached boundary of Minkowski difference.
+		}
+		// Insert new vertex between edge vertices.
+		poly = append(poly, gjkVertex{})
+		copy(poly[closest+2:], poly[closest+1:])
+		poly[closest+1] = w
+	}
+	return depth, normal, true
+}
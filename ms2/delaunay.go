@@ -0,0 +1,230 @@
+package ms2
+
+import "errors"
+
+var errDelaunayFewSites = errors.New("Delaunay triangulation needs at least 3 sites")
+
+// Delaunay is a 2D Delaunay triangulation of a set of sites: no site lies inside the circumcircle of any triangle.
+// It is the dual of the Voronoi diagram, see [Voronoi].
+type Delaunay struct {
+	sites []Vec
+	tris  [][3]int
+}
+
+// NewDelaunay triangulates sites with the incremental Bowyer-Watson algorithm, not Fortune's sweep line.
+// Insertion starts from a finite super triangle with vertices 1e5 times the extent of the sites away from them,
+// so thin triangles on the convex hull of nearly collinear sites may be missing. Repeated sites are ignored.
+// Collinear sites result in a triangulation with no triangles.
+func NewDelaunay(sites []Vec) (Delaunay, error) {
+	if len(sites) < 3 {
+		return Delaunay{}, errDelaunayFewSites
+	}
+	var bw bowyerWatson
+	bw.triangulate(sites)
+	d := Delaunay{sites: sites}
+	for _, tri := range bw.tris {
+		if !tri.dead && bw.isSite(tri.v[0]) && bw.isSite(tri.v[1]) && bw.isSite(tri.v[2]) {
+			d.tris = append(d.tris, tri.v)
+		}
+	}
+	return d, nil
+}
+
+// Sites returns the triangulated sites. Modifying the returned slice modifies the triangulation.
+func (d Delaunay) Sites() []Vec { return d.sites }
+
+// Triangles returns the indices into [Delaunay.Sites] of the vertices of each triangle in counter-clockwise order.
+func (d Delaunay) Triangles() [][3]int { return d.tris }
+
+// Triangle returns the ith triangle of the triangulation.
+func (d Delaunay) Triangle(i int) Triangle {
+	v := d.tris[i]
+	return Triangle{d.sites[v[0]], d.sites[v[1]], d.sites[v[2]]}
+}
+
+// AppendEdges appends the edges of the triangulation to dst as pairs of site indices. Each edge is appended once.
+func (d Delaunay) AppendEdges(dst [][2]int) [][2]int {
+	seen := make(map[[2]int]bool, 3*len(d.tris)/2)
+	for _, v := range d.tris {
+		for k := 0; k < 3; k++ {
+			a, b := v[k], v[(k+1)%3]
+			if a > b {
+				a, b = b, a
+			}
+			if !seen[[2]int{a, b}] {
+				seen[[2]int{a, b}] = true
+				dst = append(dst, [2]int{a, b})
+			}
+		}
+	}
+	return dst
+}
+
+// bowyerWatson implements the Bowyer-Watson incremental Delaunay triangulation with a
+// super triangle enclosing all sites. Predicates are computed in float64 for robustness.
+type bowyerWatson struct {
+	pts  [][2]float64 // Sites followed by the 3 super triangle vertices.
+	tris []bwTriangle
+	// dup[i] is true if site i is a repeat of a previous site and was not inserted.
+	dup []bool
+	// last is a live triangle from which point location starts.
+	last int
+	// Cavity search buffers.
+	cavity   []int
+	boundary []bwEdge
+	mark     []int
+	stamp    int
+}
+
+type bwTriangle struct {
+	v    [3]int // Counter-clockwise vertices.
+	n    [3]int // n[k] is neighbor across edge opposite v[k], or -1.
+	dead bool
+}
+
+type bwEdge struct {
+	a, b, outer int
+}
+
+// isSite reports whether vertex index i is a site and not a super triangle vertex.
+func (bw *bowyerWatson) isSite(i int) bool { return i < len(bw.pts)-3 }
+
+func (bw *bowyerWatson) triangulate(sites []Vec) {
+	n := len(sites)
+	bw.pts = make([][2]float64, n, n+3)
+	bw.dup = make([]bool, n)
+	var bb Box
+	for i, s := range sites {
+		bw.pts[i] = [2]float64{float64(s.X), float64(s.Y)}
+		if i == 0 {
+			bb = Box{Min: s, Max: s}
+		} else {
+			bb = bb.IncludePoint(s)
+		}
+	}
+	c := bb.Center()
+	size := float64(bb.Size().Max())
+	if size == 0 {
+		size = 1
+	}
+	// Super triangle far away from sites to minimize its influence on the hull.
+	const far = 1e5
+	cx, cy := float64(c.X), float64(c.Y)
+	bw.pts = append(bw.pts,
+		[2]float64{cx - 2*far*size, cy - far*size},
+		[2]float64{cx + 2*far*size, cy - far*size},
+		[2]float64{cx, cy + 2*far*size},
+	)
+	bw.tris = append(bw.tris[:0], bwTriangle{v: [3]int{n, n + 1, n + 2}, n: [3]int{-1, -1, -1}})
+	bw.last = 0
+	for i := 0; i < n; i++ {
+		bw.insert(i)
+	}
+}
+
+func (bw *bowyerWatson) insert(ip int) {
+	p := bw.pts[ip]
+	t := bw.locate(p)
+	for _, v := range bw.tris[t].v {
+		if bw.pts[v] == p {
+			bw.dup[ip] = true
+			return
+		}
+	}
+	// Find cavity of triangles whose circumcircle contains p.
+	bw.stamp++
+	for len(bw.mark) < len(bw.tris) {
+		bw.mark = append(bw.mark, 0)
+	}
+	bw.cavity = append(bw.cavity[:0], t)
+	bw.mark[t] = bw.stamp
+	for i := 0; i < len(bw.cavity); i++ {
+		for _, nb := range bw.tris[bw.cavity[i]].n {
+			if nb >= 0 && bw.mark[nb] != bw.stamp && bw.inCircle(nb, p) {
+				bw.mark[nb] = bw.stamp
+				bw.cavity = append(bw.cavity, nb)
+			}
+		}
+	}
+	// Gather cavity boundary edges. They are counter-clockwise around the cavity.
+	bw.boundary = bw.boundary[:0]
+	for _, c := range bw.cavity {
+		tri := &bw.tris[c]
+		for k, nb := range tri.n {
+			if nb < 0 || bw.mark[nb] != bw.stamp {
+				bw.boundary = append(bw.boundary, bwEdge{a: tri.v[(k+1)%3], b: tri.v[(k+2)%3], outer: nb})
+			}
+		}
+		tri.dead = true
+	}
+	// Fan new triangles from p to the boundary edges.
+	first := len(bw.tris)
+	for _, e := range bw.boundary {
+		idx := len(bw.tris)
+		bw.tris = append(bw.tris, bwTriangle{v: [3]int{e.a, e.b, ip}, n: [3]int{-1, -1, e.outer}})
+		if e.outer >= 0 {
+			outer := &bw.tris[e.outer]
+			for k := range outer.n {
+				if outer.n[k] >= 0 && bw.tris[outer.n[k]].dead && outer.v[(k+1)%3] == e.b && outer.v[(k+2)%3] == e.a {
+					outer.n[k] = idx
+				}
+			}
+		}
+	}
+	// Link new triangles among themselves: triangle (a,b,p) neighbors (b,c,p) across edge b-p and (z,a,p) across edge p-a.
+	newTris := bw.tris[first:]
+	for i := range newTris {
+		for j := range newTris {
+			if newTris[j].v[0] == newTris[i].v[1] {
+				newTris[i].n[0] = first + j
+				newTris[j].n[1] = first + i
+			}
+		}
+	}
+	bw.last = first
+}
+
+// locate returns a live triangle containing p by walking from the last created triangle.
+func (bw *bowyerWatson) locate(p [2]float64) int {
+	t := bw.last
+walk:
+	for steps := 0; steps < len(bw.tris); steps++ {
+		tri := &bw.tris[t]
+		for k := 0; k < 3; k++ {
+			a, b := bw.pts[tri.v[(k+1)%3]], bw.pts[tri.v[(k+2)%3]]
+			if orient2(a, b, p) < 0 && tri.n[k] >= 0 {
+				t = tri.n[k]
+				continue walk
+			}
+		}
+		return t
+	}
+	// Walk failed due to degeneracies. Fall back to a linear search.
+	for i := range bw.tris {
+		tri := &bw.tris[i]
+		if !tri.dead && bw.inCircle(i, p) {
+			return i
+		}
+	}
+	return t
+}
+
+// inCircle reports whether p lies strictly inside the circumcircle of triangle t.
+func (bw *bowyerWatson) inCircle(t int, p [2]float64) bool {
+	v := bw.tris[t].v
+	a, b, c := bw.pts[v[0]], bw.pts[v[1]], bw.pts[v[2]]
+	adx, ady := a[0]-p[0], a[1]-p[1]
+	bdx, bdy := b[0]-p[0], b[1]-p[1]
+	cdx, cdy := c[0]-p[0], c[1]-p[1]
+	alift := adx*adx + ady*ady
+	blift := bdx*bdx + bdy*bdy
+	clift := cdx*cdx + cdy*cdy
+	det := alift*(bdx*cdy-cdx*bdy) + blift*(cdx*ady-adx*cdy) + clift*(adx*bdy-bdx*ady)
+	return det > 0
+}
+
+// orient2 returns a positive value if a, b, c are in counter-clockwise order,
+// negative if clockwise and zero if collinear.
+func orient2(a, b, c [2]float64) float64 {
+	return (b[0]-a[0])*(c[1]-a[1]) - (b[1]-a[1])*(c[0]-a[0])
+}
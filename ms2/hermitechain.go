@@ -0,0 +1,179 @@
+package ms2
+
+import (
+	math "github.com/chewxy/math32"
+)
+
+// Catmull-Rom parametrization exponents for use with [CatmullRomChain].
+const (
+	// CatmullRomUniform spaces knots uniformly. Equivalent to [SplineCatmullRom].
+	CatmullRomUniform = 0
+	// CatmullRomCentripetal spaces knots by the square root of the distance between points.
+	// It is guaranteed to not produce cusps nor self-intersections within a segment.
+	CatmullRomCentripetal = 0.5
+	// CatmullRomChordal spaces knots by the distance between points.
+	CatmullRomChordal = 1
+)
+
+// CatmullRomChain is a chain of non-uniform Catmull-Rom spline segments. Unlike [SplineCatmullRom]
+// the spacing between knots depends on the distance between points which avoids the cusps and
+// self-intersections uniform Catmull-Rom splines exhibit when points are unevenly spaced.
+//
+// Like [SplineChain] with [SplineCatmullRom] an open chain of n points has n-3 segments
+// interpolating the points from the second to the second to last.
+// Segments are converted to Hermite form, see [CatmullRomChain.Segment].
+type CatmullRomChain struct {
+	// Points are the interpolated points of the chain.
+	Points []Vec
+	// Alpha is the knot parametrization exponent. Knot intervals are given by |P[i+1]-P[i]|^Alpha.
+	// See [CatmullRomUniform], [CatmullRomCentripetal] and [CatmullRomChordal].
+	Alpha float32
+	// Closed makes the chain periodic joining the last point with the first one.
+	Closed bool
+}
+
+// NumSegments returns the number of spline segments in the chain.
+func (cr CatmullRomChain) NumSegments() int {
+	n := len(cr.Points)
+	if cr.Closed {
+		if n < 2 {
+			return 0
+		}
+		return n
+	} else if n < 4 {
+		return 0
+	}
+	return n - 3
+}
+
+// Segment returns the ith segment of the chain in Hermite form: start point, start velocity,
+// end point and end velocity. These can be evaluated with [SplineHermite] or sampled with [Spline3Sampler]. Segment panics if i is out of range.
+func (cr CatmullRomChain) Segment(i int) (p0, v0, p1, v1 Vec) {
+	if i < 0 || i >= cr.NumSegments() {
+		panic("spline chain segment out of range")
+	}
+	n := len(cr.Points)
+	var q0, q1, q2, q3 Vec
+	if cr.Closed {
+		q0, q1, q2, q3 = cr.Points[(i+n-1)%n], cr.Points[i], cr.Points[(i+1)%n], cr.Points[(i+2)%n]
+	} else {
+		q0, q1, q2, q3 = cr.Points[i], cr.Points[i+1], cr.Points[i+2], cr.Points[i+3]
+	}
+	// Knot intervals. Coincident points are handled by borrowing neighboring intervals.
+	dt0 := math.Pow(Norm(Sub(q1, q0)), cr.Alpha)
+	dt1 := math.Pow(Norm(Sub(q2, q1)), cr.Alpha)
+	dt2 := math.Pow(Norm(Sub(q3, q2)), cr.Alpha)
+	if dt1 < 1e-4 {
+		dt1 = 1
+	}
+	if dt0 < 1e-4 {
+		dt0 = dt1
+	}
+	if dt2 < 1e-4 {
+		dt2 = dt1
+	}
+	// Tangents of the non-uniform spline at q1 and q2, rescaled to the [0,1] segment parametrization.
+	m1 := Add(Sub(Scale(1/dt0, Sub(q1, q0)), Scale(1/(dt0+dt1), Sub(q2, q0))), Scale(1/dt1, Sub(q2, q1)))
+	m2 := Add(Sub(Scale(1/dt1, Sub(q2, q1)), Scale(1/(dt1+dt2), Sub(q3, q1))), Scale(1/dt2, Sub(q3, q2)))
+	return q1, Scale(dt1, m1), q2, Scale(dt1, m2)
+}
+
+// Evaluate evaluates the chain at global parameter t in [0, NumSegments]. t is clamped to this range.
+func (cr CatmullRomChain) Evaluate(t float32) Vec {
+	i, tl := chainLocal(cr.NumSegments(), t)
+	p0, v0, p1, v1 := cr.Segment(i)
+	return _hermite.Evaluate(tl, p0, v0, p1, v1)
+}
+
+// AppendSamples samples every segment of the chain with the bisection method of [Spline3Sampler.SampleBisect]
+// and appends the resulting points to dst. Points shared by consecutive segments are appended once.
+// Open chains include both chain extremes; closed chains do not repeat the starting point at the end.
+func (cr CatmullRomChain) AppendSamples(dst []Vec, tolerance float32, maxDepth int) []Vec {
+	return appendChainSamples(dst, _hermite, cr.NumSegments(), cr.Closed, cr.Segment, tolerance, maxDepth)
+}
+
+// KochanekBartelsKey is a key point of a [KochanekBartelsChain] with its TCB parameters.
+// All parameters set to zero result in a Catmull-Rom spline through the key.
+type KochanekBartelsKey struct {
+	Point Vec
+	// Tension changes the length of the tangent at the key. 1 produces a sharp corner, -1 a loose curve.
+	Tension float32
+	// Bias changes the direction of the tangent. 1 makes the curve overshoot in the direction of the previous key, -1 undershoot.
+	Bias float32
+	// Continuity changes the sharpness of the change between incoming and outgoing tangents. -1 produces a sharp corner, 1 a bulge.
+	Continuity float32
+}
+
+// KochanekBartelsChain is a chain of Kochanek-Bartels (TCB) spline segments which interpolates all its keys.
+// Each key can set the tension, bias and continuity of the curve passing through it. Segments are converted to Hermite form,
+// see [KochanekBartelsChain.Segment]. The ends of open chains are extended by reflecting the second and second to last keys.
+type KochanekBartelsChain struct {
+	Keys []KochanekBartelsKey
+	// Closed makes the chain periodic joining the last key with the first one.
+	Closed bool
+}
+
+// NumSegments returns the number of spline segments in the chain.
+func (kb KochanekBartelsChain) NumSegments() int {
+	n := len(kb.Keys)
+	if n < 2 {
+		return 0
+	} else if kb.Closed {
+		return n
+	}
+	return n - 1
+}
+
+// Segment returns the ith segment of the chain in Hermite form: start point, start velocity,
+// end point and end velocity. These can be evaluated with [SplineHermite] or sampled with [Spline3Sampler]. Segment panics if i is out of range.
+func (kb KochanekBartelsChain) Segment(i int) (p0, v0, p1, v1 Vec) {
+	if i < 0 || i >= kb.NumSegments() {
+		panic("spline chain segment out of range")
+	}
+	n := len(kb.Keys)
+	k0, k1 := kb.Keys[i], kb.Keys[(i+1)%n]
+	prev, next := kb.point(i-1), kb.point(i+2)
+	// Outgoing tangent of the start key.
+	a, b := k0.factors()
+	out := Add(Scale(a*(1+k0.Continuity), Sub(k0.Point, prev)), Scale(b*(1-k0.Continuity), Sub(k1.Point, k0.Point)))
+	// Incoming tangent of the end key.
+	a, b = k1.factors()
+	in := Add(Scale(a*(1-k1.Continuity), Sub(k1.Point, k0.Point)), Scale(b*(1+k1.Continuity), Sub(next, k1.Point)))
+	return k0.Point, out, k1.Point, in
+}
+
+// Evaluate evaluates the chain at global parameter t in [0, NumSegments]. t is clamped to this range.
+func (kb KochanekBartelsChain) Evaluate(t float32) Vec {
+	i, tl := chainLocal(kb.NumSegments(), t)
+	p0, v0, p1, v1 := kb.Segment(i)
+	return _hermite.Evaluate(tl, p0, v0, p1, v1)
+}
+
+// AppendSamples samples every segment of the chain with the bisection method of [Spline3Sampler.SampleBisect]
+// and appends the resulting points to dst. Points shared by consecutive segments are appended once.
+// Open chains include both chain extremes; closed chains do not repeat the starting point at the end.
+func (kb KochanekBartelsChain) AppendSamples(dst []Vec, tolerance float32, maxDepth int) []Vec {
+	return appendChainSamples(dst, _hermite, kb.NumSegments(), kb.Closed, kb.Segment, tolerance, maxDepth)
+}
+
+// point returns the key point at index i, wrapping around for closed chains
+// and reflecting about the extremes for open chains.
+func (kb KochanekBartelsChain) point(i int) Vec {
+	n := len(kb.Keys)
+	switch {
+	case kb.Closed:
+		return kb.Keys[(i+n)%n].Point
+	case i < 0:
+		return Sub(Scale(2, kb.Keys[0].Point), kb.Keys[1].Point)
+	case i >= n:
+		return Sub(Scale(2, kb.Keys[n-1].Point), kb.Keys[n-2].Point)
+	}
+	return kb.Keys[i].Point
+}
+
+// factors returns the tension and bias weights of the incoming and outgoing differences of the key.
+func (k KochanekBartelsKey) factors() (prevFactor, nextFactor float32) {
+	return (1 - k.Tension) * (1 + k.Bias) / 2, (1 - k.Tension) * (1 - k.Bias) / 2
+}
+
+var _hermite = SplineHermite()
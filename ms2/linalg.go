@@ -0,0 +1,79 @@
+package ms2
+
+import (
+	math "github.com/chewxy/math32"
+)
+
+// EigsSym returns the eigenvalues of a symmetric 2x2 matrix in descending order and
+// the rotation matrix whose columns are the corresponding unit eigenvectors:
+//
+//	a = vectors * Diagonal2(values.X, values.Y) * vectorsᵀ
+//
+// Only the lower triangle of a is used; the off-diagonal entry a₀₁ is assumed equal to a₁₀.
+// Applied to the covariance matrix of a point set, the first eigenvector is the principal axis.
+func (a Mat2) EigsSym() (values Vec, vectors Mat2) {
+	mean := (a.x00 + a.x11) / 2
+	half := (a.x00 - a.x11) / 2
+	r := math.Hypot(half, a.x10)
+	theta := math.Atan2(a.x10, half) / 2
+	return Vec{X: mean + r, Y: mean - r}, RotationMat2(theta)
+}
+
+// SVD performs singular value decomposition on a 2x2 matrix using a closed form:
+//
+//	a = U * S * Vᵀ
+//
+// U and V are rotation matrices and S is diagonal with |S₀₀| >= |S₁₁|. S₀₀ is non-negative and
+// S₁₁ is negative if a contains a reflection, i.e: if the determinant of a is negative.
+func (a Mat2) SVD() (U, S, V Mat2) {
+	e := (a.x00 + a.x11) / 2
+	f := (a.x00 - a.x11) / 2
+	g := (a.x10 + a.x01) / 2
+	h := (a.x10 - a.x01) / 2
+	q := math.Hypot(e, h)
+	r := math.Hypot(f, g)
+	a1 := math.Atan2(g, f)
+	a2 := math.Atan2(h, e)
+	theta := (a2 - a1) / 2
+	phi := (a2 + a1) / 2
+	return RotationMat2(phi), Diagonal2(q+r, q-r), RotationMat2(-theta)
+}
+
+// PolarDecomposition decomposes a 2x2 matrix into a rotation followed by a stretch:
+//
+//	a = rotation * stretch
+//
+// rotation is the rotation matrix closest to a and stretch is a symmetric matrix.
+// stretch is positive semi-definite if the determinant of a is non-negative.
+func (a Mat2) PolarDecomposition() (rotation, stretch Mat2) {
+	angle := math.Atan2(a.x10-a.x01, a.x00+a.x11)
+	rotation = RotationMat2(angle)
+	stretch = MulMat2(rotation.Transpose(), a)
+	// Enforce exact symmetry lost to rounding.
+	stretch.x01 = (stretch.x01 + stretch.x10) / 2
+	stretch.x10 = stretch.x01
+	return rotation, stretch
+}
+
+// QRDecomposition performs QR decomposition of a 2x2 matrix using a Givens rotation:
+//
+//	a = q * r
+//
+// q is a rotation matrix and r is upper triangular. If the first column of a is zero q is the identity.
+func (a Mat2) QRDecomposition() (q, r Mat2) {
+	norm := math.Hypot(a.x00, a.x10)
+	if norm == 0 {
+		return IdentityMat2(), a
+	}
+	c, s := a.x00/norm, a.x10/norm
+	q = Mat2{
+		c, -s,
+		s, c,
+	}
+	r = Mat2{
+		x00: norm,
+		x01: c*a.x01 + s*a.x11,
+		x11: c*a.x11 - s*a.x01,
+	}
+	return q, r
+}
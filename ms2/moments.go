@@ -0,0 +1,134 @@
+package ms2
+
+import (
+	math "github.com/chewxy/math32"
+)
+
+// AreaMoments holds the moments of area of a planar region about the coordinate axes, as used for
+// beam cross-sections and planar parts. All moments are signed: a region bounded counter-clockwise
+// has positive area while one bounded clockwise has negative moments, which is how holes are subtracted.
+type AreaMoments struct {
+	// Area is the signed area of the region.
+	Area float32
+	// Qx is the first moment of area about the x axis, the integral of y over the region.
+	Qx float32
+	// Qy is the first moment of area about the y axis, the integral of x over the region.
+	Qy float32
+	// Ix is the second moment of area about the x axis, the integral of y² over the region.
+	Ix float32
+	// Iy is the second moment of area about the y axis, the integral of x² over the region.
+	Iy float32
+	// Ixy is the product of inertia, the integral of x·y over the region.
+	Ixy float32
+}
+
+// RingAreaMoments returns the moments of area about the origin of the region bounded by a closed ring of vertices.
+// The moments are positive for counter-clockwise rings and negative for clockwise rings.
+func RingAreaMoments(ring []Vec) AreaMoments {
+	if len(ring) < 3 {
+		return AreaMoments{}
+	}
+	// Integrate relative to the first vertex to reduce cancellation for rings far from the origin.
+	origin := ring[0]
+	var m AreaMoments
+	prev := Vec{}
+	for _, v := range ring[1:] {
+		v = Sub(v, origin)
+		m.addEdge(prev, v)
+		prev = v
+	}
+	m.addEdge(prev, Vec{})
+	m.Area /= 2
+	m.Qx /= 6
+	m.Qy /= 6
+	m.Ix /= 12
+	m.Iy /= 12
+	m.Ixy /= 24
+	return m.About(Scale(-1, origin))
+}
+
+// addEdge accumulates the unscaled Green's theorem contributions of the edge from a to b.
+func (m *AreaMoments) addEdge(a, b Vec) {
+	cross := Cross(a, b)
+	m.Area += cross
+	m.Qx += (a.Y + b.Y) * cross
+	m.Qy += (a.X + b.X) * cross
+	m.Ix += (a.Y*a.Y + a.Y*b.Y + b.Y*b.Y) * cross
+	m.Iy += (a.X*a.X + a.X*b.X + b.X*b.X) * cross
+	m.Ixy += (a.X*b.Y + 2*a.X*a.Y + 2*b.X*b.Y + b.X*a.Y) * cross
+}
+
+// AreaMoments returns the moments of area about the origin of the shape, the sum of the moments of its rings.
+// Holes are subtracted by their orientation so the shape should be normalized, see [Shape.Normalize].
+func (s Shape) AreaMoments() AreaMoments {
+	var m AreaMoments
+	for _, ring := range s.Rings {
+		rm := RingAreaMoments(ring)
+		m.Area += rm.Area
+		m.Qx += rm.Qx
+		m.Qy += rm.Qy
+		m.Ix += rm.Ix
+		m.Iy += rm.Iy
+		m.Ixy += rm.Ixy
+	}
+	return m
+}
+
+// Centroid returns the centroid of the region. The result is not finite for a region of zero area.
+func (m AreaMoments) Centroid() Vec {
+	return Vec{X: m.Qy / m.Area, Y: m.Qx / m.Area}
+}
+
+// About returns the moments of area about the axes parallel to the coordinate axes that pass through p,
+// using the parallel axis theorem.
+func (m AreaMoments) About(p Vec) AreaMoments {
+	return AreaMoments{
+		Area: m.Area,
+		Qx:   m.Qx - p.Y*m.Area,
+		Qy:   m.Qy - p.X*m.Area,
+		Ix:   m.Ix - 2*p.Y*m.Qx + p.Y*p.Y*m.Area,
+		Iy:   m.Iy - 2*p.X*m.Qy + p.X*p.X*m.Area,
+		Ixy:  m.Ixy - p.X*m.Qx - p.Y*m.Qy + p.X*p.Y*m.Area,
+	}
+}
+
+// Centroidal returns the moments of area about the axes parallel to the coordinate axes that pass through the centroid.
+// The first moments of the result are zero.
+func (m AreaMoments) Centroidal() AreaMoments {
+	c := m.Centroid()
+	return AreaMoments{
+		Area: m.Area,
+		Ix:   m.Ix - c.Y*m.Qx,
+		Iy:   m.Iy - c.X*m.Qy,
+		Ixy:  m.Ixy - c.X*m.Qx,
+	}
+}
+
+// Polar returns the polar moment of area about the origin of the moments, the integral of x²+y² over the region.
+// Call it on [AreaMoments.Centroidal] for the polar moment about the centroid.
+func (m AreaMoments) Polar() float32 {
+	return m.Ix + m.Iy
+}
+
+// InertiaTensor returns the symmetric second moment of area tensor J about the origin of the moments.
+// The second moment of area about an axis through the origin with unit direction u is uᵀ*J*u.
+func (m AreaMoments) InertiaTensor() Mat2 {
+	return Mat2{
+		x00: m.Ix, x01: -m.Ixy,
+		x10: -m.Ixy, x11: m.Iy,
+	}
+}
+
+// PrincipalAxes returns the principal second moments of area about the centroid in descending order and
+// the rotation matrix whose columns are the corresponding principal axis directions. The product of inertia
+// about the principal axes is zero. See [Mat2.EigsSym].
+func (m AreaMoments) PrincipalAxes() (moments Vec, axes Mat2) {
+	return m.Centroidal().InertiaTensor().EigsSym()
+}
+
+// PrincipalAngle returns the angle in radians from the x axis to the principal axis about the centroid with
+// the largest second moment of area, in the range [-π/2, π/2].
+func (m AreaMoments) PrincipalAngle() float32 {
+	c := m.Centroidal()
+	return math.Atan2(-c.Ixy, (c.Ix-c.Iy)/2) / 2
+}
@@ -18,12 +18,21 @@ func (cperr *cpAtIdxErr) Error() string {
 	return "PolygonBuilder control_point[" + strconv.Itoa(cperr.idx) + "]: " + cperr.msg.Error()
 }
 
-const arcTol = 5e-1
+const (
+	arcTol = 5e-1
+	// defaultSplineTol is the spline discretization tolerance used when PolygonBuilder.SplineTolerance is zero.
+	defaultSplineTol = 1e-2
+	// splineMaxDepth limits spline edge discretization to 2**splineMaxDepth vertices.
+	splineMaxDepth = 10
+)
 
-// PolygonBuilder facilitates polygon construction with arcs, smoothing and chamfers
+// PolygonBuilder facilitates polygon construction with arcs, spline edges, smoothing and chamfers
 // with the [PolygonControlPoint] type.
 type PolygonBuilder struct {
 	verts []PolygonControlPoint
+	// SplineTolerance is the maximum permissible distance between spline edges and their discretization
+	// in [PolygonBuilder.AppendVecs]. If zero a tolerance of 0.01 is used.
+	SplineTolerance float32
 }
 
 // PolygonControlPoint represents a polygon point joined by two edges, or alternatively
@@ -31,11 +40,24 @@ type PolygonBuilder struct {
 // It is used by the [PolygonBuilder] type and notably returned by the Add* methods
 // so that the user may control the polygon's shape. By default represents a vertex joining two other neighboring vertices.
 type PolygonControlPoint struct {
-	v      Vec     // Absolute vertex position.
-	radius float32 // Smoothing radius, if zero then no smoothing.
-	facets int32   // Amount of facets to create when smoothing. If negative indicates arcing instead of smoothing.
+	v       Vec     // Absolute vertex position.
+	radius  float32 // Smoothing radius, if zero then no smoothing.
+	facets  int32   // Amount of facets to create when smoothing. If negative indicates arcing instead of smoothing.
+	chamfer float32 // Chamfer size if the smoothing was set by Chamfer, kept for encoding.
+	edge    edgeKind
+	h0, h1  Vec // Absolute Bézier control handle positions of the edge reaching this vertex.
 }
 
+// edgeKind is the kind of curve of the edge reaching a control point from the previous control point.
+type edgeKind uint8
+
+const (
+	edgeLine edgeKind = iota // Straight edge or arc.
+	edgeBezierQuadratic
+	edgeBezierCubic
+	edgeCatmullRom
+)
+
 // Nagon sets the vertices of p to that of a N sided regular polygon. If n<3 then Nagon does nothing.
 func (p *PolygonBuilder) Nagon(n int, centerDistance float32) {
 	p.NagonSmoothed(n, centerDistance, 0, 0)
@@ -114,13 +136,19 @@ func (p *PolygonBuilder) IsClockwise() bool {
 		return false
 	}
 	vPrev := p.verts[len(p.verts)-1].v
-	var windingSum float32
+	var sum float32
 	for i := 0; i < len(p.verts); i++ {
 		v := p.verts[i].v
-		windingSum += (v.X - vPrev.X) * (v.Y + vPrev.Y)
+		sum += windingTerm(vPrev, v)
 		vPrev = v
 	}
-	return windingSum < 0
+	return sum < 0
+}
+
+// windingTerm returns the contribution of edge v0->v1 to the winding sum of a ring,
+// which is equal to minus twice the ring's signed area.
+func windingTerm(v0, v1 Vec) float32 {
+	return (v1.X - v0.X) * (v1.Y + v0.Y)
 }
 
 // AppendVecs appends the Polygon's discretized representation to the argument Vec buffer and returns the result.
@@ -139,6 +167,8 @@ func (p *PolygonBuilder) AppendVecs(buf []Vec) ([]Vec, error) {
 		if current.isArc() {
 			buf, err = appendArc2points(buf, prev.v, current.v, current.radius, -current.facets)
 			buf = append(buf, current.v)
+		} else if current.edge != edgeLine {
+			buf = p.appendSplineEdge(buf, i)
 		} else if current.isSmoothed() {
 			next := p.verts[(i+1)%len(p.verts)]
 			buf, err = appendSmoothedCorner(buf, prev.v, current.v, next.v, current.radius, current.facets)
@@ -153,6 +183,30 @@ func (p *PolygonBuilder) AppendVecs(buf []Vec) ([]Vec, error) {
 	return buf, nil
 }
 
+// appendSplineEdge appends the discretized spline edge reaching the ith control point, ending with the control point itself.
+func (p *PolygonBuilder) appendSplineEdge(buf []Vec, i int) []Vec {
+	n := len(p.verts)
+	current := p.verts[i]
+	prev := p.verts[(i+n-1)%n]
+	sampler := Spline3Sampler{Tolerance: p.SplineTolerance}
+	if sampler.Tolerance <= 0 {
+		sampler.Tolerance = defaultSplineTol
+	}
+	switch current.edge {
+	case edgeBezierQuadratic:
+		sampler.Spline = SplineBezierQuadratic()
+		sampler.SetSplinePoints(prev.v, current.h0, current.v, current.v)
+	case edgeBezierCubic:
+		sampler.Spline = SplineBezierCubic()
+		sampler.SetSplinePoints(prev.v, current.h0, current.h1, current.v)
+	case edgeCatmullRom:
+		sampler.Spline = SplineCatmullRom()
+		sampler.SetSplinePoints(p.verts[(i+n-2)%n].v, prev.v, current.v, p.verts[(i+1)%n].v)
+	}
+	buf = sampler.SampleBisect(buf, splineMaxDepth)
+	return append(buf, current.v)
+}
+
 func (p *PolygonBuilder) last() *PolygonControlPoint {
 	if len(p.verts) > 0 {
 		return &p.verts[len(p.verts)-1]
@@ -161,10 +215,13 @@ func (p *PolygonBuilder) last() *PolygonControlPoint {
 }
 
 // Smooth smoothes this polygon vertex by a radius and discretises the smoothing in facets.
+// Smoothing requires straight edges: it replaces a spline edge reaching the control point, see [PolygonControlPoint.BezierQuadratic].
 func (v *PolygonControlPoint) Smooth(radius float32, facets int) {
 	if radius > 0 && facets > 0 {
 		v.radius = radius
 		v.facets = int32(facets)
+		v.chamfer = 0
+		v.edge = edgeLine
 	}
 }
 
@@ -177,8 +234,43 @@ func (v *PolygonControlPoint) Arc(radius float32, facets int) {
 	if radius != 0 && facets > 0 {
 		v.radius = radius
 		v.facets = -int32(facets)
+		v.chamfer = 0
+		v.edge = edgeLine
 	}
 }
+
+// BezierQuadratic makes the edge between the previous control point and this one a quadratic Bézier
+// curve with a control handle in absolute coordinates. It replaces an arc set with [PolygonControlPoint.Arc]
+// and smoothing set with [PolygonControlPoint.Smooth] or [PolygonControlPoint.Chamfer].
+func (v *PolygonControlPoint) BezierQuadratic(handle Vec) {
+	v.clearCorner()
+	v.edge = edgeBezierQuadratic
+	v.h0 = handle
+}
+
+// BezierCubic makes the edge between the previous control point and this one a cubic Bézier curve
+// with control handles in absolute coordinates. handle0 shapes the curve leaving the previous control point
+// and handle1 the curve reaching this one. It replaces an arc set with [PolygonControlPoint.Arc]
+// and smoothing set with [PolygonControlPoint.Smooth] or [PolygonControlPoint.Chamfer].
+func (v *PolygonControlPoint) BezierCubic(handle0, handle1 Vec) {
+	v.clearCorner()
+	v.edge = edgeBezierCubic
+	v.h0, v.h1 = handle0, handle1
+}
+
+// CatmullRom makes the edge between the previous control point and this one a Catmull-Rom spline segment,
+// shaped by the control points before the previous one and after this one. Consecutive Catmull-Rom
+// control points form a smooth curve passing through them. It replaces an arc set with [PolygonControlPoint.Arc]
+// and smoothing set with [PolygonControlPoint.Smooth] or [PolygonControlPoint.Chamfer].
+func (v *PolygonControlPoint) CatmullRom() {
+	v.clearCorner()
+	v.edge = edgeCatmullRom
+}
+
+// clearCorner removes the arc or smoothing of the control point.
+func (v *PolygonControlPoint) clearCorner() {
+	v.radius, v.facets, v.chamfer = 0, 0, 0
+}
 func (v *PolygonControlPoint) isSmoothed() bool { return v.facets > 0 && v.radius > 0 }
 func (v *PolygonControlPoint) isArc() bool      { return v.facets < 0 && v.radius != 0 }
 
@@ -190,6 +282,9 @@ const sqrtHalf = math.Sqrt2 / 2
 // Chamfer is a smoothing of a single facet of length `size`.
 func (v *PolygonControlPoint) Chamfer(size float32) {
 	v.Smooth(size*sqrtHalf, 1)
+	if size > 0 {
+		v.chamfer = size
+	}
 }
 
 func appendArc2points(dst []Vec, p1, p2 Vec, r float32, facets int32) ([]Vec, error) {
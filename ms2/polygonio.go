@@ -0,0 +1,355 @@
+package ms2
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// floatBits is the size in bits of the floating point type used for formatting and parsing.
+// gen.go replaces it for the 64-bit package.
+const floatBits = 32
+
+type polygonLineErr struct {
+	line int
+	msg  error
+}
+
+func (lerr *polygonLineErr) Error() string {
+	return "PolygonBuilder line " + strconv.Itoa(lerr.line) + ": " + lerr.msg.Error()
+}
+
+var (
+	errNoControlPoint  = errors.New("no control point to modify")
+	errBadSmoothArgs   = errors.New("smoothing requires positive radius and facets")
+	errBadArcArgs      = errors.New("arc requires non-zero radius and positive facets")
+	errBadChamferArgs  = errors.New("chamfer requires positive size")
+	errSmoothAndArc    = errors.New("control point cannot be arc and smoothed")
+	errSmoothAndSpline = errors.New("control point cannot be smoothed and reached by a spline edge")
+	errEdgeKinds       = errors.New("control point has more than one edge kind")
+)
+
+// MarshalText encodes the control points of the builder in a line oriented text format, one control point per line.
+// The format is described in [PolygonBuilder.UnmarshalText].
+func (p PolygonBuilder) MarshalText() ([]byte, error) {
+	var b []byte
+	if p.SplineTolerance != 0 {
+		b = append(b, "tol "...)
+		b = appendFloat(b, p.SplineTolerance)
+		b = append(b, '\n')
+	}
+	for _, cp := range p.verts {
+		b = append(b, "xy "...)
+		b = appendFloats(b, cp.v.X, cp.v.Y)
+		switch {
+		case cp.isArc():
+			b = append(b, "; arc "...)
+			b = appendFloat(b, cp.radius)
+			b = append(b, ' ')
+			b = strconv.AppendInt(b, int64(-cp.facets), 10)
+		case cp.isSmoothed() && cp.chamfer > 0:
+			b = append(b, "; chamfer "...)
+			b = appendFloat(b, cp.chamfer)
+		case cp.isSmoothed():
+			b = append(b, "; smooth "...)
+			b = appendFloat(b, cp.radius)
+			b = append(b, ' ')
+			b = strconv.AppendInt(b, int64(cp.facets), 10)
+		}
+		switch cp.edge {
+		case edgeBezierQuadratic:
+			b = append(b, "; quad "...)
+			b = appendFloats(b, cp.h0.X, cp.h0.Y)
+		case edgeBezierCubic:
+			b = append(b, "; cubic "...)
+			b = appendFloats(b, cp.h0.X, cp.h0.Y, cp.h1.X, cp.h1.Y)
+		case edgeCatmullRom:
+			b = append(b, "; catmullrom"...)
+		}
+		b = append(b, '\n')
+	}
+	return b, nil
+}
+
+// UnmarshalText replaces the control points of the builder with those decoded from a line oriented text format.
+// Each statement is a command followed by space separated numeric arguments. Statements are separated by
+// newlines or semicolons and text following a '#' is a comment. Commands that modify a control point apply
+// to the last control point added. Errors report the offending line number. The commands are:
+//
+//	tol TOLERANCE         set the builder's SplineTolerance
+//	xy X Y                add a control point in absolute coordinates
+//	rel DX DY             add a control point relative to the last control point
+//	polar R THETA         add a control point in absolute polar coordinates, THETA in radians
+//	smooth RADIUS FACETS  smooth the control point, see [PolygonControlPoint.Smooth]
+//	chamfer SIZE          chamfer the control point, see [PolygonControlPoint.Chamfer]
+//	arc RADIUS FACETS     arc the edge reaching the control point, see [PolygonControlPoint.Arc]
+//	quad HX HY            quadratic Bézier edge reaching the control point, see [PolygonControlPoint.BezierQuadratic]
+//	cubic H0X H0Y H1X H1Y cubic Bézier edge reaching the control point, see [PolygonControlPoint.BezierCubic]
+//	catmullrom            Catmull-Rom edge reaching the control point, see [PolygonControlPoint.CatmullRom]
+//
+// For example, a rounded rectangle with a chamfered corner:
+//
+//	xy 0 0; smooth 1 6
+//	rel 10 0; chamfer 1
+//	rel 0 5; smooth 1 6
+//	rel -10 0; smooth 1 6
+func (p *PolygonBuilder) UnmarshalText(text []byte) error {
+	var parsed PolygonBuilder
+	scanner := bufio.NewScanner(bytes.NewReader(text))
+	line := 0
+	for scanner.Scan() {
+		line++
+		stmts := scanner.Text()
+		if idx := strings.IndexByte(stmts, '#'); idx >= 0 {
+			stmts = stmts[:idx]
+		}
+		for _, stmt := range strings.Split(stmts, ";") {
+			fields := strings.Fields(stmt)
+			if len(fields) == 0 {
+				continue
+			}
+			err := parsed.parseStatement(fields[0], fields[1:])
+			if err != nil {
+				return &polygonLineErr{line: line, msg: err}
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	p.verts = append(p.verts[:0], parsed.verts...)
+	p.SplineTolerance = parsed.SplineTolerance
+	return nil
+}
+
+// polygonCmdArgs is the amount of arguments of each text format command.
+var polygonCmdArgs = map[string]int{
+	"tol": 1, "xy": 2, "rel": 2, "polar": 2, "smooth": 2, "chamfer": 1,
+	"arc": 2, "quad": 2, "cubic": 4, "catmullrom": 0,
+}
+
+func (p *PolygonBuilder) parseStatement(cmd string, args []string) error {
+	want, ok := polygonCmdArgs[cmd]
+	if !ok {
+		return errors.New("unknown command " + strconv.Quote(cmd))
+	} else if len(args) != want {
+		return errors.New(cmd + " expects " + strconv.Itoa(want) + " arguments, got " + strconv.Itoa(len(args)))
+	}
+	var v [4]float32
+	for i, arg := range args {
+		f, err := strconv.ParseFloat(arg, floatBits)
+		if err != nil {
+			return errors.New(cmd + " argument " + strconv.Quote(arg) + " is not a number")
+		}
+		v[i] = float32(f)
+	}
+	switch cmd {
+	case "tol":
+		if v[0] < 0 {
+			return errors.New("negative spline tolerance")
+		}
+		p.SplineTolerance = v[0]
+		return nil
+	case "xy":
+		p.AddXY(v[0], v[1])
+		return nil
+	case "rel":
+		p.AddRelativeXY(v[0], v[1])
+		return nil
+	case "polar":
+		p.AddPolarRTheta(v[0], v[1])
+		return nil
+	}
+	cp := p.Last()
+	if cp == nil {
+		return errNoControlPoint
+	}
+	switch cmd {
+	case "smooth":
+		return cp.setSmooth(v[0], v[1])
+	case "chamfer":
+		return cp.setChamfer(v[0])
+	case "arc":
+		return cp.setArc(v[0], v[1])
+	}
+	if err := cp.splineErr(); err != nil {
+		return err
+	}
+	switch cmd {
+	case "quad":
+		cp.BezierQuadratic(Vec{X: v[0], Y: v[1]})
+	case "cubic":
+		cp.BezierCubic(Vec{X: v[0], Y: v[1]}, Vec{X: v[2], Y: v[3]})
+	case "catmullrom":
+		cp.CatmullRom()
+	}
+	return nil
+}
+
+// polygonJSON is the JSON representation of a [PolygonBuilder].
+type polygonJSON struct {
+	SplineTolerance float32            `json:"spline_tolerance,omitempty"`
+	Points          []controlPointJSON `json:"points"`
+}
+
+// controlPointJSON is the JSON representation of a [PolygonControlPoint].
+type controlPointJSON struct {
+	X          float32     `json:"x"`
+	Y          float32     `json:"y"`
+	Smooth     *facetsJSON `json:"smooth,omitempty"`
+	Chamfer    *float32    `json:"chamfer,omitempty"`
+	Arc        *facetsJSON `json:"arc,omitempty"`
+	Quadratic  *[2]float32 `json:"quadratic,omitempty"`
+	Cubic      *[4]float32 `json:"cubic,omitempty"`
+	CatmullRom bool        `json:"catmull_rom,omitempty"`
+}
+
+type facetsJSON struct {
+	Radius float32 `json:"radius"`
+	Facets int     `json:"facets"`
+}
+
+// MarshalJSON encodes the control points of the builder as JSON. Control points are encoded as objects
+// with their absolute coordinates and optional "smooth" and "arc" objects with radius and facets,
+// a "chamfer" size, a "quadratic" or "cubic" array of Bézier handle coordinates and a "catmull_rom" boolean.
+func (p PolygonBuilder) MarshalJSON() ([]byte, error) {
+	pj := polygonJSON{SplineTolerance: p.SplineTolerance, Points: make([]controlPointJSON, len(p.verts))}
+	for i, cp := range p.verts {
+		cj := &pj.Points[i]
+		cj.X, cj.Y = cp.v.X, cp.v.Y
+		switch {
+		case cp.isArc():
+			cj.Arc = &facetsJSON{Radius: cp.radius, Facets: int(-cp.facets)}
+		case cp.isSmoothed() && cp.chamfer > 0:
+			chamfer := cp.chamfer
+			cj.Chamfer = &chamfer
+		case cp.isSmoothed():
+			cj.Smooth = &facetsJSON{Radius: cp.radius, Facets: int(cp.facets)}
+		}
+		switch cp.edge {
+		case edgeBezierQuadratic:
+			cj.Quadratic = &[2]float32{cp.h0.X, cp.h0.Y}
+		case edgeBezierCubic:
+			cj.Cubic = &[4]float32{cp.h0.X, cp.h0.Y, cp.h1.X, cp.h1.Y}
+		case edgeCatmullRom:
+			cj.CatmullRom = true
+		}
+	}
+	return json.Marshal(pj)
+}
+
+// UnmarshalJSON replaces the control points of the builder with those decoded from JSON
+// in the format produced by [PolygonBuilder.MarshalJSON].
+func (p *PolygonBuilder) UnmarshalJSON(data []byte) error {
+	var pj polygonJSON
+	err := json.Unmarshal(data, &pj)
+	if err != nil {
+		return err
+	} else if pj.SplineTolerance < 0 {
+		return errors.New("negative spline tolerance")
+	}
+	var parsed PolygonBuilder
+	parsed.SplineTolerance = pj.SplineTolerance
+	for i, cj := range pj.Points {
+		cp := parsed.AddXY(cj.X, cj.Y)
+		nEdges := 0
+		if cj.Quadratic != nil {
+			nEdges++
+			cp.BezierQuadratic(Vec{X: cj.Quadratic[0], Y: cj.Quadratic[1]})
+		}
+		if cj.Cubic != nil {
+			nEdges++
+			cp.BezierCubic(Vec{X: cj.Cubic[0], Y: cj.Cubic[1]}, Vec{X: cj.Cubic[2], Y: cj.Cubic[3]})
+		}
+		if cj.CatmullRom {
+			nEdges++
+			cp.CatmullRom()
+		}
+		if cj.Arc != nil {
+			nEdges++
+			err = cp.setArc(cj.Arc.Radius, float32(cj.Arc.Facets))
+		}
+		if nEdges > 1 {
+			err = errEdgeKinds
+		} else if err == nil && cj.Smooth != nil && cj.Chamfer != nil {
+			err = errors.New("control point cannot be smoothed and chamfered")
+		} else if err == nil && cj.Smooth != nil {
+			err = cp.setSmooth(cj.Smooth.Radius, float32(cj.Smooth.Facets))
+		} else if err == nil && cj.Chamfer != nil {
+			err = cp.setChamfer(*cj.Chamfer)
+		}
+		if err != nil {
+			return &cpAtIdxErr{idx: i, msg: err}
+		}
+	}
+	p.verts = append(p.verts[:0], parsed.verts...)
+	p.SplineTolerance = parsed.SplineTolerance
+	return nil
+}
+
+// setSmooth is like [PolygonControlPoint.Smooth] but returns an error on invalid arguments.
+func (v *PolygonControlPoint) setSmooth(radius, facets float32) error {
+	if radius <= 0 || facets < 1 || facets != float32(int32(facets)) {
+		return errBadSmoothArgs
+	} else if v.isArc() {
+		return errSmoothAndArc
+	} else if v.edge != edgeLine {
+		return errSmoothAndSpline
+	}
+	v.Smooth(radius, int(facets))
+	return nil
+}
+
+// setChamfer is like [PolygonControlPoint.Chamfer] but returns an error on invalid arguments.
+func (v *PolygonControlPoint) setChamfer(size float32) error {
+	if size <= 0 {
+		return errBadChamferArgs
+	} else if v.isArc() {
+		return errSmoothAndArc
+	} else if v.edge != edgeLine {
+		return errSmoothAndSpline
+	}
+	v.Chamfer(size)
+	return nil
+}
+
+// setArc is like [PolygonControlPoint.Arc] but returns an error on invalid arguments.
+func (v *PolygonControlPoint) setArc(radius, facets float32) error {
+	if radius == 0 || facets < 1 || facets != float32(int32(facets)) {
+		return errBadArcArgs
+	} else if v.isSmoothed() {
+		return errSmoothAndArc
+	} else if v.edge != edgeLine {
+		return errEdgeKinds
+	}
+	v.Arc(radius, int(facets))
+	return nil
+}
+
+// splineErr returns an error if the control point is smoothed or already has an arc or spline edge,
+// which setting a spline edge would silently replace.
+func (v *PolygonControlPoint) splineErr() error {
+	if v.isSmoothed() {
+		return errSmoothAndSpline
+	} else if v.isArc() || v.edge != edgeLine {
+		return errEdgeKinds
+	}
+	return nil
+}
+
+func appendFloat(b []byte, f float32) []byte {
+	return strconv.AppendFloat(b, float64(f), 'g', -1, floatBits)
+}
+
+func appendFloats(b []byte, fs ...float32) []byte {
+	for i, f := range fs {
+		if i > 0 {
+			b = append(b, ' ')
+		}
+		b = appendFloat(b, f)
+	}
+	return b
+}
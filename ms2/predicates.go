@@ -0,0 +1,47 @@
+package ms2
+
+import "github.com/soypat/geometry/internal"
+
+// Orient2D returns the exact orientation of the points a, b and c:
+//   - 1 if a, b and c occur in counter-clockwise order
+//   - -1 if they occur in clockwise order
+//   - 0 if they are collinear
+//
+// Unlike floating point cross products the result is exact, which makes it suitable for algorithms that rely
+// on consistent answers near degeneracy. Exactness requires that products of coordinates neither overflow nor
+// underflow double precision, which holds for all finite single precision inputs. Double precision coordinates
+// large enough to overflow, around 1e150 in magnitude, may give wrong results such as 0.
+func Orient2D(a, b, c Vec) int {
+	return sign(internal.Orient2(
+		float64(a.X), float64(a.Y),
+		float64(b.X), float64(b.Y),
+		float64(c.X), float64(c.Y),
+	))
+}
+
+// InCircle returns exactly where the point d lies relative to the circle passing through a, b and c:
+//   - 1 if d lies inside the circle
+//   - -1 if d lies outside the circle
+//   - 0 if the four points are cocircular
+//
+// a, b and c must be in counter-clockwise order, see [Orient2D]. If they are in clockwise order the sign is reversed.
+// The result is exact for finite single precision inputs and for double precision inputs whose products
+// of up to four coordinates neither overflow nor underflow.
+func InCircle(a, b, c, d Vec) int {
+	return sign(internal.InCircle(
+		float64(a.X), float64(a.Y),
+		float64(b.X), float64(b.Y),
+		float64(c.X), float64(c.Y),
+		float64(d.X), float64(d.Y),
+	))
+}
+
+func sign(v float64) int {
+	switch {
+	case v > 0:
+		return 1
+	case v < 0:
+		return -1
+	}
+	return 0
+}
@@ -0,0 +1,220 @@
+package ms2
+
+import (
+	"github.com/soypat/geometry/i2"
+)
+
+// Quadtree implements heapless 2D spatial Quadtree algorithms. It is the 2D counterpart of ms3.Octree.
+type Quadtree struct {
+	// Resolution is the size of the smallest square in the Quadtree. [i2.Square].Lvl==0.
+	Resolution float32
+	// Origin represents the position of the first square's first corner (most negative/smallest corner).
+	Origin Vec
+}
+
+// DecomposeDFS decomposes isquares from the end of squares into their quadtree sub-isquares
+// and appends them to the squares buffer, resulting in a depth-first traversal (DFS) of the quadtree.
+// This way squares will contain the largest squares at the start (low index) and the smallest squares at the end (high index).
+// Squares that reach the smallest size will be consumed and their 2D corners appended to dst. Smallest size squares do not decompose into more isquares.
+// squares with level of zero are discarded and no action is taken.
+//
+// The isquare decomposition continues until one or more of the following conditions are met:
+//   - Smallest square size is reached and the capacity in 2D dst can't store a resolution sized isquare corners, calculated as cap(dst)-len(dst) < 16.
+//   - Need to decompose a isquare to more isquares but capacity of squares buffer not enough to store a quadtree decomposition, calculated as cap(squares)-len(squares) < 4.
+//   - squares buffer has been fully consumed and is empty, calculated as len(squares) == 0.
+//
+// This algorithm is HEAPLESS: this means dst and squares buffer capacities are not modified.
+func (qt Quadtree) DecomposeDFS(dst []Vec, squares []i2.Square) ([]Vec, []i2.Square) {
+	for len(squares) > 0 {
+		lastIdx := len(squares) - 1
+		square := squares[lastIdx]
+		if square.Level == 0 {
+			// Square has been moved to prune queue. Discard and keep going.
+			squares = squares[:lastIdx]
+			continue
+		}
+		if square.IsSecondSmallest() {
+			// Is base-level square.
+			if cap(dst)-len(dst) < 4*4 {
+				break // No space for position buffering.
+			}
+			subsquares := square.Quadtree()
+			for _, ssquare := range subsquares {
+				corners := qt.SquareCorners(ssquare, qt.Resolution)
+				dst = append(dst, corners[:]...)
+			}
+			squares = squares[:lastIdx] // Trim square used.
+
+		} else {
+			// Is square with sub-squares.
+			if cap(squares)-len(squares) < 4 {
+				break // No more space for square buffering.
+			}
+			subsquares := square.Quadtree()
+			// We trim off the last square which we just processed in append.
+			squares = append(squares[:lastIdx], subsquares[:]...)
+		}
+	}
+	return dst, squares
+}
+
+// DecomposeBFS decomposes start into quadtree squares and appends them to dst without surpassing dst's slice capacity
+// and continues to decompose the resulting squares until all squares are minimumDecomposedLvl or dst capacity reached.
+// Smallest squares will remain at the highest index of dst. The boolean value returned indicates whether the
+// argument start isquare was able to be decomposed and its children added to dst.
+func (qt Quadtree) DecomposeBFS(dst []i2.Square, start i2.Square, minimumDecomposedLvl int) ([]i2.Square, bool) {
+	if minimumDecomposedLvl < 1 {
+		panic("bad minimumDecomposedLvl")
+	}
+	if cap(dst) < 4 {
+		return dst, false // No space to decompose new squares.
+	} else if start.Level <= minimumDecomposedLvl {
+		return dst, false // Square already fully decomposed.
+	}
+
+	subSquares := start.Quadtree()
+	startIdx := len(dst)
+	firstIdx := len(dst)
+	dst = append(dst, subSquares[:]...) // Squares will be of at minimum minLvl-1
+	for cap(dst)-len(dst) >= 4 {
+		// Decompose and append squares.
+		square := dst[firstIdx]
+		if square.Level <= minimumDecomposedLvl {
+			// Reached square of minimum prunable level.
+			break
+		}
+		subSquares := square.Quadtree()
+		// Is square with sub-squares.
+		// We trim off the last square which we just processed in append.
+		dst = append(dst, subSquares[:]...)
+		firstIdx++
+	}
+	// Move squares to start of buffer from where we started consuming them.
+	n := copy(dst[startIdx:], dst[firstIdx:])
+	dst = dst[:startIdx+n]
+	return dst, true
+}
+
+// SafeMove appends squares from the end of src to dst while taking care
+// not to leave dst without space to decompose to smallest square level using DFS.
+// Squares appended to dst from src are removed from src.
+func (qt Quadtree) SafeMove(dst, src []i2.Square) (newDst, newSrc []i2.Square) {
+	if len(src) == 0 {
+		return dst, src
+	}
+	// Calculate amount of squares that would be generated in DFS of the first square of src.
+	srcGenSquares := 4 * (src[0].Level + 1)
+	neededSpace := 1 + srcGenSquares // plus one for appended square.
+	// Calculate free space in dst after squares generated by 1 decomposition+append.
+	free := cap(dst) - neededSpace
+	trimIdx := max(0, len(src)-free)
+	prevCap := cap(dst)
+	dst = append(dst, src[trimIdx:]...)
+	if cap(dst) != prevCap {
+		panic("heapless promise broken")
+	}
+	src = src[:trimIdx]
+	return dst, src
+}
+
+// SafeSpread takes squares with Lvl>0 from end of src and "spreads" them over dstWithLvl0 square buffer taking special care so that the buffer can still be decomposed to smallest squares.
+// The buffer dstWithLvl0 is considered to have exactly numLvl0 squares with Lvl==0 anywhere within. These Lvl==0 squares will be replaced
+// with src squares first.
+// src must not contain zero leveled squares.
+func (qt Quadtree) SafeSpread(dstWithLvl0, src []i2.Square, numLvl0 int) (newDst, newSrc []i2.Square, newNumLvl0 int) {
+	if len(src) == 0 || numLvl0 == 0 || len(dstWithLvl0) == 0 {
+		return dstWithLvl0, src, numLvl0 // No work to do.
+	}
+	srcIdx := len(src) - 1 // Start appending from end of src.
+	square := src[srcIdx]
+	neededSpace := 4*square.Level + 1
+	for i := 0; numLvl0 > 0 && i < len(dstWithLvl0); i++ {
+		free := cap(dstWithLvl0) - i
+		if free < neededSpace {
+			break // If we add this square we'd overflow the target buffer upon DFS decomposition, so don't.
+		}
+		// Look for zero level squares (invalid/empty/discarded).
+		if dstWithLvl0[i].Level != 0 {
+			continue
+		} else if square.Level == 0 {
+			panic("bad src square in quadtreeSafeSpread")
+		}
+		dstWithLvl0[i] = square
+		numLvl0--
+		srcIdx--
+		if srcIdx < 0 {
+			break // Done processing squares.
+		}
+		square = src[srcIdx]
+		neededSpace = 4*square.Level + 1
+	}
+	return dstWithLvl0, src[:srcIdx+1], numLvl0
+}
+
+// SquareCorners returns the corners of the square in counter-clockwise order starting at the square origin, same as [Box.Vertices].
+// squareSize should be the result of [Quadtree.SquareSize] called on c. It is left to the user for performance reasons.
+func (qt Quadtree) SquareCorners(c i2.Square, squareSize float32) [4]Vec {
+	origin := qt.SquareOrigin(c, squareSize)
+	return [4]Vec{
+		Add(origin, Vec{X: 0, Y: 0}),
+		Add(origin, Vec{X: squareSize, Y: 0}),
+		Add(origin, Vec{X: squareSize, Y: squareSize}),
+		Add(origin, Vec{X: 0, Y: squareSize}),
+	}
+}
+
+// SquareOrigin returns the Square argument origin (lowest index corner position) in the quadtree.
+// squareSize should be the result of [Quadtree.SquareSize] called on c. It is left to the user for performance reasons.
+func (qt Quadtree) SquareOrigin(c i2.Square, squareSize float32) Vec {
+	idx := c.Index()
+	return Add(qt.Origin, Scale(squareSize, Vec{X: float32(idx.X), Y: float32(idx.Y)}))
+}
+
+// SquareCenter returns center of square.
+// squareSize should be the result of [Quadtree.SquareSize] called on c. It is left to the user for performance reasons.
+func (qt Quadtree) SquareCenter(c i2.Square, squareSize float32) Vec {
+	halfSize := 0.5 * squareSize
+	return Add(qt.SquareOrigin(c, squareSize), Vec{X: halfSize, Y: halfSize})
+}
+
+// SquareBox returns the bounding box of the square argument.
+// squareSize should be the result of [Quadtree.SquareSize] called on c. It is left to the user for performance reasons.
+func (qt Quadtree) SquareBox(c i2.Square, squareSize float32) Box {
+	origin := qt.SquareOrigin(c, squareSize)
+	return Box{
+		Min: origin,
+		Max: AddScalar(squareSize, origin),
+	}
+}
+
+// SquareSize returns the length of the sides of the square.
+func (qt Quadtree) SquareSize(c i2.Square) float32 {
+	dim := 1 << (c.Level - 1)
+	return float32(dim) * qt.Resolution
+}
+
+// SquareDDA returns a [GridDDA] that traverses the sub-squares of root of the argument level pierced by the ray
+// P(t) = origin + t*dir between parameters tmin and tmax. Visited cell indices are converted to squares with:
+//
+//	square := i2.Square{Vec: root.Vec.Add(step.Cell.ShiftLeft(level)), Level: level}
+func (qt Quadtree) SquareDDA(root i2.Square, level int, origin, dir Vec, tmin, tmax float32) GridDDA {
+	if level < 1 || level > root.Level {
+		panic("invalid SquareDDA level")
+	}
+	n := 1 << (root.Level - level)
+	return NewGridDDA(qt.SquareBox(root, qt.SquareSize(root)), n, n, origin, dir, tmin, tmax)
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,589 @@
+package ms2
+
+import (
+	"errors"
+
+	math "github.com/chewxy/math32"
+)
+
+var (
+	errSkeletonFewVertices = errors.New("skeleton needs a ring with at least 3 distinct vertices")
+	errSkeletonNoConverge  = errors.New("straight skeleton wavefront did not converge, input may be self-intersecting")
+	errMedialAxisSpacing   = errors.New("medial axis sample spacing must be positive")
+)
+
+// SkeletonNode is a node of a [Skeleton] graph.
+type SkeletonNode struct {
+	Pos Vec
+	// Time is the offset distance at which the inward moving boundary reaches the node.
+	// It is zero for nodes on the boundary.
+	Time float32
+}
+
+// Skeleton is a graph of arcs joining nodes in the interior of a shape, such as its
+// straight skeleton (see [NewStraightSkeleton]) or medial axis (see [NewMedialAxis]).
+type Skeleton struct {
+	Nodes []SkeletonNode
+	// Arcs are pairs of indices into Nodes.
+	Arcs [][2]int
+}
+
+// NewStraightSkeleton computes the straight skeleton of a shape whose rings do not intersect, such as a simple polygon
+// with holes. The straight skeleton is traced by the vertices of the shape's boundary as its edges move inward at unit
+// speed, keeping their direction. The arcs of the skeleton are the ridges of a roof with constant slope built on the
+// shape and the Time of a node is the offset distance at which it is reached, i.e: the height of the roof.
+//
+// The first nodes are the vertices of the shape's rings in order with zero Time. Rings are classified as outer
+// rings and holes with [Shape.Normalize], the argument shape is not modified. The wavefront is simulated event by event
+// which takes O(n³) time for a shape with n vertices, suitable for shapes with up to a few hundred vertices.
+// Events occurring at the same time and point, common in symmetric shapes, are joined into a single node.
+func NewStraightSkeleton(shape Shape) (Skeleton, error) {
+	var ss straightSkeleton
+	err := ss.init(shape)
+	if err != nil {
+		return Skeleton{}, err
+	}
+	err = ss.run()
+	return ss.sk, err
+}
+
+// skelEdge is an edge of the input shape. Wavefront edges move along their edge's normal at unit speed.
+type skelEdge struct {
+	origin Vec
+	dir    Vec // Unit direction.
+	normal Vec // Inward unit normal.
+}
+
+// skelVertex is a vertex of the wavefront, moving at constant velocity along a skeleton arc.
+type skelVertex struct {
+	pos         Vec // Position at time t0.
+	t0          float32
+	vel         Vec
+	left, right int // Index of the edges entering and leaving the vertex.
+	prev, next  int // Index of neighboring vertices in the wavefront.
+	node        int // Skeleton node at which the vertex was created.
+	alive       bool
+}
+
+type straightSkeleton struct {
+	edges  []skelEdge
+	verts  []skelVertex
+	sk     Skeleton
+	nInput int
+	tol    float32
+	now    float32
+	// Buffers of indices of coincident vertices and of vertices whose leaving edge contains them.
+	cluster, hits []int
+}
+
+func (ss *straightSkeleton) init(shape Shape) error {
+	// Copy rings to normalize orientations: interior lies to the left of every edge.
+	var clean Shape
+	for _, ring := range shape.Rings {
+		var r []Vec
+		for i, v := range ring {
+			if v != ring[(i+1)%len(ring)] {
+				r = append(r, v)
+			}
+		}
+		if len(r) < 3 {
+			return errSkeletonFewVertices
+		}
+		clean.Rings = append(clean.Rings, r)
+	}
+	if len(clean.Rings) == 0 {
+		return errSkeletonFewVertices
+	}
+	clean.Normalize()
+	ss.tol = 1e-5 * clean.Bounds().Size().Max()
+	for _, ring := range clean.Rings {
+		start := len(ss.verts)
+		n := len(ring)
+		for i, v := range ring {
+			next := ring[(i+1)%n]
+			dir := Unit(Sub(next, v))
+			ss.edges = append(ss.edges, skelEdge{origin: v, dir: dir, normal: perp(dir)})
+			ss.sk.Nodes = append(ss.sk.Nodes, SkeletonNode{Pos: v})
+			ss.verts = append(ss.verts, skelVertex{
+				pos:   v,
+				left:  start + (i+n-1)%n,
+				right: start + i,
+				prev:  start + (i+n-1)%n,
+				next:  start + (i+1)%n,
+				node:  start + i,
+				alive: true,
+			})
+		}
+	}
+	ss.nInput = len(ss.sk.Nodes)
+	for i := range ss.verts {
+		v := &ss.verts[i]
+		v.vel = ss.velocity(v.left, v.right)
+	}
+	return nil
+}
+
+func (ss *straightSkeleton) run() error {
+	maxEvents := 8*len(ss.verts) + 16
+	for events := 0; events < maxEvents; events++ {
+		// Events simultaneous with the last one are processed as a batch before advancing time.
+		if ss.simultaneousEvent() {
+			continue
+		}
+		kind, vi, ai, t := ss.nextEvent()
+		if kind == eventNone {
+			for i := range ss.verts {
+				if ss.verts[i].alive {
+					return errSkeletonNoConverge
+				}
+			}
+			return nil
+		}
+		ss.now = t
+		if ss.simultaneousEvent() {
+			continue
+		}
+		switch kind {
+		case eventEdge:
+			ss.edgeEvent(vi)
+		case eventSplit:
+			ss.splitEvent(vi, ai)
+		}
+	}
+	return errSkeletonNoConverge
+}
+
+const (
+	eventNone = iota
+	eventEdge
+	eventSplit
+)
+
+// nextEvent finds the earliest event of the wavefront. Edge events collapse the edge leaving vertex vi.
+// Split events occur when reflex vertex vi hits the edge leaving vertex ai.
+func (ss *straightSkeleton) nextEvent() (kind, vi, ai int, t float32) {
+	t = math.Inf(1)
+	for i := range ss.verts {
+		if !ss.verts[i].alive {
+			continue
+		}
+		if tc, ok := ss.collapseTime(i); ok && tc < t {
+			kind, vi, t = eventEdge, i, tc
+		}
+	}
+	for i := range ss.verts {
+		v := &ss.verts[i]
+		if !v.alive || !ss.isReflex(v) {
+			continue
+		}
+		for j := range ss.verts {
+			a := &ss.verts[j]
+			if !a.alive || j == i || a.next == i || a.right == v.left || a.right == v.right {
+				continue
+			}
+			if ts, ok := ss.splitTime(i, j); ok && ts < t {
+				kind, vi, ai, t = eventSplit, i, j, ts
+			}
+		}
+	}
+	return kind, vi, ai, t
+}
+
+// collapseTime returns the time at which the wavefront edge leaving vertex i shrinks to zero length.
+func (ss *straightSkeleton) collapseTime(i int) (float32, bool) {
+	v := &ss.verts[i]
+	w := &ss.verts[v.next]
+	d := ss.edges[v.right].dir
+	gap := Dot(Sub(ss.posAt(w, ss.now), ss.posAt(v, ss.now)), d)
+	if gap <= ss.tol {
+		return ss.now, true
+	}
+	closing := Dot(Sub(v.vel, w.vel), d)
+	if closing <= 0 {
+		return 0, false
+	}
+	return ss.now + gap/closing, true
+}
+
+// splitTime returns the time at which reflex vertex i hits the wavefront edge leaving vertex j.
+func (ss *straightSkeleton) splitTime(i, j int) (float32, bool) {
+	v := &ss.verts[i]
+	a := &ss.verts[j]
+	b := &ss.verts[a.next]
+	e := &ss.edges[a.right]
+	pv := ss.posAt(v, ss.now)
+	// Distance from vertex to the edge's line at the current time. The line moves inward at unit speed.
+	dist := Dot(Sub(pv, e.origin), e.normal) - ss.now
+	closing := 1 - Dot(v.vel, e.normal)
+	if dist < -ss.tol || closing <= 0 {
+		return 0, false
+	}
+	dt := math.Max(dist, 0) / closing
+	t := ss.now + dt
+	p := Add(pv, Scale(dt, v.vel))
+	sp := Dot(p, e.dir)
+	sa := Dot(ss.posAt(a, t), e.dir)
+	sb := Dot(ss.posAt(b, t), e.dir)
+	if sp < sa-ss.tol || sp > sb+ss.tol {
+		return 0, false // Hits the edge's line outside of the edge.
+	}
+	return t, true
+}
+
+func (ss *straightSkeleton) edgeEvent(i int) {
+	a := ss.verts[i]
+	b := ss.verts[a.next]
+	p := Scale(0.5, Add(ss.posAt(&a, ss.now), ss.posAt(&b, ss.now)))
+	node := ss.addNode(p)
+	ss.addArc(a.node, node)
+	ss.addArc(b.node, node)
+	ss.verts[i].alive = false
+	ss.verts[a.next].alive = false
+	if b.next == i {
+		return // Two vertex wavefront collapsed.
+	}
+	c := ss.addVertex(p, a.left, b.right, a.prev, b.next, node)
+	ss.collapseSmallWavefront(c)
+}
+
+// simultaneousEvent processes an event occurring at the current time, if any: an edge that has collapsed or
+// two or more non-adjacent vertices and edges meeting at a point. It reports whether an event was processed.
+func (ss *straightSkeleton) simultaneousEvent() bool {
+	for i := range ss.verts {
+		v := &ss.verts[i]
+		if !v.alive {
+			continue
+		}
+		tc, ok := ss.collapseTime(i)
+		if (ok && tc <= ss.now) || EqualElem(ss.posAt(v, ss.now), ss.posAt(&ss.verts[v.next], ss.now), ss.tol) {
+			ss.edgeEvent(i)
+			return true
+		}
+	}
+	for i := range ss.verts {
+		if !ss.verts[i].alive {
+			continue
+		}
+		p := ss.posAt(&ss.verts[i], ss.now)
+		ss.cluster = append(ss.cluster[:0], i)
+		ss.hits = ss.hits[:0]
+		for j := range ss.verts {
+			if !ss.verts[j].alive || j == i {
+				continue
+			}
+			if EqualElem(ss.posAt(&ss.verts[j], ss.now), p, ss.tol) {
+				if j < i {
+					break // Cluster already tried from vertex j.
+				}
+				ss.cluster = append(ss.cluster, j)
+			} else if ss.edgeContains(j, p) {
+				ss.hits = append(ss.hits, j)
+			}
+		}
+		if len(ss.cluster)+len(ss.hits) > 1 && ss.vertexEvent(p) {
+			return true
+		}
+	}
+	return false
+}
+
+// edgeContains reports whether p lies on the wavefront edge leaving vertex j at the current time, away from its ends.
+func (ss *straightSkeleton) edgeContains(j int, p Vec) bool {
+	a := &ss.verts[j]
+	e := &ss.edges[a.right]
+	if math.Abs(Dot(Sub(p, e.origin), e.normal)-ss.now) > ss.tol {
+		return false
+	}
+	sp := Dot(p, e.dir)
+	sa := Dot(ss.posAt(a, ss.now), e.dir)
+	sb := Dot(ss.posAt(&ss.verts[a.next], ss.now), e.dir)
+	return sa+ss.tol < sp && sp < sb-ss.tol
+}
+
+// vertexEvent joins the wavefronts of the cluster vertices meeting at p and of the hit edges containing p.
+// Each vertex has swept the angular gap between its edges behind it and each edge the half plane behind it.
+// Sorted around p, the gaps must be disjoint and between consecutive gaps a new vertex joins the edge leaving one
+// gap with the edge reaching the next. A reflex vertex hitting an edge is a split event. It reports whether
+// the event was valid.
+func (ss *straightSkeleton) vertexEvent(p Vec) bool {
+	const angleTol = 1e-4
+	type gap struct {
+		start, width float32
+		v            skelVertex
+	}
+	gaps := make([]gap, 0, len(ss.cluster)+len(ss.hits))
+	addGap := func(v skelVertex) {
+		in, out := ss.edges[v.left].dir, ss.edges[v.right].dir
+		start := math.Atan2(-in.Y, -in.X)
+		gaps = append(gaps, gap{start: start, width: wrapAngle(math.Atan2(out.Y, out.X) - start), v: v})
+		for m := len(gaps) - 1; m > 0 && gaps[m].start < gaps[m-1].start; m-- {
+			gaps[m], gaps[m-1] = gaps[m-1], gaps[m]
+		}
+	}
+	for _, vi := range ss.cluster {
+		addGap(ss.verts[vi])
+	}
+	for _, j := range ss.hits {
+		// The edge is split at p as if it had a vertex there.
+		a := ss.verts[j]
+		addGap(skelVertex{left: a.right, right: a.right, prev: j, next: a.next})
+	}
+	for k, g := range gaps {
+		next := gaps[(k+1)%len(gaps)]
+		if g.width > wrapAngle(next.start-g.start)+angleTol {
+			return false // Overlapping gaps, vertices are not meeting from different directions.
+		}
+	}
+	node := ss.addNode(p)
+	for _, vi := range ss.cluster {
+		ss.addArc(ss.verts[vi].node, node)
+		ss.verts[vi].alive = false
+	}
+	start := len(ss.verts)
+	for k, g := range gaps {
+		w := gaps[(k+1)%len(gaps)].v
+		ss.addVertex(p, w.left, g.v.right, w.prev, g.v.next, node)
+	}
+	for vi := start; vi < len(ss.verts); vi++ {
+		ss.collapseSmallWavefront(vi)
+	}
+	return true
+}
+
+func (ss *straightSkeleton) splitEvent(i, j int) {
+	v := ss.verts[i]
+	a := ss.verts[j]
+	p := ss.posAt(&v, ss.now)
+	node := ss.addNode(p)
+	ss.addArc(v.node, node)
+	ss.verts[i].alive = false
+	v1 := ss.addVertex(p, v.left, a.right, v.prev, a.next, node)
+	v2 := ss.addVertex(p, a.right, v.right, j, v.next, node)
+	ss.collapseSmallWavefront(v1)
+	ss.collapseSmallWavefront(v2)
+}
+
+// addVertex adds a wavefront vertex at p between the prev and next vertices and links them to it.
+func (ss *straightSkeleton) addVertex(p Vec, left, right, prev, next, node int) int {
+	idx := len(ss.verts)
+	ss.verts = append(ss.verts, skelVertex{
+		pos:   p,
+		t0:    ss.now,
+		vel:   ss.velocity(left, right),
+		left:  left,
+		right: right,
+		prev:  prev,
+		next:  next,
+		node:  node,
+		alive: true,
+	})
+	ss.verts[prev].next = idx
+	ss.verts[next].prev = idx
+	return idx
+}
+
+// collapseSmallWavefront removes the wavefront containing vertex i if it has less than 3 vertices,
+// joining the remaining vertices with an arc.
+func (ss *straightSkeleton) collapseSmallWavefront(i int) {
+	v := &ss.verts[i]
+	if !v.alive {
+		return
+	}
+	w := &ss.verts[v.next]
+	if v.next != i && w.next != i {
+		return
+	}
+	nv := ss.addNode(ss.posAt(v, ss.now))
+	ss.addArc(v.node, nv)
+	v.alive = false
+	if v.next == i {
+		return
+	}
+	w.alive = false
+	if v.vel != (Vec{}) {
+		// Remnant of a triangle whose edges collapse simultaneously, up to rounding errors.
+		ss.addArc(w.node, nv)
+		return
+	}
+	// Opposing edges: the wavefront collapsed to a segment between both vertices.
+	nw := ss.addNode(ss.posAt(w, ss.now))
+	ss.addArc(w.node, nw)
+	ss.addArc(nv, nw)
+}
+
+// velocity returns the velocity of a wavefront vertex joining the edges left and right,
+// which keeps it on both edges as they move inward at unit speed.
+func (ss *straightSkeleton) velocity(left, right int) Vec {
+	n1, n2 := ss.edges[left].normal, ss.edges[right].normal
+	det := Cross(n1, n2)
+	if math.Abs(det) < 1e-6 {
+		if Dot(n1, n2) > 0 {
+			return n1 // Collinear edges.
+		}
+		return Vec{} // Opposing edges: the wavefront has collapsed to a segment.
+	}
+	return Vec{X: (n2.Y - n1.Y) / det, Y: (n1.X - n2.X) / det}
+}
+
+// wrapAngle returns the angle wrapped to [0, 2π).
+func wrapAngle(a float32) float32 {
+	a = math.Mod(a, 2*math.Pi)
+	if a < 0 {
+		a += 2 * math.Pi
+	}
+	return a
+}
+
+func (ss *straightSkeleton) isReflex(v *skelVertex) bool {
+	return Cross(ss.edges[v.left].dir, ss.edges[v.right].dir) < -1e-6
+}
+
+func (ss *straightSkeleton) posAt(v *skelVertex, t float32) Vec {
+	return Add(v.pos, Scale(t-v.t0, v.vel))
+}
+
+// addNode returns the index of the skeleton node at p at the current time, creating it if
+// no node was created at the same position and time.
+func (ss *straightSkeleton) addNode(p Vec) int {
+	for i := ss.nInput; i < len(ss.sk.Nodes); i++ {
+		nd := ss.sk.Nodes[i]
+		if math.Abs(nd.Time-ss.now) <= ss.tol && EqualElem(nd.Pos, p, ss.tol) {
+			return i
+		}
+	}
+	ss.sk.Nodes = append(ss.sk.Nodes, SkeletonNode{Pos: p, Time: ss.now})
+	return len(ss.sk.Nodes) - 1
+}
+
+func (ss *straightSkeleton) addArc(a, b int) {
+	if a == b {
+		return
+	}
+	for _, arc := range ss.sk.Arcs {
+		if arc == [2]int{a, b} || arc == [2]int{b, a} {
+			return
+		}
+	}
+	ss.sk.Arcs = append(ss.sk.Arcs, [2]int{a, b})
+}
+
+// NewMedialAxis approximates the medial axis of a shape whose rings do not intersect: the set of centers of circles
+// inside the shape that touch its boundary at two or more points. The Time of each node is the radius of its circle,
+// i.e: the distance to the boundary.
+//
+// The boundary is sampled at intervals no larger than spacing and the medial axis is approximated by the edges of the
+// samples' Voronoi diagram that lie inside the shape, excluding those separating consecutive samples on the same ring.
+// Smaller spacing gives a more accurate result at the cost of more computation.
+func NewMedialAxis(shape Shape, spacing float32) (Skeleton, error) {
+	if spacing <= 0 {
+		return Skeleton{}, errMedialAxisSpacing
+	}
+	// Sample boundary. ringOf and posInRing identify consecutive samples.
+	var samples []Vec
+	var ringOf, ringStart []int
+	var closed, resampled Polyline
+	for r, ring := range shape.Rings {
+		if len(ring) < 3 {
+			return Skeleton{}, errSkeletonFewVertices
+		}
+		ringStart = append(ringStart, len(samples))
+		closed = append(append(closed[:0], ring...), ring[0])
+		n := max(int(math.Ceil(closed.Length()/spacing))+1, 4)
+		resampled = closed.AppendResampled(resampled[:0], n)
+		samples = append(samples, resampled[:n-1]...) // Last sample repeats the first.
+		for i := 0; i < n-1; i++ {
+			ringOf = append(ringOf, r)
+		}
+	}
+	ringStart = append(ringStart, len(samples))
+	del, err := NewDelaunay(samples)
+	if err != nil {
+		return Skeleton{}, err
+	}
+	consecutive := func(i, j int) bool {
+		if ringOf[i] != ringOf[j] {
+			return false
+		}
+		n := ringStart[ringOf[i]+1] - ringStart[ringOf[i]]
+		d := (i - j + n) % n
+		return d == 1 || d == n-1
+	}
+	tris := del.Triangles()
+	tol := 1e-4 * shape.Bounds().Size().Max()
+	// Voronoi vertices are Delaunay circumcenters. Nodes are created for those inside the shape.
+	nodeOf := make([]int, len(tris))
+	var sk Skeleton
+	for t := range tris {
+		nodeOf[t] = -1
+		center, radius := del.Triangle(t).Circumcircle()
+		if math.IsNaN(center.X) || math.IsInf(center.X, 0) || !shape.Contains(center, FillEvenOdd) {
+			continue
+		}
+		nodeOf[t] = len(sk.Nodes)
+		sk.Nodes = append(sk.Nodes, SkeletonNode{Pos: center, Time: radius})
+	}
+	// Voronoi edges join circumcenters of triangles sharing a Delaunay edge.
+	owner := make(map[[2]int]int, 3*len(tris)/2)
+	for t, tri := range tris {
+		for k := 0; k < 3; k++ {
+			a, b := tri[k], tri[(k+1)%3]
+			if a > b {
+				a, b = b, a
+			}
+			other, ok := owner[[2]int{a, b}]
+			if !ok {
+				owner[[2]int{a, b}] = t
+				continue
+			}
+			na, nb := nodeOf[t], nodeOf[other]
+			if na < 0 || nb < 0 || na == nb || consecutive(a, b) {
+				continue
+			}
+			sk.Arcs = append(sk.Arcs, [2]int{na, nb})
+		}
+	}
+	return sk.mergeNodes(tol), nil
+}
+
+// mergeNodes returns the skeleton with nodes closer than tol merged and the resulting degenerate arcs removed.
+func (sk Skeleton) mergeNodes(tol float32) Skeleton {
+	merged := make([]int, len(sk.Nodes))
+	// Hash kept nodes by grid cells of size tol so that only neighboring cells are searched.
+	cells := make(map[[2]int][]int, len(sk.Nodes))
+	cellOf := func(p Vec) [2]int {
+		return [2]int{int(math.Floor(p.X / tol)), int(math.Floor(p.Y / tol))}
+	}
+	var out Skeleton
+	for i, nd := range sk.Nodes {
+		merged[i] = -1
+		c := cellOf(nd.Pos)
+	search:
+		for dx := -1; dx <= 1; dx++ {
+			for dy := -1; dy <= 1; dy++ {
+				for _, j := range cells[[2]int{c[0] + dx, c[1] + dy}] {
+					if EqualElem(out.Nodes[j].Pos, nd.Pos, tol) {
+						merged[i] = j
+						break search
+					}
+				}
+			}
+		}
+		if merged[i] < 0 {
+			cells[c] = append(cells[c], len(out.Nodes))
+			merged[i] = len(out.Nodes)
+			out.Nodes = append(out.Nodes, nd)
+		}
+	}
+	seen := make(map[[2]int]bool, len(sk.Arcs))
+	for _, arc := range sk.Arcs {
+		a, b := merged[arc[0]], merged[arc[1]]
+		if a > b {
+			a, b = b, a
+		}
+		if a != b && !seen[[2]int{a, b}] {
+			seen[[2]int{a, b}] = true
+			out.Arcs = append(out.Arcs, [2]int{a, b})
+		}
+	}
+	return out
+}
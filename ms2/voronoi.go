@@ -0,0 +1,173 @@
+package ms2
+
+import "errors"
+
+var (
+	errVoronoiNoSites = errors.New("Voronoi diagram needs at least one site")
+	errVoronoiBounds  = errors.New("Voronoi bounds must be a non-empty box")
+)
+
+// Voronoi is a 2D Voronoi diagram of a set of sites clipped to a bounding box.
+// The cell of a site is the region of the box closer to that site than to any other site.
+//
+// Cells are computed from the dual Delaunay triangulation, built with the incremental Bowyer-Watson algorithm:
+// each cell is the bounding box clipped by the perpendicular bisectors between the site and its Delaunay neighbors.
+// Fortune's sweep line algorithm is not used so the diagram and [Delaunay] share one triangulation implementation.
+type Voronoi struct {
+	sites     []Vec
+	bounds    Box
+	cells     [][]Vec
+	neighbors [][]int
+	// adjacent are the Delaunay neighbors of each site, including those whose shared cell edge lies outside bounds.
+	adjacent [][]int
+	delaunay Delaunay
+}
+
+// NewVoronoi computes the Voronoi diagram of sites clipped to bounds. Sites need not lie within bounds.
+// Repeated sites after the first occurrence have empty cells and no neighbors.
+func NewVoronoi(sites []Vec, bounds Box) (Voronoi, error) {
+	if len(sites) == 0 {
+		return Voronoi{}, errVoronoiNoSites
+	} else if bounds.Empty() {
+		return Voronoi{}, errVoronoiBounds
+	}
+	var bw bowyerWatson
+	bw.triangulate(sites)
+	vor := Voronoi{
+		sites:     sites,
+		bounds:    bounds,
+		cells:     make([][]Vec, len(sites)),
+		neighbors: make([][]int, len(sites)),
+		adjacent:  make([][]int, len(sites)),
+		delaunay:  Delaunay{sites: sites},
+	}
+	for t, tri := range bw.tris {
+		if tri.dead {
+			continue
+		}
+		allSites := true
+		for k := 0; k < 3; k++ {
+			a, b := tri.v[(k+1)%3], tri.v[(k+2)%3]
+			if !bw.isSite(a) || !bw.isSite(b) {
+				allSites = false
+			} else if tri.n[k] < 0 || t < tri.n[k] {
+				// Visit each edge once.
+				vor.adjacent[a] = append(vor.adjacent[a], b)
+				vor.adjacent[b] = append(vor.adjacent[b], a)
+			}
+		}
+		if allSites {
+			vor.delaunay.tris = append(vor.delaunay.tris, tri.v)
+		}
+	}
+	var poly, aux []Vec
+	var labels, auxLabels []int
+	bv := bounds.Vertices()
+	for i, site := range sites {
+		if bw.dup[i] {
+			continue
+		}
+		poly = append(poly[:0], bv[:]...)
+		labels = append(labels[:0], -1, -1, -1, -1)
+		for _, j := range vor.adjacent[i] {
+			aux, auxLabels = clipBisector(aux[:0], auxLabels[:0], poly, labels, site, sites[j], j)
+			poly, aux = aux, poly
+			labels, auxLabels = auxLabels, labels
+		}
+		if len(poly) < 3 {
+			continue
+		}
+		vor.cells[i] = append([]Vec(nil), poly...)
+		for k, j := range labels {
+			if j < 0 || poly[k] == poly[(k+1)%len(poly)] {
+				continue
+			}
+			vor.neighbors[i] = append(vor.neighbors[i], j)
+		}
+	}
+	return vor, nil
+}
+
+// Sites returns the sites of the diagram.
+func (vor Voronoi) Sites() []Vec { return vor.sites }
+
+// Bounds returns the box the diagram is clipped to.
+func (vor Voronoi) Bounds() Box { return vor.bounds }
+
+// Cell returns the convex polygon of the ith site's cell with vertices in counter-clockwise order.
+// The cell is empty if the site's cell does not intersect the bounds or the site is repeated.
+func (vor Voronoi) Cell(i int) []Vec { return vor.cells[i] }
+
+// Neighbors returns the indices of the sites whose cells share an edge with the ith site's cell within the bounds.
+func (vor Voronoi) Neighbors(i int) []int { return vor.neighbors[i] }
+
+// Delaunay returns the dual Delaunay triangulation of the sites.
+func (vor Voronoi) Delaunay() Delaunay { return vor.delaunay }
+
+// Nearest returns the index of the site nearest to p by walking the Delaunay graph.
+// p need not lie within the diagram's bounds.
+func (vor Voronoi) Nearest(p Vec) int {
+	best := 0
+	bestD2 := Norm2(Sub(p, vor.sites[0]))
+	for improved := true; improved; {
+		improved = false
+		for _, j := range vor.adjacent[best] {
+			if d2 := Norm2(Sub(p, vor.sites[j])); d2 < bestD2 {
+				best, bestD2 = j, d2
+				improved = true
+			}
+		}
+	}
+	return best
+}
+
+// AppendCentroids appends the centroid of each cell to dst. Sites with empty cells append the site itself.
+// Moving the sites to the centroids and recomputing the diagram is one iteration of Lloyd's relaxation.
+func (vor Voronoi) AppendCentroids(dst []Vec) []Vec {
+	for i, cell := range vor.cells {
+		if len(cell) < 3 {
+			dst = append(dst, vor.sites[i])
+			continue
+		}
+		var area float32
+		var c Vec
+		for k := range cell {
+			a, b := cell[k], cell[(k+1)%len(cell)]
+			cross := a.X*b.Y - b.X*a.Y
+			area += cross
+			c = Add(c, Scale(cross, Add(a, b)))
+		}
+		if area == 0 {
+			dst = append(dst, vor.sites[i])
+			continue
+		}
+		dst = append(dst, Scale(1/(3*area), c))
+	}
+	return dst
+}
+
+// clipBisector clips the convex polygon poly to the half-plane of points closer to site than to other and appends
+// the result to dst. labels[k] identifies the edge from poly[k] to poly[k+1]. Edges created by the bisector are labeled otherLabel.
+func clipBisector(dst []Vec, dstLabels []int, poly []Vec, labels []int, site, other Vec, otherLabel int) ([]Vec, []int) {
+	mid := Scale(0.5, Add(site, other))
+	normal := Sub(other, site)
+	for k := range poly {
+		cur, next := poly[k], poly[(k+1)%len(poly)]
+		dc, dn := Dot(Sub(cur, mid), normal), Dot(Sub(next, mid), normal)
+		curIn, nextIn := dc <= 0, dn <= 0
+		if curIn {
+			dst = append(dst, cur)
+			dstLabels = append(dstLabels, labels[k])
+		}
+		if curIn != nextIn {
+			t := dc / (dc - dn)
+			dst = append(dst, Add(cur, Scale(t, Sub(next, cur))))
+			if curIn {
+				dstLabels = append(dstLabels, otherLabel)
+			} else {
+				dstLabels = append(dstLabels, labels[k])
+			}
+		}
+	}
+	return dst, dstLabels
+}
@@ -0,0 +1,441 @@
+package ms3
+
+import (
+	math "github.com/chewxy/math32"
+)
+
+// Supporter is implemented by convex shapes which provide a support mapping for use with [GJKSolver].
+// Support returns the point of the shape furthest along dir, that is, the point p of the shape that maximizes Dot(p, dir).
+// dir is not required to be normalized.
+type Supporter interface {
+	Support(dir Vec) Vec
+}
+
+var (
+	_ Supporter = Triangle{}
+	_ Supporter = Tetra{}
+	_ Supporter = Box{}
+	_ Supporter = Line{}
+	_ Supporter = Sphere{}
+	_ Supporter = ConvexPoints{}
+)
+
+// Sphere is a solid sphere defined by its center and radius.
+type Sphere struct {
+	Center Vec
+	Radius float32
+}
+
+// Support returns the point of the sphere furthest along dir. See [Supporter].
+func (s Sphere) Support(dir Vec) Vec {
+	n := Norm(dir)
+	if n == 0 {
+		return Add(s.Center, Vec{X: s.Radius})
+	}
+	return Add(s.Center, Scale(s.Radius/n, dir))
+}
+
+// ConvexPoints is the convex hull of a set of points. It is used to build a [Supporter] from
+// the vertices of an arbitrary convex polyhedron.
+type ConvexPoints []Vec
+
+// Support returns the point of the set furthest along dir. See [Supporter].
+func (c ConvexPoints) Support(dir Vec) Vec { return supportPoints(c, dir) }
+
+// Support returns the vertex of the triangle furthest along dir. See [Supporter].
+func (t Triangle) Support(dir Vec) Vec { return supportPoints(t[:], dir) }
+
+// Support returns the vertex of the tetrahedron furthest along dir. See [Supporter].
+func (t Tetra) Support(dir Vec) Vec { return supportPoints(t[:], dir) }
+
+// Support returns the end point of the line segment furthest along dir. See [Supporter].
+func (l Line) Support(dir Vec) Vec { return supportPoints(l[:], dir) }
+
+// Support returns the vertex of the box furthest along dir. See [Supporter].
+func (a Box) Support(dir Vec) Vec {
+	v := a.Min
+	if dir.X > 0 {
+		v.X = a.Max.X
+	}
+	if dir.Y > 0 {
+		v.Y = a.Max.Y
+	}
+	if dir.Z > 0 {
+		v.Z = a.Max.Z
+	}
+	return v
+}
+
+func supportPoints(pts []Vec, dir Vec) Vec {
+	best := pts[0]
+	bestDot := Dot(best, dir)
+	for _, p := range pts[1:] {
+		if d := Dot(p, dir); d > bestDot {
+			best, bestDot = p, d
+		}
+	}
+	return best
+}
+
+// DefaultGJKSolver returns a [GJKSolver] with recommended parameters.
+func DefaultGJKSolver() GJKSolver {
+	return GJKSolver{
+		MaxIterations: 64,
+		Tolerance:     1e-5,
+	}
+}
+
+// GJKSolver implements convex shape queries on [Supporter] shapes: the Gilbert-Johnson-Keerthi (GJK) algorithm
+// for overlap tests and separation distance and the Expanding Polytope Algorithm (EPA) for penetration depth.
+// Both operate on the Minkowski difference A-B of the shapes, which contains the origin if and only if the shapes overlap.
+type GJKSolver struct {
+	// MaxIterations bounds the amount of support mapping evaluations of each algorithm. Parameter is required.
+	MaxIterations int
+	// Tolerance is the relative tolerance with which the distance or penetration depth is computed. Parameter is required.
+	Tolerance float32
+}
+
+// gjkVertex is a vertex of the Minkowski difference A-B and the shape points which generate it.
+type gjkVertex struct {
+	w, a, b Vec
+}
+
+// Overlap reports whether shapes a and b intersect. Touching shapes are considered overlapping.
+func (s GJKSolver) Overlap(a, b Supporter) bool {
+	_, _, _, overlap := s.gjk(a, b)
+	return overlap
+}
+
+// Distance returns the separation distance between shapes a and b and the closest points
+// on each shape, pa on a and pb on b. If the shapes overlap dist is zero and pa and pb are not meaningful.
+func (s GJKSolver) Distance(a, b Supporter) (dist float32, pa, pb Vec) {
+	simplex, lambda, n, overlap := s.gjk(a, b)
+	for i := 0; i < n; i++ {
+		pa = Add(pa, Scale(lambda[i], simplex[i].a))
+		pb = Add(pb, Scale(lambda[i], simplex[i].b))
+	}
+	if overlap {
+		return 0, pa, pb
+	}
+	return Norm(Sub(pa, pb)), pa, pb
+}
+
+// Penetration returns the penetration depth and unit normal of overlapping shapes a and b using EPA.
+// Translating b by depth along normal separates the shapes, leaving them touching.
+// ok is false if the shapes do not overlap.
+func (s GJKSolver) Penetration(a, b Supporter) (depth float32, normal Vec, ok bool) {
+	simplex, _, n, overlap := s.gjk(a, b)
+	if !overlap {
+		return 0, Vec{}, false
+	}
+	n = gjkExpand(a, b, &simplex, n, s.Tolerance)
+	if n < 4 {
+		// Minkowski difference is flat: shapes are touching.
+		if n == 3 {
+			normal = Unit(Cross(Sub(simplex[1].w, simplex[0].w), Sub(simplex[2].w, simplex[0].w)))
+		}
+		return 0, normal, true
+	}
+	return s.epa(a, b, simplex)
+}
+
+// gjkExpand adds support points to the simplex until it is a tetrahedron, needed when GJK terminates
+// with a lower dimensional simplex due to touching shapes. It returns the new amount of simplex vertices,
+// which is less than 4 if the Minkowski difference is flat.
+func gjkExpand(a, b Supporter, simplex *[4]gjkVertex, n int, tol float32) int {
+	for n < 4 {
+		var dirs []Vec
+		switch n {
+		case 1:
+			dirs = []Vec{{X: 1}, {X: -1}, {Y: 1}, {Y: -1}, {Z: 1}, {Z: -1}}
+		case 2:
+			e := Sub(simplex[1].w, simplex[0].w)
+			// Cross with the axis least aligned with the edge.
+			axis := Vec{X: 1}
+			ae := AbsElem(e)
+			if ae.Y <= ae.X && ae.Y <= ae.Z {
+				axis = Vec{Y: 1}
+			} else if ae.Z <= ae.X && ae.Z <= ae.Y {
+				axis = Vec{Z: 1}
+			}
+			p1 := Cross(e, axis)
+			p2 := Cross(e, p1)
+			dirs = []Vec{p1, Scale(-1, p1), p2, Scale(-1, p2)}
+		case 3:
+			normal := Cross(Sub(simplex[1].w, simplex[0].w), Sub(simplex[2].w, simplex[0].w))
+			dirs = []Vec{normal, Scale(-1, normal)}
+		}
+		added := false
+		for _, d := range dirs {
+			v := gjkSupport(a, b, d)
+			if gjkAffinelyIndependent(simplex[:n], v.w, tol) {
+				simplex[n] = v
+				n++
+				added = true
+				break
+			}
+		}
+		if !added {
+			break
+		}
+	}
+	return n
+}
+
+// gjk runs the GJK distance algorithm and returns the final simplex and the barycentric
+// coordinates of the point of the Minkowski difference closest to the origin.
+func (s GJKSolver) gjk(a, b Supporter) (simplex [4]gjkVertex, lambda [4]float32, n int, overlap bool) {
+	if s.MaxIterations <= 0 {
+		panic("invalid MaxIterations")
+	} else if s.Tolerance <= 0 || math.IsNaN(s.Tolerance) {
+		panic("invalid Tolerance")
+	}
+	simplex[0] = gjkSupport(a, b, Vec{X: 1})
+	lambda[0] = 1
+	n = 1
+	v := simplex[0].w
+	tol2 := s.Tolerance * s.Tolerance
+	for i := 0; i < s.MaxIterations; i++ {
+		vv := Norm2(v)
+		var maxW2 float32
+		for k := 0; k < n; k++ {
+			maxW2 = math.Max(maxW2, Norm2(simplex[k].w))
+		}
+		if vv <= tol2*maxW2 {
+			return simplex, lambda, n, true // Origin on simplex: shapes touching.
+		}
+		w := gjkSupport(a, b, Scale(-1, v))
+		if vv-Dot(v, w.w) <= s.Tolerance*vv {
+			break // No significant progress towards origin.
+		}
+		duplicate := false
+		for k := 0; k < n; k++ {
+			duplicate = duplicate || simplex[k].w == w.w
+		}
+		if duplicate {
+			break
+		}
+		simplex[n] = w
+		n++
+		v, lambda, n = gjkClosest(&simplex, n)
+		if n == 4 {
+			return simplex, lambda, n, true // Origin enclosed by tetrahedron.
+		}
+	}
+	return simplex, lambda, n, false
+}
+
+func gjkSupport(a, b Supporter, dir Vec) gjkVertex {
+	pa := a.Support(dir)
+	pb := b.Support(Scale(-1, dir))
+	return gjkVertex{w: Sub(pa, pb), a: pa, b: pb}
+}
+
+// gjkClosest finds the point of the simplex closest to the origin, reduces the simplex to the
+// smallest sub-simplex containing it and returns the point and its barycentric coordinates.
+func gjkClosest(simplex *[4]gjkVertex, n int) (v Vec, lambda [4]float32, m int) {
+	bestDist := math.Inf(1)
+	var bestMask int
+	var bestLambda [4]float32
+	for mask := 1; mask < 1<<n; mask++ {
+		var idx [4]int
+		k := 0
+		for i := 0; i < n; i++ {
+			if mask&(1<<i) != 0 {
+				idx[k] = i
+				k++
+			}
+		}
+		l, ok := gjkProject(simplex, idx[:k])
+		if !ok {
+			continue
+		}
+		var p Vec
+		for j := 0; j < k; j++ {
+			p = Add(p, Scale(l[j], simplex[idx[j]].w))
+		}
+		if d := Norm2(p); d < bestDist {
+			bestDist, bestMask, bestLambda, v = d, mask, l, p
+		}
+	}
+	// Keep vertices of the sub-simplex.
+	var reduced [4]gjkVertex
+	for i := 0; i < n; i++ {
+		if bestMask&(1<<i) != 0 {
+			reduced[m] = simplex[i]
+			m++
+		}
+	}
+	*simplex = reduced
+	return v, bestLambda, m
+}
+
+// gjkProject projects the origin onto the affine hull of the simplex vertices given by idx and returns
+// the barycentric coordinates of the projection. ok is false if the projection lies outside the sub-simplex
+// or the sub-simplex is degenerate.
+func gjkProject(simplex *[4]gjkVertex, idx []int) (lambda [4]float32, ok bool) {
+	k := len(idx)
+	if k == 1 {
+		return [4]float32{1}, true
+	}
+	// Minimize |w0 + sum(mu_i*e_i)|² with e_i = w_i - w0 by solving the normal equations G*mu = -E'*w0.
+	w0 := simplex[idx[0]].w
+	var e [3]Vec
+	for i := 1; i < k; i++ {
+		e[i-1] = Sub(simplex[idx[i]].w, w0)
+	}
+	var sys [3][4]float32
+	for i := 0; i < k-1; i++ {
+		for j := 0; j < k-1; j++ {
+			sys[i][j] = Dot(e[i], e[j])
+		}
+		sys[i][k-1] = -Dot(e[i], w0)
+	}
+	mu, ok := solveSmall(&sys, k-1)
+	if !ok {
+		return lambda, false
+	}
+	lambda[0] = 1
+	for i := 0; i < k-1; i++ {
+		if mu[i] <= 0 {
+			return lambda, false
+		}
+		lambda[i+1] = mu[i]
+		lambda[0] -= mu[i]
+	}
+	return lambda, lambda[0] > 0
+}
+
+// solveSmall solves the n×n linear system with augmented matrix sys using Gaussian elimination with partial pivoting.
+func solveSmall(sys *[3][4]float32, n int) (x [3]float32, ok bool) {
+	var scale float32
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			scale = math.Max(scale, math.Abs(sys[i][j]))
+		}
+	}
+	for col := 0; col < n; col++ {
+		pivot := col
+		for r := col + 1; r < n; r++ {
+			if math.Abs(sys[r][col]) > math.Abs(sys[pivot][col]) {
+				pivot = r
+			}
+		}
+		if math.Abs(sys[pivot][col]) <= 1e-6*scale {
+			return x, false // Singular system: degenerate simplex.
+		}
+		sys[col], sys[pivot] = sys[pivot], sys[col]
+		for r := col + 1; r < n; r++ {
+			f := sys[r][col] / sys[col][col]
+			for c := col; c <= n; c++ {
+				sys[r][c] -= f * sys[col][c]
+			}
+		}
+	}
+	for r := n - 1; r >= 0; r-- {
+		sum := sys[r][n]
+		for c := r + 1; c < n; c++ {
+			sum -= sys[r][c] * x[c]
+		}
+		x[r] = sum / sys[r][r]
+	}
+	return x, true
+}
+
+// gjkAffinelyIndependent reports whether w is affinely independent of the simplex vertices.
+func gjkAffinelyIndependent(simplex []gjkVertex, w Vec, tol float32) bool {
+	w0 := simplex[0].w
+	d := Sub(w, w0)
+	scale := Norm(d)
+	if scale == 0 {
+		return false
+	}
+	switch len(simplex) {
+	case 1:
+		return true
+	case 2:
+		e := Sub(simplex[1].w, w0)
+		return Norm(Cross(e, d)) > tol*Norm(e)*scale
+	default:
+		n := Cross(Sub(simplex[1].w, w0), Sub(simplex[2].w, w0))
+		return math.Abs(Dot(n, d)) > tol*Norm(n)*scale
+	}
+}
+
+type epaFace struct {
+	v      [3]int
+	normal Vec
+	dist   float32
+}
+
+// epa runs the expanding polytope algorithm starting from a tetrahedron enclosing the origin.
+func (s GJKSolver) epa(a, b Supporter, tetra [4]gjkVertex) (depth float32, normal Vec, ok bool) {
+	verts := make([]gjkVertex, 0, 4+s.MaxIterations)
+	verts = append(verts, tetra[:]...)
+	faces := make([]epaFace, 0, 4+2*s.MaxIterations)
+	for _, f := range [4][4]int{{0, 1, 2, 3}, {0, 3, 1, 2}, {0, 2, 3, 1}, {1, 3, 2, 0}} {
+		face := epaNewFace(verts, f[0], f[1], f[2])
+		if Dot(face.normal, Sub(verts[f[3]].w, verts[f[0]].w)) > 0 {
+			face = epaNewFace(verts, f[0], f[2], f[1]) // Orient face outwards.
+		}
+		faces = append(faces, face)
+	}
+	var edges [][2]int
+	var closest epaFace
+	for i := 0; i < s.MaxIterations; i++ {
+		closest = faces[0]
+		for _, f := range faces[1:] {
+			if f.dist < closest.dist {
+				closest = f
+			}
+		}
+		w := gjkSupport(a, b, closest.normal)
+		if Dot(w.w, closest.normal)-closest.dist <= s.Tolerance*math.Max(closest.dist, 1e-3) {
+			break // Reached boundary of Minkowski difference.
+		}
+		// Remove faces visible from new vertex and find the horizon.
+		iw := len(verts)
+		verts = append(verts, w)
+		edges = edges[:0]
+		kept := faces[:0]
+		for _, f := range faces {
+			if Dot(f.normal, Sub(w.w, verts[f.v[0]].w)) <= 0 {
+				kept = append(kept, f)
+				continue
+			}
+			for k := 0; k < 3; k++ {
+				e := [2]int{f.v[k], f.v[(k+1)%3]}
+				shared := false
+				for j, other := range edges {
+					if other == [2]int{e[1], e[0]} {
+						edges[j] = edges[len(edges)-1]
+						edges = edges[:len(edges)-1]
+						shared = true
+						break
+					}
+				}
+				if !shared {
+					edges = append(edges, e)
+				}
+			}
+		}
+		faces = kept
+		for _, e := range edges {
+			faces = append(faces, epaNewFace(verts, e[0], e[1], iw))
+		}
+		if len(faces) == 0 {
+			break // Numerical breakdown.
+		}
+	}
+	return closest.dist, closest.normal, true
+}
+
+func epaNewFace(verts []gjkVertex, i, j, k int) epaFace {
+	n := Cross(Sub(verts[j].w, verts[i].w), Sub(verts[k].w, verts[i].w))
+	norm := Norm(n)
+	if norm == 0 {
+		return epaFace{v: [3]int{i, j, k}, dist: math.Inf(1)} // Degenerate face is never closest.
+	}
+	n = Scale(1/norm, n)
+	return epaFace{v: [3]int{i, j, k}, normal: n, dist: math.Abs(Dot(n, verts[i].w))}
+}
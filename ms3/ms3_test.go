@@ -2,11 +2,13 @@ package ms3
 
 import (
 	"fmt"
+	"math/big"
 	"math/rand"
 	"testing"
 
 	math "github.com/chewxy/math32"
 
+	"github.com/soypat/geometry/i3"
 	"github.com/soypat/geometry/internal"
 )
 
@@ -208,3 +210,322 @@ func TestCollinearDegenerate(t *testing.T) {
 		t.Error("a==c with b within tol should be collinear")
 	}
 }
+
+func TestGridDDA(t *testing.T) {
+	domain := Box{Min: Vec{X: -1, Y: 0, Z: 2}, Max: Vec{X: 3, Y: 2, Z: 5}}
+	const nx, ny, nz = 8, 5, 6
+	size := DivElem(domain.Size(), Vec{X: nx, Y: ny, Z: nz})
+	rng := rand.New(rand.NewSource(1))
+	randVec := func() Vec {
+		return Vec{X: float32(rng.Float64()*8 - 3), Y: float32(rng.Float64()*6 - 2), Z: float32(rng.Float64()*7 + 0.5)}
+	}
+	for i := 0; i < 200; i++ {
+		seg := Line{randVec(), randVec()}
+		dir := Sub(seg[1], seg[0])
+		dda := NewGridDDA(domain, nx, ny, nz, seg[0], dir, 0, 1)
+		steps := dda.AppendSteps(nil)
+		for j, step := range steps {
+			if step.TEnter > step.TExit || step.TEnter < 0 || step.TExit > 1 {
+				t.Fatalf("seg %v step %d: bad parameters %+v", seg, j, step)
+			}
+			c := step.Cell
+			if c.X < 0 || c.Y < 0 || c.Z < 0 || c.X >= nx || c.Y >= ny || c.Z >= nz {
+				t.Fatalf("seg %v: cell %v out of grid", seg, c)
+			}
+			// Midpoint of traversed interval lies in cell.
+			mid := Add(seg[0], Scale((step.TEnter+step.TExit)/2, dir))
+			cellBox := Box{Min: Add(domain.Min, MulElem(size, Vec{X: float32(c.X), Y: float32(c.Y), Z: float32(c.Z)}))}
+			cellBox.Max = Add(cellBox.Min, size)
+			if !cellBox.ScaleCentered(Vec{X: 1.001, Y: 1.001, Z: 1.001}).Contains(mid) {
+				t.Fatalf("seg %v: midpoint %v of step %+v not in cell box %v", seg, mid, step, cellBox)
+			}
+			if j == 0 {
+				continue
+			}
+			prev := steps[j-1]
+			d := c.Sub(prev.Cell)
+			if d.X*d.X+d.Y*d.Y+d.Z*d.Z != 1 || prev.Cell.Sub(c) != step.Normal {
+				t.Fatalf("seg %v: non-adjacent cells %+v -> %+v", seg, prev, step)
+			}
+			if prev.TExit != step.TEnter {
+				t.Fatalf("seg %v: discontinuous parameters %+v -> %+v", seg, prev, step)
+			}
+		}
+		// Points sampled along the segment must lie within visited cells.
+		visited := make(map[i3.Vec]bool)
+		for _, step := range steps {
+			visited[step.Cell] = true
+		}
+		for k := 1; k < 100; k++ {
+			p := Add(seg[0], Scale(float32(k)/100, dir))
+			rel := DivElem(Sub(p, domain.Min), size)
+			cell := i3.Vec{X: int(math.Floor(rel.X)), Y: int(math.Floor(rel.Y)), Z: int(math.Floor(rel.Z))}
+			frac := Sub(rel, FloorElem(rel))
+			if !domain.Contains(p) || frac.Min() < 1e-3 || frac.Max() > 1-1e-3 {
+				continue // Outside or too close to cell boundary.
+			}
+			if !visited[cell] {
+				t.Fatalf("seg %v: cell %v containing %v not visited", seg, cell, p)
+			}
+		}
+	}
+	// Ray missing the domain.
+	dda := NewGridDDA(domain, nx, ny, nz, Vec{X: -5}, Vec{Y: 1}, 0, 10)
+	if _, ok := dda.Next(); ok {
+		t.Error("expected no cells for ray missing domain")
+	}
+	// Octree traversal: cubes along the x axis.
+	oct := Octree{Resolution: 1}
+	root := i3.Cube{Level: 4}
+	dda = oct.CubeDDA(root, 2, Vec{X: -1, Y: 0.5, Z: 0.5}, Vec{X: 1}, 0, 100)
+	steps := dda.AppendSteps(nil)
+	if len(steps) != 4 {
+		t.Fatalf("want 4 cubes along axis, got %d", len(steps))
+	}
+	for i, step := range steps {
+		cube := i3.Cube{Vec: root.Vec.Add(step.Cell.ShiftLeftScalar(2)), Level: 2}
+		box := oct.CubeBox(cube, oct.CubeSize(cube))
+		if box.Min.X != float32(2*i) || box.Min.Y != 0 || box.Min.Z != 0 {
+			t.Errorf("cube %d box %v unexpected", i, box)
+		}
+	}
+}
+
+func TestGJK(t *testing.T) {
+	const tol = 1e-3
+	gjk := DefaultGJKSolver()
+	sa := Sphere{Center: Vec{}, Radius: 1}
+	sb := Sphere{Center: Vec{X: 3, Y: 4}, Radius: 2}
+	dist, pa, pb := gjk.Distance(sa, sb)
+	if math.Abs(dist-2) > tol || !EqualElem(pa, Vec{X: 0.6, Y: 0.8}, 1e-2) || !EqualElem(pb, Vec{X: 1.8, Y: 2.4}, 1e-2) {
+		t.Errorf("sphere distance: got %g %v %v", dist, pa, pb)
+	}
+	box := Box{Min: Vec{X: -1, Y: -1, Z: -1}, Max: Vec{X: 1, Y: 1, Z: 1}}
+	tri := Triangle{{X: 3, Y: -5, Z: 0.5}, {X: 3, Y: 5, Z: 0.5}, {X: 6, Y: 0, Z: 0.5}}
+	dist, pa, pb = gjk.Distance(box, tri)
+	if math.Abs(dist-2) > tol || math.Abs(pa.X-1) > tol || math.Abs(pb.X-3) > tol {
+		t.Errorf("box-triangle distance: got %g %v %v", dist, pa, pb)
+	}
+	tetra := Tetra{{X: 0.5}, {X: 3, Y: 0}, {X: 2, Y: 2}, {X: 2, Y: 1, Z: 2}}
+	if !gjk.Overlap(box, tetra) {
+		t.Error("box and tetrahedron should overlap")
+	}
+	if gjk.Overlap(box, tri) {
+		t.Error("box and triangle should not overlap")
+	}
+	verts := box.Vertices()
+	hull := ConvexPoints(verts[:])
+	if dist, _, _ := gjk.Distance(hull, Line{{X: 2, Y: 2, Z: 2}, {X: 3, Y: 3, Z: 3}}); math.Abs(dist-math.Sqrt(3)) > tol {
+		t.Errorf("hull-line distance: got %g", dist)
+	}
+	// Penetration.
+	depth, normal, ok := gjk.Penetration(sa, Sphere{Center: Vec{X: 1.5}, Radius: 1})
+	if !ok || math.Abs(depth-0.5) > 1e-2 || !EqualElem(normal, Vec{X: 1}, 1e-2) {
+		t.Errorf("sphere penetration: got %g %v %v", depth, normal, ok)
+	}
+	other := box.Add(Vec{X: 0.2, Y: 1.7, Z: 0.1})
+	depth, normal, ok = gjk.Penetration(box, other)
+	if !ok || math.Abs(depth-0.3) > tol || !EqualElem(normal, Vec{Y: 1}, tol) {
+		t.Errorf("box penetration: got %g %v %v", depth, normal, ok)
+	}
+	// Separating by the penetration vector leaves shapes touching.
+	moved := other.Add(Scale(depth*1.01, normal))
+	if gjk.Overlap(box, moved) {
+		t.Error("shapes should be separated after resolving penetration")
+	}
+	if _, _, ok := gjk.Penetration(box, tri); ok {
+		t.Error("non-overlapping shapes should have no penetration")
+	}
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 100; i++ {
+		c := Vec{X: float32(rng.Float64()*6 - 3), Y: float32(rng.Float64()*6 - 3), Z: float32(rng.Float64()*6 - 3)}
+		r := float32(rng.Float64()*2 + 0.1)
+		s := Sphere{Center: c, Radius: r}
+		want := Norm(c) - r - 1
+		dist, _, _ := gjk.Distance(sa, s)
+		if want > 0 && math.Abs(dist-want) > 1e-2 {
+			t.Fatalf("sphere %v: got distance %g want %g", s, dist, want)
+		}
+		if want < 0 {
+			depth, normal, ok := gjk.Penetration(sa, s)
+			if !ok || math.Abs(depth+want) > 2e-2*(1+r) || (Norm(c) > 0.1 && !EqualElem(normal, Unit(c), 0.1)) {
+				t.Fatalf("sphere %v: got penetration %g %v want %g %v", s, depth, normal, -want, Unit(c))
+			}
+		}
+	}
+}
+
+func TestBoxQueries(t *testing.T) {
+	const tol = 1e-5
+	box := Box{Min: Vec{X: -1, Y: -2, Z: -3}, Max: Vec{X: 1, Y: 2, Z: 3}}
+	tmin, tmax, normal, hit := box.IntersectRay(Vec{X: -5, Y: 0, Z: 0}, Vec{X: 2, Y: 0, Z: 0})
+	if !hit || tmin != 2 || tmax != 3 || normal != (Vec{X: -1}) {
+		t.Errorf("ray along x: got %g %g %v %v", tmin, tmax, normal, hit)
+	}
+	tmin, tmax, normal, hit = box.IntersectRay(Vec{Z: 10}, Vec{X: 0.1, Y: 0.1, Z: -1})
+	if !hit || math.Abs(tmin-7) > tol || math.Abs(tmax-10) > tol || normal != (Vec{Z: 1}) {
+		t.Errorf("ray from above: got %g %g %v %v", tmin, tmax, normal, hit)
+	}
+	if _, _, _, hit = box.IntersectRay(Vec{X: 5}, Vec{X: 1}); hit {
+		t.Error("box behind ray should not be hit")
+	}
+	if _, _, _, hit = box.IntersectRay(Vec{Y: 5}, Vec{X: 1}); hit {
+		t.Error("parallel ray outside box should not hit")
+	}
+	if tmin, _, _, hit = box.IntersectRay(Vec{}, Vec{Y: 1}); !hit || tmin != -2 {
+		t.Errorf("ray from inside box: got tmin=%g hit=%v", tmin, hit)
+	}
+
+	c0, c1, ok := box.ClipSegment(Vec{X: -3, Y: 0, Z: 0}, Vec{X: 0.5, Y: 0, Z: 0})
+	if !ok || c0 != (Vec{X: -1}) || c1 != (Vec{X: 0.5}) {
+		t.Errorf("clip segment: got %v %v %v", c0, c1, ok)
+	}
+	if _, _, ok = box.ClipSegment(Vec{X: 2}, Vec{X: 3, Y: 3}); ok {
+		t.Error("segment outside box should not clip")
+	}
+
+	if got := box.Closest(Vec{X: 5, Y: 0, Z: -10}); got != (Vec{X: 1, Z: -3}) {
+		t.Errorf("closest: got %v", got)
+	}
+	if d := box.Distance(Vec{X: 4, Y: 6, Z: 0}); math.Abs(d-5) > tol {
+		t.Errorf("distance: want 5, got %g", d)
+	}
+	if d := box.Distance(Vec{}); d != 0 {
+		t.Errorf("distance inside box: got %g", d)
+	}
+	other := box.Add(Vec{X: 5, Y: 8})
+	if d := box.DistanceBox(other); math.Abs(d-5) > tol || d != other.DistanceBox(box) {
+		t.Errorf("box distance: want 5, got %g", d)
+	}
+	if d := box.DistanceBox(box.Add(Vec{X: 1})); d != 0 {
+		t.Errorf("overlapping box distance: got %g", d)
+	}
+
+	lo, hi := box.Split(2, 1)
+	if lo != (Box{Min: box.Min, Max: Vec{X: 1, Y: 2, Z: 1}}) || hi != (Box{Min: Vec{X: -1, Y: -2, Z: 1}, Max: box.Max}) {
+		t.Errorf("split: got %v %v", lo, hi)
+	}
+	if lo, hi = box.Split(0, 10); lo != box || !hi.Empty() {
+		t.Errorf("split outside box: got %v %v", lo, hi)
+	}
+	var volume float32
+	verts := box.Vertices()
+	for i, octant := range box.Octree() {
+		volume += octant.Volume()
+		if !octant.Contains(verts[i]) || !octant.Contains(box.Center()) || !box.ContainsBox(octant) {
+			t.Errorf("octant %d %v does not match vertex %v", i, octant, verts[i])
+		}
+	}
+	if math.Abs(volume-box.Volume()) > tol {
+		t.Errorf("octant volumes sum %g, want %g", volume, box.Volume())
+	}
+}
+
+func TestPolyline(t *testing.T) {
+	const tol = 1e-5
+	// Helix-like path climbing around the z axis.
+	var pl Polyline
+	for i := 0; i <= 64; i++ {
+		a := float32(i) * math.Pi / 16
+		pl = append(pl, Vec{X: math.Cos(a), Y: math.Sin(a), Z: float32(i) / 16})
+	}
+	length := pl.Length()
+	resampled := pl.AppendResampled(nil, 33)
+	if len(resampled) != 33 || !EqualElem(resampled[32], pl[64], tol) {
+		t.Fatalf("bad resampling")
+	}
+	if got := Polyline(resampled).Length(); math.Abs(got-length) > 0.01*length {
+		t.Errorf("resampled length %g, want about %g", got, length)
+	}
+	for i := 1; i < len(pl)-1; i++ {
+		tg, n := pl.Tangent(i), pl.Normal(i)
+		if math.Abs(Norm(tg)-1) > tol || math.Abs(Dot(tg, n)) > tol {
+			t.Fatalf("vertex %d: tangent %v and normal %v not orthonormal", i, tg, n)
+		}
+		// Normal points towards the helix axis.
+		if toAxis := Unit(Vec{X: -pl[i].X, Y: -pl[i].Y}); Dot(n, toAxis) < 0.9 {
+			t.Fatalf("vertex %d: normal %v does not point to axis", i, n)
+		}
+	}
+	if n := pl.Normal(0); n != (Vec{}) {
+		t.Errorf("end normal should be zero, got %v", n)
+	}
+	p := pl.PointAt(length / 3)
+	closest, s, dist := pl.Project(Add(p, Vec{X: 0.1 * p.X, Y: 0.1 * p.Y}))
+	if !EqualElem(closest, p, 1e-2) || math.Abs(s-length/3) > 1e-2 || dist > 0.11 {
+		t.Errorf("project: got %v %g %g, want %v %g", closest, s, dist, p, length/3)
+	}
+	sub := pl.AppendBetween(nil, length/4, length/2)
+	if got := Polyline(sub).Length(); math.Abs(got-length/4) > 1e-3 {
+		t.Errorf("sub polyline length %g, want %g", got, length/4)
+	}
+}
+
+func TestPredicates(t *testing.T) {
+	a, b, c := Vec{X: 1}, Vec{Y: 1}, Vec{X: -1}
+	if Orient3D(a, b, c, Vec{Z: 1}) != 1 || Orient3D(a, b, c, Vec{Z: -1}) != -1 || Orient3D(a, b, c, Vec{X: 3, Y: 2}) != 0 {
+		t.Error("bad Orient3D sign")
+	}
+	d := Vec{Z: 1}
+	for _, test := range []struct {
+		e    Vec
+		want int
+	}{
+		{e: Vec{Y: -1}, want: 0},
+		{e: Vec{Z: -1}, want: 0},
+		{e: Vec{X: 0.25, Y: 0.5}, want: 1},
+		{e: Vec{X: 1, Y: 1, Z: 1}, want: -1},
+	} {
+		if got := InSphere(a, b, c, d, test.e); got != test.want {
+			t.Errorf("InSphere(%v)=%d, want %d", test.e, got, test.want)
+		}
+		if got := InSphere(b, a, c, d, test.e); got != -test.want {
+			t.Errorf("negative InSphere(%v)=%d, want %d", test.e, got, -test.want)
+		}
+	}
+
+	rng := rand.New(rand.NewSource(1))
+	rv := func() Vec {
+		return Vec{X: float32(rng.Float64()*20 - 10), Y: float32(rng.Float64()*20 - 10), Z: float32(rng.Float64()*20 - 10)}
+	}
+	for i := 0; i < 1000; i++ {
+		// Nearly coplanar points: d is an affine combination of a, b and c rounded to the float grid.
+		a, b, c := rv(), rv(), rv()
+		u, v := float32(rng.Float64()), float32(rng.Float64())
+		d := Add(a, Add(Scale(u, Sub(b, a)), Scale(v, Sub(c, a))))
+		want := ratOrient3(a, b, c, d)
+		if got := Orient3D(a, b, c, d); got != want {
+			t.Fatalf("Orient3D(%v,%v,%v,%v)=%d, want %d", a, b, c, d, got, want)
+		}
+		// Nearly collinear points. p lies on line ab if abp is degenerate seen from three independent directions.
+		p := Add(a, Scale(u, Sub(b, a)))
+		wantCollinear := ratOrient3(a, b, p, Add(a, Vec{X: 1})) == 0 &&
+			ratOrient3(a, b, p, Add(a, Vec{Y: 1})) == 0 &&
+			ratOrient3(a, b, p, Add(a, Vec{Z: 1})) == 0
+		if got := Collinear(a, p, b, 0); got != wantCollinear {
+			t.Fatalf("Collinear(%v,%v,%v,0)=%v, want %v", a, p, b, got, wantCollinear)
+		}
+	}
+	if !Collinear(Vec{X: 1, Y: 2, Z: 3}, Vec{X: 2, Y: 4, Z: 6}, Vec{X: 3, Y: 6, Z: 9}, 0) {
+		t.Error("exactly collinear points not reported collinear")
+	}
+}
+
+func ratOrient3(a, b, c, d Vec) int {
+	r := func(f float32) *big.Rat { return new(big.Rat).SetFloat64(float64(f)) }
+	var m [3][3]*big.Rat
+	for i, v := range [3]Vec{b, c, d} {
+		m[i] = [3]*big.Rat{
+			new(big.Rat).Sub(r(v.X), r(a.X)),
+			new(big.Rat).Sub(r(v.Y), r(a.Y)),
+			new(big.Rat).Sub(r(v.Z), r(a.Z)),
+		}
+	}
+	minor := func(i, j, k, l int) *big.Rat {
+		p := new(big.Rat).Mul(m[i][k], m[j][l])
+		return p.Sub(p, new(big.Rat).Mul(m[i][l], m[j][k]))
+	}
+	det := new(big.Rat).Mul(m[0][0], minor(1, 2, 1, 2))
+	det.Sub(det, new(big.Rat).Mul(m[0][1], minor(1, 2, 0, 2)))
+	return det.Add(det, new(big.Rat).Mul(m[0][2], minor(1, 2, 0, 1))).Sign()
+}
@@ -0,0 +1,392 @@
+package ms3
+
+import (
+	"errors"
+)
+
+// NURBS is a non-uniform rational B-spline curve of arbitrary degree, the curve
+// representation used in CAD formats such as STEP, IGES and DXF.
+// A NURBS with all weights equal to 1 is a non-rational (polynomial) B-spline.
+//
+// The curve is defined over the parameter domain returned by [NURBS.Domain].
+// Algorithms are taken from Piegl and Tiller's "The NURBS Book" (2nd ed).
+type NURBS struct {
+	degree  int
+	ctl     []Vec
+	weights []float32
+	knots   []float32
+}
+
+var (
+	errNURBSDegree       = errors.New("NURBS degree must be positive")
+	errNURBSFewPoints    = errors.New("NURBS needs at least degree+1 control points")
+	errNURBSKnotsLen     = errors.New("NURBS knot vector length must be number of control points plus degree plus one")
+	errNURBSKnotsOrder   = errors.New("NURBS knot vector must be non-decreasing")
+	errNURBSWeightsLen   = errors.New("NURBS weights length must match number of control points")
+	errNURBSWeightNonPos = errors.New("NURBS weights must be positive")
+	errNURBSEmptyDomain  = errors.New("NURBS knot vector defines an empty domain")
+	errNURBSKnotDomain   = errors.New("knot outside of NURBS domain interior")
+	errNURBSMultiplicity = errors.New("knot multiplicity would exceed NURBS degree")
+	errNURBSUnclamped    = errors.New("NURBS knot vector not clamped")
+)
+
+// NewNURBS creates a new NURBS curve of the given degree. The control points, weights and knots
+// are copied so the arguments may be reused after the call.
+//   - If weights is nil all weights are set to 1, which results in a non-rational B-spline.
+//   - If knots is nil a clamped uniform knot vector over the domain [0,1] is generated, resulting in a
+//     curve which interpolates the first and last control points.
+func NewNURBS(degree int, controlPoints []Vec, weights, knots []float32) (NURBS, error) {
+	n := len(controlPoints)
+	switch {
+	case degree < 1:
+		return NURBS{}, errNURBSDegree
+	case n < degree+1:
+		return NURBS{}, errNURBSFewPoints
+	case weights != nil && len(weights) != n:
+		return NURBS{}, errNURBSWeightsLen
+	case knots != nil && len(knots) != n+degree+1:
+		return NURBS{}, errNURBSKnotsLen
+	}
+	c := NURBS{
+		degree:  degree,
+		ctl:     append([]Vec(nil), controlPoints...),
+		weights: make([]float32, n),
+	}
+	for i := range c.weights {
+		c.weights[i] = 1
+		if weights != nil {
+			if weights[i] <= 0 {
+				return NURBS{}, errNURBSWeightNonPos
+			}
+			c.weights[i] = weights[i]
+		}
+	}
+	if knots != nil {
+		for i := 1; i < len(knots); i++ {
+			if knots[i] < knots[i-1] {
+				return NURBS{}, errNURBSKnotsOrder
+			}
+		}
+		c.knots = append([]float32(nil), knots...)
+	} else {
+		c.knots = make([]float32, n+degree+1)
+		nInterior := n - degree
+		for i := degree + 1; i < n; i++ {
+			c.knots[i] = float32(i-degree) / float32(nInterior)
+		}
+		for i := n; i < len(c.knots); i++ {
+			c.knots[i] = 1
+		}
+	}
+	if c.knots[degree] == c.knots[n] {
+		return NURBS{}, errNURBSEmptyDomain
+	}
+	return c, nil
+}
+
+// Degree returns the polynomial degree of the curve's basis functions.
+func (c NURBS) Degree() int { return c.degree }
+
+// ControlPoints returns a copy of the curve's control points.
+func (c NURBS) ControlPoints() []Vec { return append([]Vec(nil), c.ctl...) }
+
+// Weights returns a copy of the curve's control point weights.
+func (c NURBS) Weights() []float32 { return append([]float32(nil), c.weights...) }
+
+// Knots returns a copy of the curve's knot vector.
+func (c NURBS) Knots() []float32 { return append([]float32(nil), c.knots...) }
+
+// Domain returns the start and end of the parameter domain over which the curve is defined.
+func (c NURBS) Domain() (tstart, tend float32) {
+	return c.knots[c.degree], c.knots[len(c.ctl)]
+}
+
+// Evaluate returns the point on the curve at parameter t. t is clamped to the curve's domain.
+func (c NURBS) Evaluate(t float32) Vec {
+	t = c.clampDomain(t)
+	span := c.findSpan(t)
+	N := c.basisFuncs(span, t)
+	var cw Vec
+	var w float32
+	for j := range N {
+		idx := span - c.degree + j
+		nw := N[j] * c.weights[idx]
+		cw = Add(cw, Scale(nw, c.ctl[idx]))
+		w += nw
+	}
+	return Scale(1/w, cw)
+}
+
+// AppendDerivs appends the point on the curve at parameter t and its derivatives
+// with respect to t up to the given order to dst and returns the result:
+//
+//	dst = append(dst, C(t), C'(t), C''(t), ...)
+//
+// Derivatives of order higher than the degree of a non-rational curve are zero.
+func (c NURBS) AppendDerivs(dst []Vec, t float32, order int) []Vec {
+	if order < 0 {
+		panic("negative derivative order")
+	}
+	t = c.clampDomain(t)
+	p := c.degree
+	span := c.findSpan(t)
+	nders := order
+	if nders > p {
+		nders = p // Higher basis derivatives are zero.
+	}
+	ders := c.dersBasisFuncs(span, t, nders)
+	// Derivatives of the homogeneous curve A(t)=Σ N·w·P and weight function w(t)=Σ N·w.
+	aders := make([]Vec, order+1)
+	wders := make([]float32, order+1)
+	for k := 0; k <= nders; k++ {
+		for j := 0; j <= p; j++ {
+			idx := span - p + j
+			nw := ders[k][j] * c.weights[idx]
+			aders[k] = Add(aders[k], Scale(nw, c.ctl[idx]))
+			wders[k] += nw
+		}
+	}
+	// Rational curve derivatives, Algorithm A4.2 of The NURBS Book.
+	start := len(dst)
+	for k := 0; k <= order; k++ {
+		v := aders[k]
+		binom := float32(1)
+		for i := 1; i <= k; i++ {
+			binom = binom * float32(k-i+1) / float32(i)
+			v = Sub(v, Scale(binom*wders[i], dst[start+k-i]))
+		}
+		dst = append(dst, Scale(1/wders[0], v))
+	}
+	return dst
+}
+
+// InsertKnot inserts knot t into the curve's knot vector using Boehm's algorithm without changing the curve's shape.
+// t must lie strictly within the curve's domain and the resulting knot multiplicity must not exceed the degree.
+func (c *NURBS) InsertKnot(t float32) error {
+	tstart, tend := c.Domain()
+	if t <= tstart || t >= tend {
+		return errNURBSKnotDomain
+	}
+	p := c.degree
+	span := c.findSpan(t)
+	if c.multiplicity(t) >= p {
+		return errNURBSMultiplicity
+	}
+	n := len(c.ctl)
+	ctl := make([]Vec, n+1)
+	weights := make([]float32, n+1)
+	copy(ctl, c.ctl[:span-p+1])
+	copy(weights, c.weights[:span-p+1])
+	copy(ctl[span+1:], c.ctl[span:])
+	copy(weights[span+1:], c.weights[span:])
+	for i := span - p + 1; i <= span; i++ {
+		alpha := (t - c.knots[i]) / (c.knots[i+p] - c.knots[i])
+		// Interpolate in homogeneous coordinates.
+		w0, w1 := c.weights[i-1], c.weights[i]
+		w := alpha*w1 + (1-alpha)*w0
+		cw := Add(Scale(alpha*w1, c.ctl[i]), Scale((1-alpha)*w0, c.ctl[i-1]))
+		ctl[i] = Scale(1/w, cw)
+		weights[i] = w
+	}
+	knots := make([]float32, len(c.knots)+1)
+	copy(knots, c.knots[:span+1])
+	knots[span+1] = t
+	copy(knots[span+2:], c.knots[span+1:])
+	c.ctl, c.weights, c.knots = ctl, weights, knots
+	return nil
+}
+
+// BezierDecompose returns an equivalent curve with every interior knot inserted up to a multiplicity equal to the degree.
+// The resulting control points and weights, iterated every Degree points, describe the (rational) Bézier segments of the curve:
+// for a cubic curve segment i is given by control points 3*i to 3*i+3. A non-rational cubic result can be evaluated with [SplineBezierCubic].
+// The knot vector must be clamped, which is to say the first and last knots must have a multiplicity of degree+1.
+func (c NURBS) BezierDecompose() (NURBS, error) {
+	p := c.degree
+	n := len(c.ctl)
+	for i := 1; i <= p; i++ {
+		if c.knots[i] != c.knots[0] || c.knots[n+p-i] != c.knots[n+p] {
+			return NURBS{}, errNURBSUnclamped
+		}
+	}
+	// InsertKnot allocates new buffers so the receiver's buffers are not modified.
+	for i := p + 1; i < len(c.ctl); i += c.multiplicity(c.knots[i]) {
+		t := c.knots[i]
+		for m := c.multiplicity(t); m < p; m++ {
+			err := c.InsertKnot(t)
+			if err != nil {
+				return NURBS{}, err
+			}
+		}
+	}
+	return c, nil
+}
+
+// SampleBisect samples the curve over its entire domain using the bisection method of
+// [Spline3Sampler.SampleBisect] over each non-empty knot span and appends the resulting points to dst.
+// Unlike [Spline3Sampler.SampleBisect] the curve extremes are included.
+// maxDepth determines the max amount of times to subdivide each knot span.
+func (c NURBS) SampleBisect(dst []Vec, tolerance float32, maxDepth int) []Vec {
+	if maxDepth <= 0 {
+		panic("invalid depth")
+	} else if tolerance <= 0 {
+		panic("tolerance must be positive")
+	}
+	baseRes := 1.0 / float32(uint(1)<<uint(maxDepth))
+	x := c.Evaluate(c.knots[c.degree])
+	dst = append(dst, x)
+	for i := c.degree; i < len(c.ctl); i++ {
+		t0, t1 := c.knots[i], c.knots[i+1]
+		if t0 == t1 {
+			continue
+		}
+		span := t1 - t0
+		f := func(t float32) Vec { return c.Evaluate(t0 + t*span) }
+		dst = appendBisect(dst, f, tolerance, maxDepth, 0, x, 0, baseRes)
+		x = c.Evaluate(t1)
+		dst = append(dst, x)
+	}
+	return dst
+}
+
+func (c NURBS) clampDomain(t float32) float32 {
+	tstart, tend := c.Domain()
+	if t < tstart {
+		return tstart
+	} else if t > tend {
+		return tend
+	}
+	return t
+}
+
+// findSpan returns the knot span index i such that knots[i] <= t < knots[i+1]. Algorithm A2.1 of The NURBS Book.
+func (c NURBS) findSpan(t float32) int {
+	n := len(c.ctl) - 1
+	if t >= c.knots[n+1] {
+		// Special case: last non-empty span.
+		for n > c.degree && c.knots[n] == c.knots[n+1] {
+			n--
+		}
+		return n
+	}
+	low, high := c.degree, n+1
+	mid := (low + high) / 2
+	for t < c.knots[mid] || t >= c.knots[mid+1] {
+		if t < c.knots[mid] {
+			high = mid
+		} else {
+			low = mid
+		}
+		mid = (low + high) / 2
+	}
+	return mid
+}
+
+// multiplicity returns the amount of times t is repeated in the knot vector.
+func (c NURBS) multiplicity(t float32) (m int) {
+	for _, k := range c.knots {
+		if k == t {
+			m++
+		}
+	}
+	return m
+}
+
+// basisFuncs returns the degree+1 non-vanishing basis functions at t of the given knot span
+// using the Cox-de Boor recursion. Algorithm A2.2 of The NURBS Book.
+func (c NURBS) basisFuncs(span int, t float32) []float32 {
+	p := c.degree
+	buf := make([]float32, 3*(p+1))
+	N, left, right := buf[:p+1], buf[p+1:2*(p+1)], buf[2*(p+1):]
+	N[0] = 1
+	for j := 1; j <= p; j++ {
+		left[j] = t - c.knots[span+1-j]
+		right[j] = c.knots[span+j] - t
+		var saved float32
+		for r := 0; r < j; r++ {
+			tmp := N[r] / (right[r+1] + left[j-r])
+			N[r] = saved + right[r+1]*tmp
+			saved = left[j-r] * tmp
+		}
+		N[j] = saved
+	}
+	return N
+}
+
+// dersBasisFuncs returns the non-vanishing basis functions and their derivatives up to order n
+// at t of the given knot span. ders[k][j] is the kth derivative of basis function span-degree+j.
+// Algorithm A2.3 of The NURBS Book.
+func (c NURBS) dersBasisFuncs(span int, t float32, n int) [][]float32 {
+	p := c.degree
+	ndu := make2D(p+1, p+1)
+	left := make([]float32, p+1)
+	right := make([]float32, p+1)
+	ndu[0][0] = 1
+	for j := 1; j <= p; j++ {
+		left[j] = t - c.knots[span+1-j]
+		right[j] = c.knots[span+j] - t
+		var saved float32
+		for r := 0; r < j; r++ {
+			// Lower triangle stores knot differences.
+			ndu[j][r] = right[r+1] + left[j-r]
+			tmp := ndu[r][j-1] / ndu[j][r]
+			// Upper triangle stores basis functions.
+			ndu[r][j] = saved + right[r+1]*tmp
+			saved = left[j-r] * tmp
+		}
+		ndu[j][j] = saved
+	}
+	ders := make2D(n+1, p+1)
+	for j := 0; j <= p; j++ {
+		ders[0][j] = ndu[j][p]
+	}
+	a := make2D(2, p+1)
+	for r := 0; r <= p; r++ {
+		s1, s2 := 0, 1
+		a[0][0] = 1
+		for k := 1; k <= n; k++ {
+			var d float32
+			rk := r - k
+			pk := p - k
+			if r >= k {
+				a[s2][0] = a[s1][0] / ndu[pk+1][rk]
+				d = a[s2][0] * ndu[rk][pk]
+			}
+			j1 := 1
+			if rk < -1 {
+				j1 = -rk
+			}
+			j2 := k - 1
+			if r-1 > pk {
+				j2 = p - r
+			}
+			for j := j1; j <= j2; j++ {
+				a[s2][j] = (a[s1][j] - a[s1][j-1]) / ndu[pk+1][rk+j]
+				d += a[s2][j] * ndu[rk+j][pk]
+			}
+			if r <= pk {
+				a[s2][k] = -a[s1][k-1] / ndu[pk+1][r]
+				d += a[s2][k] * ndu[r][pk]
+			}
+			ders[k][r] = d
+			s1, s2 = s2, s1
+		}
+	}
+	// Multiply through by the correct factors.
+	factor := float32(p)
+	for k := 1; k <= n; k++ {
+		for j := 0; j <= p; j++ {
+			ders[k][j] *= factor
+		}
+		factor *= float32(p - k)
+	}
+	return ders
+}
+
+func make2D(rows, cols int) [][]float32 {
+	buf := make([]float32, rows*cols)
+	m := make([][]float32, rows)
+	for i := range m {
+		m[i] = buf[i*cols : (i+1)*cols]
+	}
+	return m
+}
@@ -199,9 +199,28 @@ func (oct Octree) CubeSize(c i3.Cube) float32 {
 	return float32(dim) * oct.Resolution
 }
 
+// CubeDDA returns a [GridDDA] that traverses the sub-cubes of root of the argument level pierced by the ray
+// P(t) = origin + t*dir between parameters tmin and tmax. Visited cell indices are converted to cubes with:
+//
+//	cube := i3.Cube{Vec: root.Vec.Add(step.Cell.ShiftLeftScalar(level)), Level: level}
+func (oct Octree) CubeDDA(root i3.Cube, level int, origin, dir Vec, tmin, tmax float32) GridDDA {
+	if level < 1 || level > root.Level {
+		panic("invalid CubeDDA level")
+	}
+	n := 1 << (root.Level - level)
+	return NewGridDDA(oct.CubeBox(root, oct.CubeSize(root)), n, n, n, origin, dir, tmin, tmax)
+}
+
 func max(a, b int) int {
 	if a > b {
 		return a
 	}
 	return b
 }
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
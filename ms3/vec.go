@@ -2,6 +2,7 @@ package ms3
 
 import (
 	math "github.com/chewxy/math32"
+	"github.com/soypat/geometry/internal"
 	"github.com/soypat/geometry/ms1"
 )
 
@@ -264,7 +265,11 @@ func SmoothStepElem(e0, e1, x Vec) Vec {
 // Collinear returns true if the three points a, b and c lie on a common line to
 // within the given tolerance. tol is interpreted as the maximum permissible
 // perpendicular distance from b to the line passing through a and c.
+// If tol is zero collinearity is tested exactly, see [Orient3D].
 func Collinear(a, b, c Vec, tol float32) bool {
+	if tol == 0 {
+		return collinearExact(a, b, c)
+	}
 	ab := Sub(b, a)
 	ac := Sub(c, a)
 	len2AC := Norm2(ac)
@@ -278,3 +283,14 @@ func Collinear(a, b, c Vec, tol float32) bool {
 	//	|ab × ac| / |ac| <= tol   <=>   |ab × ac|² <= tol²·|ac|²
 	return Norm2(Cross(ab, ac)) <= tol*tol*len2AC
 }
+
+// collinearExact reports whether a, b and c lie exactly on a common line, which is
+// the case when their projections onto the three coordinate planes are all collinear.
+func collinearExact(a, b, c Vec) bool {
+	orient := func(ax, ay, bx, by, cx, cy float32) float64 {
+		return internal.Orient2(float64(ax), float64(ay), float64(bx), float64(by), float64(cx), float64(cy))
+	}
+	return orient(a.X, a.Y, b.X, b.Y, c.X, c.Y) == 0 &&
+		orient(a.Y, a.Z, b.Y, b.Z, c.Y, c.Z) == 0 &&
+		orient(a.Z, a.X, b.Z, b.X, c.Z, c.X) == 0
+}